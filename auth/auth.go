@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sns-login/oidc"
+	"time"
+)
+
+const (
+	defaultLoginPath = "/auth/login"
+	// sessionCookieName はセッションIDを保存するCookieの名前
+	sessionCookieName = "session_id"
+	// returnToCookieName はログイン後に戻るURLを保存するCookieの名前
+	returnToCookieName = "return_to"
+	returnToTtl        = 10 * time.Minute
+	// nonceCookieName と codeVerifierCookieName はログインからコールバックまでの間nonceとcode_verifierを保存するCookieの名前
+	nonceCookieName        = "nonce"
+	codeVerifierCookieName = "code_verifier"
+	defaultSessionTtl      = 24 * time.Hour
+	// loginStateCookieName はサーバー側に保存したログイン中の状態のIDを保存するCookieの名前
+	loginStateCookieName = "login_state"
+)
+
+type contextKey int
+
+const userContextKey contextKey = iota
+
+// Handlers はNewAuthenticatorが返すnet/httpのミドルウェアとハンドラ
+//
+// ginやechoなどのアダプタはこのinterfaceで受け取る。
+// FormPostの場合はIdPからPOSTでコールバックされるので、CallbackHandlerはGETとPOSTの両方に登録する
+type Handlers interface {
+	RequireLogin(next http.Handler) http.Handler
+	LoginHandler() http.Handler
+	CallbackHandler() http.Handler
+	LogoutHandler() http.Handler
+}
+
+// authenticator はSNSログインによるセッションの作成とルートの保護を行う
+type authenticator struct {
+	// Provider はログインに使うプロバイダ
+	Provider oidc.Provider
+	// LoginPath は未ログインのユーザーをリダイレクトするログインのハンドラのパス
+	LoginPath string
+	// Secure はCookieにSecure属性を付けるかどうか。本番環境ではtrueにする
+	Secure bool
+	// SameSite はCookieのSameSite属性。0の場合はLax
+	SameSite http.SameSite
+	// FormPost はresponse_mode=form_postでコールバックを受け取るかどうか。Appleでnameやemailを取得する場合などに使う
+	//
+	// form_postではIdPからクロスサイトのPOSTでコールバックされ、SameSite=LaxのCookieは送られない。
+	// trueの場合は認可リクエストにresponse_mode=form_postを含め、コールバックで読むstate、nonceなどのCookieを
+	// SameSite=None; Secureにする。セッションのCookieはコールバックで読まないのでSameSiteの設定に従う
+	FormPost bool
+	// States はstateを保存するストレージ。nilの場合はCookieに保存する
+	States oidc.StateStore
+	// LoginStates を設定するとstate、nonce、code_verifierをサーバー側に保存する。設定した場合はStatesを使わない
+	LoginStates StateStore
+	// SessionTtl はセッションの有効期間。0の場合は24時間
+	SessionTtl time.Duration
+	// OnLogin はセッションを作成する前に呼ばれる。ユーザーをDBに保存する場合などに使い、エラーを返すとログインを失敗させる
+	OnLogin func(ctx context.Context, user *oidc.User) error
+	// LogoutRedirectUrl はログアウト後のリダイレクト先。空の場合は"/"
+	LogoutRedirectUrl string
+	// ErrorHandler はログインのコールバックでエラーが起きた場合に呼ばれる。nilの場合は401を返す
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	// Sessions はセッションを保存するストレージ。既定ではプロセスのメモリに保存する
+	Sessions SessionStore
+	// Metrics はログインの開始、完了、失敗の回数を受け取る。プロバイダの名前にはProviderのIdpを使う。nilの場合は記録しない
+	Metrics oidc.MetricsRecorder
+}
+
+// NewAuthenticator はproviderでログインするauthenticatorを返す
+func NewAuthenticator(provider oidc.Provider) *authenticator {
+	return &authenticator{
+		Provider:  provider,
+		LoginPath: defaultLoginPath,
+		Sessions:  NewMemorySessionStore(),
+	}
+}
+
+// UserFromContext はRequireLoginで保護されたハンドラでログインしているユーザーを返す
+func UserFromContext(ctx context.Context) (*oidc.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*oidc.User)
+
+	return user, ok
+}
+
+// RequireLogin はログインしていないユーザーからnextを保護するミドルウェア
+//
+// セッションが有効な場合はユーザーの情報をcontextに入れてnextを呼ぶ。
+// 未ログインの場合、GETとHEADはアクセスしたURLを保存してLoginPathにリダイレクトし、それ以外は401を返す。
+// セッションを読み込めない場合は500を返す
+func (a *authenticator) RequireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := a.session(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+		if session != nil {
+			// スライディングセッションでストレージの有効期限が延長された場合に、Cookieの有効期限も合わせる
+			a.setSessionCookie(w, session)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, session.User)))
+
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		a.setTemporaryCookie(w, returnToCookieName, r.URL.RequestURI())
+		http.Redirect(w, r, a.LoginPath, http.StatusFound)
+	})
+}
+
+// session はリクエストのCookieのセッションIDから有効なセッションを返す。セッションがない場合はnilを返す
+func (a *authenticator) session(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+
+	session, err := a.Sessions.Get(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sns-login/oidc"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator_RequireLogin(t *testing.T) {
+	a := NewAuthenticator(oidc.NewGoogleOidcClient())
+	user := &oidc.User{IdProvider: oidc.Google, Sub: "1234567890"}
+	_ = a.Sessions.Set(context.Background(), &Session{Id: "valid", User: user}, time.Hour)
+	_ = a.Sessions.Set(context.Background(), &Session{Id: "expired", User: user}, -time.Hour)
+
+	patterns := []struct {
+		desc             string
+		method           string
+		sessionId        string
+		expectedStatus   int
+		expectedReturnTo string
+	}{
+		{"valid session", http.MethodGet, "valid", http.StatusOK, ""},
+		{"no session", http.MethodGet, "", http.StatusFound, "/mypage?tab=profile"},
+		{"expired session", http.MethodGet, "expired", http.StatusFound, "/mypage?tab=profile"},
+		{"unknown session", http.MethodGet, "unknown", http.StatusFound, "/mypage?tab=profile"},
+		{"no session with POST", http.MethodPost, "", http.StatusUnauthorized, ""},
+	}
+
+	for _, pattern := range patterns {
+		var actualUser *oidc.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actualUser, _ = UserFromContext(r.Context())
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(pattern.method, "/mypage?tab=profile", nil)
+		if pattern.sessionId != "" {
+			r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: pattern.sessionId})
+		}
+		a.RequireLogin(next).ServeHTTP(w, r)
+
+		resp := w.Result()
+		assert.Equal(t, pattern.expectedStatus, resp.StatusCode, pattern.desc)
+		if pattern.expectedStatus == http.StatusOK {
+			assert.Same(t, user, actualUser, pattern.desc)
+		} else {
+			assert.Nil(t, actualUser, pattern.desc)
+		}
+		if pattern.expectedStatus == http.StatusFound {
+			assert.Equal(t, defaultLoginPath, resp.Header.Get("Location"), pattern.desc)
+			assert.Equal(t, returnToCookieName, resp.Cookies()[0].Name, pattern.desc)
+			assert.Equal(t, pattern.expectedReturnTo, resp.Cookies()[0].Value, pattern.desc)
+		}
+	}
+}
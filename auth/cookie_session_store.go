@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxSessionCookieSize はブラウザが保存できるCookieの大きさの目安。名前と属性の分を残しておく
+const maxSessionCookieSize = 3800
+
+var (
+	errSessionKeyMissing     = errors.New("at least one session key is required")
+	errSessionCookieTooLarge = errors.New("session is too large to store in cookie")
+)
+
+// cookieSessionStore はセッションの内容を暗号化してCookieに保存するSessionStore
+//
+// サーバー側に何も保存しないので、ストレージを用意せずに複数のインスタンスでセッションを共有できる。
+// SetはセッションのIdを暗号化したセッションの内容に置き換え、authenticatorはそれをCookieの値にする。
+// サーバー側で失効させられないため、ログアウトしてもCookieを控えておけば有効期限までは使えてしまう
+type cookieSessionStore struct {
+	// aeads は復号に使う鍵を新しい順に並べたもの。暗号化には先頭の鍵を使う
+	aeads []cipher.AEAD
+	now   func() time.Time
+}
+
+// NewCookieSessionStore はkeysでセッションをAES-GCMで暗号化するSessionStoreを返す
+//
+// 鍵は16、24、32バイトのいずれか。鍵をローテーションする場合は新しい鍵を先頭に追加し、
+// 古い鍵で暗号化されたセッションが期限切れになってから古い鍵を外す
+func NewCookieSessionStore(keys ...[]byte) (*cookieSessionStore, error) {
+	if len(keys) == 0 {
+		return nil, errSessionKeyMissing
+	}
+
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for _, key := range keys {
+		aead, err := newAesGcm(key)
+		if err != nil {
+			return nil, err
+		}
+		aeads = append(aeads, aead)
+	}
+
+	return &cookieSessionStore{aeads: aeads, now: time.Now}, nil
+}
+
+// Get はidをCookieに保存した暗号文として復号し、有効期限内のセッションを返す
+//
+// 改ざんされたCookieや外した鍵で暗号化されたCookieは復号できないので、未ログインとして扱う
+func (s *cookieSessionStore) Get(_ context.Context, id string) (*Session, error) {
+	data, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, aead := range s.aeads {
+		plain, err := open(aead, data, []byte(sessionCookieName))
+		if err != nil {
+			continue
+		}
+
+		var record sessionRecord
+		if err := json.Unmarshal(plain, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+		if !s.now().Before(record.ExpiresAt) {
+			return nil, nil
+		}
+		session, err := record.session()
+		if err != nil {
+			return nil, err
+		}
+		session.Id = id
+
+		return session, nil
+	}
+
+	return nil, nil
+}
+
+// Set はセッションの内容を先頭の鍵で暗号化し、session.IdをCookieに保存する値に置き換える
+func (s *cookieSessionStore) Set(_ context.Context, session *Session, ttl time.Duration) error {
+	record := newSessionRecord(session, ttl)
+	record.ExpiresAt = s.now().Add(ttl)
+	plain, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	data, err := seal(s.aeads[0], plain, []byte(sessionCookieName))
+	if err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString(data)
+	if len(value) > maxSessionCookieSize {
+		return fmt.Errorf("%w: %d bytes", errSessionCookieTooLarge, len(value))
+	}
+
+	session.Id = value
+	session.ExpiresAt = record.ExpiresAt
+
+	return nil
+}
+
+// Delete はサーバー側にセッションを保存していないので何もしない。Cookieの削除はauthenticatorが行う
+func (s *cookieSessionStore) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// GC はサーバー側にセッションを保存していないので何もしない
+func (s *cookieSessionStore) GC(_ context.Context) error {
+	return nil
+}
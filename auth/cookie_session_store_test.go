@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sns-login/oidc"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCookieSessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := NewCookieSessionStore(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.now = func() time.Time { return now }
+
+	session := sessionForTest(t)
+	assert.Nil(t, store.Set(ctx, session, time.Hour))
+	assert.NotEqual(t, "session-1", session.Id)
+	assert.NotContains(t, session.Id, "DummyRefreshToken")
+
+	loaded, err := store.Get(ctx, session.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, session.Id, loaded.Id)
+	assert.Equal(t, "DummyRefreshToken", loaded.User.Token.RefreshToken)
+	assert.Equal(t, rawIdTokenForTest(), loaded.User.Token.IdToken.Raw())
+
+	tampered := []byte(session.Id)
+	if tampered[20] == 'A' {
+		tampered[20] = 'B'
+	} else {
+		tampered[20] = 'A'
+	}
+
+	patterns := []struct {
+		desc  string
+		value string
+	}{
+		{"tampered", string(tampered)},
+		{"not base64url", "!!!"},
+		{"too short", "AA"},
+	}
+	for _, pattern := range patterns {
+		loaded, err := store.Get(ctx, pattern.value)
+		assert.Nil(t, err, pattern.desc)
+		assert.Nil(t, loaded, pattern.desc)
+	}
+
+	now = now.Add(time.Hour)
+	loaded, err = store.Get(ctx, session.Id)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+}
+
+func TestCookieSessionStore_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	oldStore, err := NewCookieSessionStore(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := sessionForTest(t)
+	assert.Nil(t, oldStore.Set(ctx, session, time.Hour))
+
+	// 新しい鍵を追加しても古い鍵で暗号化されたセッションを読み込める
+	rotated, err := NewCookieSessionStore(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rotated.Get(ctx, session.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234567890", loaded.User.Sub)
+
+	// 新しいセッションは新しい鍵で暗号化される
+	newSession := sessionForTest(t)
+	assert.Nil(t, rotated.Set(ctx, newSession, time.Hour))
+	loaded, err = oldStore.Get(ctx, newSession.Id)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+
+	// 古い鍵を外すと古い鍵のセッションは読み込めない
+	newOnly, err := NewCookieSessionStore(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = newOnly.Get(ctx, session.Id)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+}
+
+func TestNewCookieSessionStore(t *testing.T) {
+	_, err := NewCookieSessionStore()
+	assert.ErrorIs(t, err, errSessionKeyMissing)
+
+	_, err = NewCookieSessionStore([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestCookieSessionStore_TooLarge(t *testing.T) {
+	store, err := NewCookieSessionStore(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := sessionForTest(t)
+	session.User.Token.AccessToken = strings.Repeat("a", maxSessionCookieSize)
+	assert.ErrorIs(t, store.Set(context.Background(), session, time.Hour), errSessionCookieTooLarge)
+}
+
+func TestAuthenticator_CookieSessionStore(t *testing.T) {
+	store, err := NewCookieSessionStore(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(fakeProvider{})
+	a.Sessions = store
+
+	// コールバックで作成したセッションの内容がCookieに保存され、RequireLoginで読み込める
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/auth/callback", nil)
+	if err := a.createSession(w, r, &oidc.User{IdProvider: oidc.Google, Sub: "1234567890"}); err != nil {
+		t.Fatal(err)
+	}
+	sessionCookie := cookiesByNameForTest(w.Result())[sessionCookieName]
+
+	var actualUser *oidc.User
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actualUser, _ = UserFromContext(r.Context())
+	})
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/mypage", nil)
+	r.AddCookie(sessionCookie)
+	a.RequireLogin(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "1234567890", actualUser.Sub)
+}
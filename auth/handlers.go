@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sns-login/oidc"
+	"strings"
+	"time"
+)
+
+// LoginHandler はstate、nonce、PKCEのcode_verifierを保存し、ユーザーをプロバイダのログイン画面にリダイレクトする
+func (a *authenticator) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := oidc.RandomNonce()
+		if err != nil {
+			a.handleError(w, r, err)
+
+			return
+		}
+		state, err := a.issueState(w, r)
+		if err != nil {
+			a.handleError(w, r, err)
+
+			return
+		}
+
+		opts := []oidc.AuthCodeOption{}
+		if a.FormPost {
+			opts = append(opts, oidc.WithResponseMode("form_post"))
+		}
+		loginUrl, pkce, err := a.Provider.LoginUrl(state, nonce, opts...)
+		if err != nil {
+			a.handleError(w, r, err)
+
+			return
+		}
+		if err := a.saveLoginState(w, r, state, nonce, pkce.Verifier); err != nil {
+			a.handleError(w, r, err)
+
+			return
+		}
+
+		a.metrics().LoginStarted(a.Provider.Idp().String())
+		http.Redirect(w, r, loginUrl, http.StatusFound)
+	})
+}
+
+// CallbackHandler はstateを確認して認可コードをプロバイダに渡し、ログインしたユーザーのセッションを作成する
+//
+// セッションの作成後はRequireLoginで保存したURLにリダイレクトする
+func (a *authenticator) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := r.FormValue("error"); code != "" {
+			a.failLogin(w, r, &oidc.AuthError{Code: code, Description: r.FormValue("error_description"), Uri: r.FormValue("error_uri")})
+
+			return
+		}
+		nonce, codeVerifier, err := a.consumeLoginState(w, r)
+		if err != nil {
+			a.failLogin(w, r, err)
+
+			return
+		}
+
+		opts := []oidc.AuthCodeOption{}
+		// プロバイダでPKCEを無効にしている場合はcode_verifierを保存していない
+		if codeVerifier != "" {
+			opts = append(opts, oidc.WithCodeVerifier(codeVerifier))
+		}
+		user, err := a.Provider.Login(r.Context(), r.FormValue("code"), nonce, opts...)
+		if err != nil {
+			a.failLogin(w, r, err)
+
+			return
+		}
+		if a.OnLogin != nil {
+			if err := a.OnLogin(r.Context(), user); err != nil {
+				a.failLogin(w, r, err)
+
+				return
+			}
+		}
+
+		if err := a.createSession(w, r, user); err != nil {
+			a.failLogin(w, r, err)
+
+			return
+		}
+
+		a.metrics().LoginCompleted(a.Provider.Idp().String())
+		redirectUrl := returnTo(r)
+		a.consumeCookie(w, r, returnToCookieName)
+		http.Redirect(w, r, redirectUrl, http.StatusFound)
+	})
+}
+
+// LogoutHandler はセッションを破棄してLogoutRedirectUrlにリダイレクトする
+//
+// 別のサイトからログアウトさせられないように、POSTのみを受け付ける
+func (a *authenticator) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			if err := a.Sessions.Delete(r.Context(), cookie.Value); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+				return
+			}
+		}
+		a.consumeCookie(w, r, sessionCookieName)
+
+		redirectUrl := a.LogoutRedirectUrl
+		if redirectUrl == "" {
+			redirectUrl = "/"
+		}
+		http.Redirect(w, r, redirectUrl, http.StatusFound)
+	})
+}
+
+// createSession は新しいセッションIDでセッションを作成し、Cookieに保存する
+func (a *authenticator) createSession(w http.ResponseWriter, r *http.Request, user *oidc.User) error {
+	// ログイン前のセッションを引き継がず、セッション固定攻撃を防ぐ
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if err := a.Sessions.Delete(r.Context(), cookie.Value); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+	}
+
+	id, err := newSessionId()
+	if err != nil {
+		return fmt.Errorf("failed to generate session id: %w", err)
+	}
+	ttl := a.SessionTtl
+	if ttl <= 0 {
+		ttl = defaultSessionTtl
+	}
+	session := &Session{Id: id, User: user, ExpiresAt: time.Now().Add(ttl)}
+	if err := a.Sessions.Set(r.Context(), session, ttl); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	a.setSessionCookie(w, session)
+
+	return nil
+}
+
+// setSessionCookie はセッションIDをセッションの有効期限までCookieに保存する
+func (a *authenticator) setSessionCookie(w http.ResponseWriter, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session.Id,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		Secure:   a.Secure,
+		HttpOnly: true,
+		SameSite: a.sameSite(),
+	})
+}
+
+// issueState は認可リクエストに含めるstateを発行する
+//
+// LoginStatesが設定されていない場合はStateStoreに保存し、設定されている場合はsaveLoginStateでnonceなどと共に保存する
+func (a *authenticator) issueState(w http.ResponseWriter, r *http.Request) (string, error) {
+	if a.LoginStates == nil {
+		return oidc.NewStateManager(a.stateStore(), 0).Issue(w, r)
+	}
+
+	state, err := newSessionId()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	return state, nil
+}
+
+// saveLoginState はコールバックまでの間state、nonce、code_verifierを保存する
+//
+// LoginStatesが設定されている場合はサーバー側に保存し、ブラウザにはそのIDだけをCookieで渡す
+func (a *authenticator) saveLoginState(w http.ResponseWriter, r *http.Request, state string, nonce string, codeVerifier string) error {
+	if a.LoginStates == nil {
+		a.setTemporaryCookie(w, nonceCookieName, nonce)
+		if codeVerifier != "" {
+			a.setTemporaryCookie(w, codeVerifierCookieName, codeVerifier)
+		}
+
+		return nil
+	}
+
+	id, err := newSessionId()
+	if err != nil {
+		return fmt.Errorf("failed to generate login state id: %w", err)
+	}
+	loginState := &LoginState{State: state, Nonce: nonce, CodeVerifier: codeVerifier}
+	if err := a.LoginStates.Save(r.Context(), id, loginState, returnToTtl); err != nil {
+		return fmt.Errorf("failed to save login state: %w", err)
+	}
+	a.setTemporaryCookie(w, loginStateCookieName, id)
+
+	return nil
+}
+
+// consumeLoginState はコールバックのstateが保存したstateと一致するかを確認し、保存したnonceとcode_verifierを返す
+func (a *authenticator) consumeLoginState(w http.ResponseWriter, r *http.Request) (string, string, error) {
+	if a.LoginStates == nil {
+		if err := oidc.NewStateManager(a.stateStore(), 0).Verify(w, r); err != nil {
+			return "", "", err
+		}
+
+		return a.consumeCookie(w, r, nonceCookieName), a.consumeCookie(w, r, codeVerifierCookieName), nil
+	}
+
+	id := a.consumeCookie(w, r, loginStateCookieName)
+	if id == "" {
+		return "", "", errLoginStateNotFound
+	}
+	saved, err := a.LoginStates.Consume(r.Context(), id)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load login state: %w", err)
+	}
+	if saved == nil {
+		return "", "", errLoginStateNotFound
+	}
+	// form_postの場合はPOSTのボディでstateが返される
+	if subtle.ConstantTimeCompare([]byte(r.FormValue("state")), []byte(saved.State)) != 1 {
+		return "", "", oidc.ErrStateMismatch
+	}
+
+	return saved.Nonce, saved.CodeVerifier, nil
+}
+
+// stateStore はStatesを返す。nilの場合はコールバックまでの間だけ使うCookieと同じ属性のCookieのStateStoreを返す
+func (a *authenticator) stateStore() oidc.StateStore {
+	if a.States != nil {
+		return a.States
+	}
+
+	store := oidc.NewCookieStateStore()
+	store.Secure = a.temporaryCookieSecure()
+	store.SameSite = a.temporaryCookieSameSite()
+
+	return store
+}
+
+// sameSite はセッションのCookieのSameSite属性を返す
+func (a *authenticator) sameSite() http.SameSite {
+	if a.SameSite == 0 {
+		return http.SameSiteLaxMode
+	}
+
+	return a.SameSite
+}
+
+// temporaryCookieSameSite はコールバックで読むCookieのSameSite属性を返す。form_postの場合はクロスサイトのPOSTでも送られるようにNoneにする
+func (a *authenticator) temporaryCookieSameSite() http.SameSite {
+	if a.FormPost {
+		return http.SameSiteNoneMode
+	}
+
+	return a.sameSite()
+}
+
+// temporaryCookieSecure はコールバックで読むCookieにSecure属性を付けるかどうかを返す。SameSite=NoneのCookieはSecureでないとブラウザに拒否される
+func (a *authenticator) temporaryCookieSecure() bool {
+	return a.Secure || a.temporaryCookieSameSite() == http.SameSiteNoneMode
+}
+
+// setTemporaryCookie はコールバックまでの間だけ使う値をCookieに保存する
+func (a *authenticator) setTemporaryCookie(w http.ResponseWriter, name string, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(returnToTtl / time.Second),
+		Secure:   a.temporaryCookieSecure(),
+		HttpOnly: true,
+		SameSite: a.temporaryCookieSameSite(),
+	})
+}
+
+// consumeCookie はCookieの値を返し、再利用できないように削除する
+//
+// 削除するCookieは保存した時と同じ属性にし、ブラウザに元のCookieを確実に上書きさせる
+func (a *authenticator) consumeCookie(w http.ResponseWriter, r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	secure, sameSite := a.temporaryCookieSecure(), a.temporaryCookieSameSite()
+	if name == sessionCookieName {
+		secure, sameSite = a.Secure, a.sameSite()
+	}
+	http.SetCookie(w, &http.Cookie{Name: name, Path: "/", MaxAge: -1, Secure: secure, HttpOnly: true, SameSite: sameSite})
+
+	return cookie.Value
+}
+
+// failLogin はコールバックでのログインの失敗を記録してhandleErrorを呼ぶ
+func (a *authenticator) failLogin(w http.ResponseWriter, r *http.Request, err error) {
+	a.metrics().LoginFailed(a.Provider.Idp().String())
+	a.handleError(w, r, err)
+}
+
+// metrics はMetricsがnilの場合に何も記録しないMetricsRecorderを返す
+func (a *authenticator) metrics() oidc.MetricsRecorder {
+	if a.Metrics == nil {
+		return oidc.NopMetrics
+	}
+
+	return a.Metrics
+}
+
+func (a *authenticator) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if a.ErrorHandler != nil {
+		a.ErrorHandler(w, r, err)
+
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// returnTo はログイン後に戻るURLを返す
+//
+// オープンリダイレクトを防ぐため、同じオリジン内のパスでない場合は"/"を返す
+func returnTo(r *http.Request) string {
+	cookie, err := r.Cookie(returnToCookieName)
+	if err != nil || !strings.HasPrefix(cookie.Value, "/") ||
+		strings.HasPrefix(cookie.Value, "//") || strings.HasPrefix(cookie.Value, "/\\") {
+		return "/"
+	}
+
+	return cookie.Value
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sns-login/oidc"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider はIdPにアクセスせずに認可コードとnonce、code_verifierを確認するProvider
+type fakeProvider struct{}
+
+func (p fakeProvider) LoginUrl(state string, nonce string, opts ...oidc.AuthCodeOption) (string, oidc.Pkce, error) {
+	pkce, err := oidc.NewPkce()
+	if err != nil {
+		return "", oidc.Pkce{}, err
+	}
+	values := url.Values{"state": {state}, "nonce": {nonce}}
+	for _, opt := range append(opts, oidc.WithCodeChallenge(pkce)) {
+		opt(values)
+	}
+
+	return "https://idp.example.com/authorize?" + values.Encode(), pkce, nil
+}
+
+func (p fakeProvider) Login(_ context.Context, code string, nonce string, opts ...oidc.AuthCodeOption) (*oidc.User, error) {
+	values := url.Values{}
+	for _, opt := range opts {
+		opt(values)
+	}
+	if code != "DummyCode" || nonce == "" || values.Get("code_verifier") == "" {
+		return nil, errors.New("invalid login")
+	}
+
+	return &oidc.User{IdProvider: oidc.Google, Sub: "1234567890"}, nil
+}
+
+func (p fakeProvider) Idp() oidc.IdProvider {
+	return oidc.Google
+}
+
+func cookiesByNameForTest(resp *http.Response) map[string]*http.Cookie {
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range resp.Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	return cookies
+}
+
+func TestAuthenticator_LoginAndCallback(t *testing.T) {
+	a := NewAuthenticator(fakeProvider{})
+	var loggedIn *oidc.User
+	a.OnLogin = func(_ context.Context, user *oidc.User) error {
+		loggedIn = user
+
+		return nil
+	}
+
+	// ログイン
+	w := httptest.NewRecorder()
+	a.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	resp := w.Result()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := location.Query()
+	loginCookies := cookiesByNameForTest(resp)
+	assert.Equal(t, query.Get("state"), loginCookies["state"].Value)
+	assert.Equal(t, query.Get("nonce"), loginCookies[nonceCookieName].Value)
+	assert.NotEmpty(t, query.Get("code_challenge"))
+	assert.NotEmpty(t, loginCookies[codeVerifierCookieName].Value)
+
+	patterns := []struct {
+		desc             string
+		query            string
+		returnTo         string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{"valid", "state=" + query.Get("state") + "&code=DummyCode", "/mypage", http.StatusFound, "/mypage"},
+		{"open redirect", "state=" + query.Get("state") + "&code=DummyCode", "//evil.example.com", http.StatusFound, "/"},
+		{"state mismatch", "state=another&code=DummyCode", "/mypage", http.StatusUnauthorized, ""},
+		{"invalid code", "state=" + query.Get("state") + "&code=InvalidCode", "/mypage", http.StatusUnauthorized, ""},
+		{"error response", "state=" + query.Get("state") + "&error=access_denied", "/mypage", http.StatusUnauthorized, ""},
+	}
+
+	for _, pattern := range patterns {
+		loggedIn = nil
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/auth/callback?"+pattern.query, nil)
+		for _, cookie := range loginCookies {
+			r.AddCookie(cookie)
+		}
+		r.AddCookie(&http.Cookie{Name: returnToCookieName, Value: pattern.returnTo})
+		a.CallbackHandler().ServeHTTP(w, r)
+
+		resp := w.Result()
+		assert.Equal(t, pattern.expectedStatus, resp.StatusCode, pattern.desc)
+		if pattern.expectedStatus != http.StatusFound {
+			assert.Nil(t, loggedIn, pattern.desc)
+
+			continue
+		}
+		assert.Equal(t, pattern.expectedLocation, resp.Header.Get("Location"), pattern.desc)
+		assert.Equal(t, "1234567890", loggedIn.Sub, pattern.desc)
+
+		// 作成したセッションでRequireLoginを通過できる
+		sessionCookie := cookiesByNameForTest(resp)[sessionCookieName]
+		assert.True(t, sessionCookie.HttpOnly, pattern.desc)
+		session, err := a.Sessions.Get(context.Background(), sessionCookie.Value)
+		assert.Nil(t, err, pattern.desc)
+		assert.Same(t, loggedIn, session.User, pattern.desc)
+	}
+}
+
+func TestAuthenticator_FormPost(t *testing.T) {
+	a := NewAuthenticator(fakeProvider{})
+	a.FormPost = true
+
+	w := httptest.NewRecorder()
+	a.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	resp := w.Result()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "form_post", location.Query().Get("response_mode"))
+
+	// クロスサイトのPOSTでも送られるように、コールバックで読むCookieはSameSite=None; Secureにする
+	loginCookies := cookiesByNameForTest(resp)
+	for _, name := range []string{"state", nonceCookieName, codeVerifierCookieName} {
+		assert.Equal(t, http.SameSiteNoneMode, loginCookies[name].SameSite, name)
+		assert.True(t, loginCookies[name].Secure, name)
+	}
+
+	form := url.Values{"state": {location.Query().Get("state")}, "code": {"DummyCode"}}
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/auth/callback", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range loginCookies {
+		r.AddCookie(cookie)
+	}
+	a.CallbackHandler().ServeHTTP(w, r)
+	resp = w.Result()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	// 削除するCookieも同じ属性にして元のCookieを上書きする
+	callbackCookies := cookiesByNameForTest(resp)
+	for _, name := range []string{"state", nonceCookieName, codeVerifierCookieName} {
+		assert.Equal(t, -1, callbackCookies[name].MaxAge, name)
+		assert.Equal(t, http.SameSiteNoneMode, callbackCookies[name].SameSite, name)
+		assert.True(t, callbackCookies[name].Secure, name)
+	}
+	assert.Equal(t, http.SameSiteLaxMode, callbackCookies[sessionCookieName].SameSite)
+}
+
+func TestAuthenticator_LogoutHandler(t *testing.T) {
+	a := NewAuthenticator(fakeProvider{})
+	a.LogoutRedirectUrl = "/bye"
+	_ = a.Sessions.Set(context.Background(), &Session{Id: "valid", User: &oidc.User{}}, time.Hour)
+
+	// GETではログアウトしない
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/auth/logout", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "valid"})
+	a.LogoutHandler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	session, _ := a.Sessions.Get(context.Background(), "valid")
+	assert.NotNil(t, session)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "valid"})
+	a.LogoutHandler().ServeHTTP(w, r)
+	resp := w.Result()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/bye", resp.Header.Get("Location"))
+	session, _ = a.Sessions.Get(context.Background(), "valid")
+	assert.Nil(t, session)
+	assert.Equal(t, -1, cookiesByNameForTest(resp)[sessionCookieName].MaxAge)
+}
+
+func TestAuthenticator_LoginStates(t *testing.T) {
+	// ログインを始めたインスタンスとは別のインスタンスでコールバックを処理する
+	states := NewMemoryStateStore()
+	loginInstance := NewAuthenticator(fakeProvider{})
+	loginInstance.LoginStates = states
+	callbackInstance := NewAuthenticator(fakeProvider{})
+	callbackInstance.LoginStates = states
+
+	w := httptest.NewRecorder()
+	loginInstance.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	resp := w.Result()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginCookies := cookiesByNameForTest(resp)
+	// nonceとcode_verifierはCookieに保存しない
+	assert.NotContains(t, loginCookies, nonceCookieName)
+	assert.NotContains(t, loginCookies, codeVerifierCookieName)
+	assert.NotEmpty(t, location.Query().Get("nonce"))
+
+	// 失敗するコールバックでログインした状態を消費しないように、別のIDで保存した状態を使う
+	_ = states.Save(context.Background(), "another-login", &LoginState{State: location.Query().Get("state")}, time.Minute)
+
+	patterns := []struct {
+		desc           string
+		state          string
+		loginStateId   string
+		expectedStatus int
+		expectedErr    error
+	}{
+		{"state mismatch", "another", "another-login", http.StatusUnauthorized, oidc.ErrStateMismatch},
+		{"no login state cookie", location.Query().Get("state"), "", http.StatusUnauthorized, errLoginStateNotFound},
+		{"unknown login state", location.Query().Get("state"), "unknown", http.StatusUnauthorized, errLoginStateNotFound},
+	}
+
+	var callbackErr error
+	callbackInstance.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, err error) {
+		callbackErr = err
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	}
+	for _, pattern := range patterns {
+		callbackErr = nil
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/auth/callback?code=DummyCode&state="+pattern.state, nil)
+		if pattern.loginStateId != "" {
+			r.AddCookie(&http.Cookie{Name: loginStateCookieName, Value: pattern.loginStateId})
+		}
+		callbackInstance.CallbackHandler().ServeHTTP(w, r)
+		assert.Equal(t, pattern.expectedStatus, w.Result().StatusCode, pattern.desc)
+		assert.ErrorIs(t, callbackErr, pattern.expectedErr, pattern.desc)
+	}
+
+	callback := func() *http.Response {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/auth/callback?code=DummyCode&state="+location.Query().Get("state"), nil)
+		r.AddCookie(loginCookies[loginStateCookieName])
+		callbackInstance.CallbackHandler().ServeHTTP(w, r)
+
+		return w.Result()
+	}
+	resp = callback()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Contains(t, cookiesByNameForTest(resp), sessionCookieName)
+
+	// 同じstateでコールバックを繰り返すことはできない
+	assert.Equal(t, http.StatusUnauthorized, callback().StatusCode)
+}
+
+// countingMetricsForTest はログインの開始、完了、失敗の回数を数えるMetricsRecorder
+type countingMetricsForTest struct {
+	oidc.MetricsRecorder
+	started, completed, failed map[string]int
+}
+
+func (m *countingMetricsForTest) LoginStarted(provider string)   { m.started[provider]++ }
+func (m *countingMetricsForTest) LoginCompleted(provider string) { m.completed[provider]++ }
+func (m *countingMetricsForTest) LoginFailed(provider string)    { m.failed[provider]++ }
+
+func TestAuthenticator_Metrics(t *testing.T) {
+	metrics := &countingMetricsForTest{MetricsRecorder: oidc.NopMetrics, started: map[string]int{}, completed: map[string]int{}, failed: map[string]int{}}
+	a := NewAuthenticator(fakeProvider{})
+	a.Metrics = metrics
+
+	w := httptest.NewRecorder()
+	a.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	resp := w.Result()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginCookies := cookiesByNameForTest(resp)
+
+	for _, code := range []string{"InvalidCode", "DummyCode"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/auth/callback?state="+location.Query().Get("state")+"&code="+code, nil)
+		for _, cookie := range loginCookies {
+			r.AddCookie(cookie)
+		}
+		a.CallbackHandler().ServeHTTP(w, r)
+	}
+
+	// ラベルはid_tokenの検証の計測値と同じIdProviderの名前になる
+	assert.Equal(t, map[string]int{"Google": 1}, metrics.started)
+	assert.Equal(t, map[string]int{"Google": 1}, metrics.completed)
+	assert.Equal(t, map[string]int{"Google": 1}, metrics.failed)
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sns-login/oidc"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionIdBytes はセッションIDとして生成する乱数のバイト数
+	sessionIdBytes = 32
+	// defaultMaxSessions はmemorySessionStoreに保存できるセッション数の既定値
+	defaultMaxSessions = 100000
+)
+
+var errSessionStoreFull = errors.New("session store is full")
+
+// Session はログインしたユーザーのセッション
+type Session struct {
+	Id string
+	// User はログイン時にプロバイダから取得し、検証したユーザーの情報
+	User      *oidc.User
+	ExpiresAt time.Time
+}
+
+// SessionStore はセッションを保存するストレージ
+//
+// 複数のインスタンスでセッションを共有する場合はRedisやDBに保存する実装に差し替える
+type SessionStore interface {
+	// Get は有効期限内のセッションを返す。見つからない場合や期限切れの場合はnilを返す
+	Get(ctx context.Context, id string) (*Session, error)
+	// Set はセッションをttlの間保存する。同じIDのセッションは上書きする
+	//
+	// Cookieに内容を保存する実装ではsession.IdをCookieに保存する値に置き換える
+	Set(ctx context.Context, session *Session, ttl time.Duration) error
+	// Delete はセッションを削除する。見つからない場合もエラーにしない
+	Delete(ctx context.Context, id string) error
+	// GC は期限切れのセッションを削除する。ストレージが期限切れのデータを自動で削除する場合は何もしない
+	GC(ctx context.Context) error
+}
+
+// memorySessionStore はセッションをプロセスのメモリに保存するSessionStore
+//
+// 期限切れのセッションはGCで削除する。メモリを使い果たさないように保存できる数に上限を持つ
+type memorySessionStore struct {
+	// MaxSessions は保存できるセッションの数。上限に達した場合は期限切れのセッションを削除し、
+	// それでも空きがなければSetがエラーを返す
+	MaxSessions int
+	mu          sync.RWMutex
+	sessions    map[string]*Session
+	now         func() time.Time
+}
+
+// NewMemorySessionStore はセッションをメモリに保存するSessionStoreを返す
+func NewMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{MaxSessions: defaultMaxSessions, sessions: map[string]*Session{}, now: time.Now}
+}
+
+func (s *memorySessionStore) Get(_ context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok || !s.now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+
+	return session, nil
+}
+
+func (s *memorySessionStore) Set(_ context.Context, session *Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[session.Id]; !ok && len(s.sessions) >= s.MaxSessions {
+		s.deleteExpired()
+		if len(s.sessions) >= s.MaxSessions {
+			return errSessionStoreFull
+		}
+	}
+
+	stored := *session
+	stored.ExpiresAt = s.now().Add(ttl)
+	s.sessions[session.Id] = &stored
+
+	return nil
+}
+
+func (s *memorySessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+
+	return nil
+}
+
+func (s *memorySessionStore) GC(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteExpired()
+
+	return nil
+}
+
+// deleteExpired は期限切れのセッションを削除する。muをロックしてから呼ぶ
+func (s *memorySessionStore) deleteExpired() {
+	now := s.now()
+	for id, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// RunGC はctxがキャンセルされるまでintervalごとにstoreのGCを呼ぶ
+//
+// 期限切れのセッションが溜まり続けないように、goroutineで起動しておく
+func RunGC(ctx context.Context, store SessionStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = store.GC(ctx)
+		}
+	}
+}
+
+// newSessionId は推測できないセッションIDを生成する
+func newSessionId() (string, error) {
+	b := make([]byte, sessionIdBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sns-login/oidc"
+	"time"
+)
+
+var errCiphertextTooShort = errors.New("ciphertext is too short")
+
+// sessionRecord はプロセスの外に保存するためにSessionをJSONにした形
+//
+// 検証済みのid_tokenはそのままmarshalできないので、生のJWTとして保存して読み込み時に復元する
+type sessionRecord struct {
+	Id            string          `json:"id"`
+	IdProvider    oidc.IdProvider `json:"idp"`
+	Sub           string          `json:"sub"`
+	Email         string          `json:"email,omitempty"`
+	EmailVerified bool            `json:"email_verified,omitempty"`
+	Name          string          `json:"name,omitempty"`
+	Username      string          `json:"username,omitempty"`
+	Picture       string          `json:"picture,omitempty"`
+	Token         *tokenRecord    `json:"token,omitempty"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	// Ttl はスライディングセッションで有効期限を延長する長さ
+	Ttl time.Duration `json:"ttl"`
+}
+
+// tokenRecord はoidc.TokenをJSONにした形
+type tokenRecord struct {
+	AccessToken  string    `json:"access_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	IdToken      string    `json:"id_token,omitempty"`
+	// IdProvider はid_tokenをパースするときに使うプロバイダ
+	IdProvider oidc.IdProvider `json:"id_token_idp,omitempty"`
+}
+
+func newSessionRecord(session *Session, ttl time.Duration) sessionRecord {
+	record := sessionRecord{Id: session.Id, ExpiresAt: session.ExpiresAt, Ttl: ttl}
+	if user := session.User; user != nil {
+		record.IdProvider = user.IdProvider
+		record.Sub = user.Sub
+		record.Email = user.Email
+		record.EmailVerified = user.EmailVerified
+		record.Name = user.Name
+		record.Username = user.Username
+		record.Picture = user.Picture
+		if user.Token != nil {
+			record.Token = newTokenRecord(user.Token)
+		}
+	}
+
+	return record
+}
+
+// session はsessionRecordをSessionに戻す
+func (record sessionRecord) session() (*Session, error) {
+	user := &oidc.User{
+		IdProvider:    record.IdProvider,
+		Sub:           record.Sub,
+		Email:         record.Email,
+		EmailVerified: record.EmailVerified,
+		Name:          record.Name,
+		Username:      record.Username,
+		Picture:       record.Picture,
+	}
+	if record.Token != nil {
+		token, err := record.Token.token()
+		if err != nil {
+			return nil, err
+		}
+		user.Token = token
+	}
+
+	return &Session{Id: record.Id, User: user, ExpiresAt: record.ExpiresAt}, nil
+}
+
+func newTokenRecord(token *oidc.Token) *tokenRecord {
+	record := &tokenRecord{
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		RefreshToken: token.RefreshToken,
+		Scope:        token.Scope,
+		Expiry:       token.Expiry,
+	}
+	if token.IdToken != nil {
+		record.IdToken = token.IdToken.Raw()
+		record.IdProvider = token.IdToken.IdProvider
+	}
+
+	return record
+}
+
+// token はtokenRecordをoidc.Tokenに戻す。id_tokenはログイン時に検証済みなので、再検証せずにパースだけ行う
+func (record tokenRecord) token() (*oidc.Token, error) {
+	token := &oidc.Token{
+		AccessToken:  record.AccessToken,
+		TokenType:    record.TokenType,
+		RefreshToken: record.RefreshToken,
+		Scope:        record.Scope,
+		Expiry:       record.Expiry,
+	}
+	if record.IdToken != "" {
+		idToken, err := oidc.NewIdToken(record.IdToken, record.IdProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stored id_token: %w", err)
+		}
+		token.IdToken = idToken
+	}
+
+	return token, nil
+}
+
+// EncodeSession はsessionをプロセスの外のストレージに保存するためのデータにする
+//
+// ttlはスライディングセッションで有効期限を延長する長さとして一緒に保存する。
+// aeadがnilでない場合はセッションIDを追加データとして暗号化する
+func EncodeSession(session *Session, ttl time.Duration, aead cipher.AEAD) ([]byte, error) {
+	return encodeSessionRecord(newSessionRecord(session, ttl), aead)
+}
+
+// DecodeSession はEncodeSessionで保存したデータをセッションと保存時のttlに戻す
+func DecodeSession(id string, data []byte, aead cipher.AEAD) (*Session, time.Duration, error) {
+	record, err := decodeSessionRecord(id, data, aead)
+	if err != nil {
+		return nil, 0, err
+	}
+	session, err := record.session()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return session, record.Ttl, nil
+}
+
+// encodeSessionRecord はrecordをJSONにする。aeadがnilでない場合はセッションIDを追加データとして暗号化する
+func encodeSessionRecord(record sessionRecord, aead cipher.AEAD) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if aead == nil {
+		return data, nil
+	}
+
+	return seal(aead, data, []byte(record.Id))
+}
+
+// decodeSessionRecord はencodeSessionRecordで保存したデータを読み込む
+//
+// 追加データのセッションIDが一致しない場合は復号に失敗するので、別のセッションのデータに差し替えられても検出できる
+func decodeSessionRecord(id string, data []byte, aead cipher.AEAD) (sessionRecord, error) {
+	var record sessionRecord
+	if aead != nil {
+		plain, err := open(aead, data, []byte(id))
+		if err != nil {
+			return record, err
+		}
+		data = plain
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	return record, nil
+}
+
+// seal はランダムなnonceでplainを暗号化し、nonceを先頭に付けて返す
+func seal(aead cipher.AEAD, plain []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+// open はsealで暗号化したデータを復号する
+func open(aead cipher.AEAD, data []byte, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errCiphertextTooShort
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return plain, nil
+}
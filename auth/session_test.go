@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"sns-login/oidc"
+	"testing"
+	"time"
+)
+
+// rawIdTokenForTest は署名を検証しないテスト用のid_tokenを返す
+func rawIdTokenForTest() string {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(`{"sub":"1234567890"}`)) + "." + encode([]byte("signature"))
+}
+
+func sessionForTest(t *testing.T) *Session {
+	idToken, err := oidc.NewIdToken(rawIdTokenForTest(), oidc.GenericOidc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Session{Id: "session-1", User: &oidc.User{
+		IdProvider: oidc.GenericOidc,
+		Sub:        "1234567890",
+		Email:      "user@example.com",
+		Token:      &oidc.Token{AccessToken: "DummyAccessToken", RefreshToken: "DummyRefreshToken", IdToken: idToken},
+	}}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemorySessionStore()
+	store.now = func() time.Time { return now }
+
+	assert.Nil(t, store.Set(ctx, &Session{Id: "session-1"}, time.Minute))
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(time.Minute), session.ExpiresAt)
+
+	// 期限切れのセッションは返さない
+	now = now.Add(time.Minute)
+	session, err = store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	// 見つからないセッションの削除はエラーにしない
+	assert.Nil(t, store.Delete(ctx, "unknown"))
+
+	assert.Nil(t, store.GC(ctx))
+	assert.Empty(t, store.sessions)
+}
+
+func TestMemorySessionStore_MaxSessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemorySessionStore()
+	store.MaxSessions = 2
+	store.now = func() time.Time { return now }
+
+	assert.Nil(t, store.Set(ctx, &Session{Id: "expired"}, -time.Minute))
+	assert.Nil(t, store.Set(ctx, &Session{Id: "session-1"}, time.Minute))
+
+	// 上限に達した場合は期限切れのセッションを削除して保存する
+	assert.Nil(t, store.Set(ctx, &Session{Id: "session-2"}, time.Minute))
+	assert.NotContains(t, store.sessions, "expired")
+
+	assert.ErrorIs(t, store.Set(ctx, &Session{Id: "session-3"}, time.Minute), errSessionStoreFull)
+	// 保存済みのセッションは上限に達していても更新できる
+	assert.Nil(t, store.Set(ctx, &Session{Id: "session-1"}, time.Hour))
+}
+
+func TestRunGC(t *testing.T) {
+	store := NewMemorySessionStore()
+	_ = store.Set(context.Background(), &Session{Id: "expired"}, -time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunGC(ctx, store, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		store.mu.RLock()
+		defer store.mu.RUnlock()
+
+		return len(store.sessions) == 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestEncodeSession(t *testing.T) {
+	session := sessionForTest(t)
+	session.ExpiresAt = time.Now().Add(time.Hour).Truncate(time.Second)
+	data, err := EncodeSession(session, time.Hour, nil)
+	assert.Nil(t, err)
+
+	decoded, ttl, err := DecodeSession("session-1", data, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, ttl)
+	assert.True(t, session.ExpiresAt.Equal(decoded.ExpiresAt))
+	assert.Equal(t, "user@example.com", decoded.User.Email)
+	assert.Equal(t, rawIdTokenForTest(), decoded.User.Token.IdToken.Raw())
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultSessionTable = "sessions"
+
+// SqlDialect はsqlSessionStoreが発行するSQLの方言
+type SqlDialect int
+
+const (
+	SqliteDialect SqlDialect = iota
+	MySqlDialect
+	PostgresDialect
+)
+
+// sqlSessionStore はセッションをdatabase/sqlのDBに保存するSessionStore
+//
+// Redisを使わない構成でも複数のインスタンスでセッションを共有できる。
+// テーブルはMigrateで作成し、期限切れのセッションはRunGCなどで定期的にGCを呼んで削除する
+type sqlSessionStore struct {
+	// Table はセッションを保存するテーブルの名前。SQLにそのまま埋め込むので、ユーザーの入力を使わない
+	Table string
+	// Cipher を設定するとトークンを含むセッションの内容を暗号化して保存する
+	Cipher  cipher.AEAD
+	db      *sql.DB
+	dialect SqlDialect
+	now     func() time.Time
+}
+
+// NewSqlSessionStore はdbのsessionsテーブルにセッションを保存するSessionStoreを返す
+func NewSqlSessionStore(db *sql.DB, dialect SqlDialect) *sqlSessionStore {
+	return &sqlSessionStore{Table: defaultSessionTable, db: db, dialect: dialect, now: time.Now}
+}
+
+// Migrations はセッションのテーブルとインデックスを作成するSQLを返す
+//
+// マイグレーションツールでスキーマを管理している場合はこのSQLを組み込み、そうでない場合はMigrateを使う
+func (s *sqlSessionStore) Migrations() []string {
+	switch s.dialect {
+	case MySqlDialect:
+		// MySQLはCREATE INDEX IF NOT EXISTSに対応していないので、インデックスをテーブルと一緒に作成する
+		return []string{fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL PRIMARY KEY, data BLOB NOT NULL, expires_at BIGINT NOT NULL, INDEX %s_expires_at (expires_at))",
+			s.Table, s.Table,
+		)}
+	case PostgresDialect:
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL PRIMARY KEY, data BYTEA NOT NULL, expires_at BIGINT NOT NULL)", s.Table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_expires_at ON %s (expires_at)", s.Table, s.Table),
+		}
+	default:
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL PRIMARY KEY, data BLOB NOT NULL, expires_at BIGINT NOT NULL)", s.Table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_expires_at ON %s (expires_at)", s.Table, s.Table),
+		}
+	}
+}
+
+// Migrate はセッションのテーブルがなければ作成する。何度実行してもよい
+func (s *sqlSessionStore) Migrate(ctx context.Context) error {
+	for _, query := range s.Migrations() {
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to migrate session table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	query := s.bind(fmt.Sprintf("SELECT data FROM %s WHERE id = ? AND expires_at > ?", s.Table))
+
+	var data []byte
+	err := s.db.QueryRowContext(ctx, query, id, s.now().UnixMilli()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session from database: %w", err)
+	}
+
+	record, err := decodeSessionRecord(id, data, s.Cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.session()
+}
+
+func (s *sqlSessionStore) Set(ctx context.Context, session *Session, ttl time.Duration) error {
+	record := newSessionRecord(session, ttl)
+	record.ExpiresAt = s.now().Add(ttl)
+	data, err := encodeSessionRecord(record, s.Cipher)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, s.bind(s.upsertQuery()), record.Id, data, record.ExpiresAt.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to save session to database: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlSessionStore) Delete(ctx context.Context, id string) error {
+	query := s.bind(fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.Table))
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete session from database: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlSessionStore) GC(ctx context.Context) error {
+	query := s.bind(fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", s.Table))
+	if _, err := s.db.ExecContext(ctx, query, s.now().UnixMilli()); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return nil
+}
+
+// upsertQuery は同じIDのセッションを上書きするINSERT文を返す
+func (s *sqlSessionStore) upsertQuery() string {
+	if s.dialect == MySqlDialect {
+		return fmt.Sprintf(
+			"INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)",
+			s.Table,
+		)
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at",
+		s.Table,
+	)
+}
+
+// bind はPostgreSQLの場合にプレースホルダの?を$1、$2...に置き換える
+func (s *sqlSessionStore) bind(query string) string {
+	if s.dialect != PostgresDialect {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "$%d", n)
+	}
+
+	return b.String()
+}
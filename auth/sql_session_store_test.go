@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newSqlSessionStoreForTest(t *testing.T) *sqlSessionStore {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// :memory:のDBは接続ごとに別になるので、接続を1つに制限する
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := NewSqlSessionStore(db, SqliteDialect)
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestSqlSessionStore(t *testing.T) {
+	ctx := context.Background()
+	store := newSqlSessionStoreForTest(t)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	// マイグレーションは何度実行してもよい
+	assert.Nil(t, store.Migrate(ctx))
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Hour))
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "user@example.com", session.User.Email)
+	assert.Equal(t, "DummyRefreshToken", session.User.Token.RefreshToken)
+	assert.Equal(t, rawIdTokenForTest(), session.User.Token.IdToken.Raw())
+
+	// 同じIDで保存すると上書きされる
+	updated := sessionForTest(t)
+	updated.User.Email = "updated@example.com"
+	assert.Nil(t, store.Set(ctx, updated, time.Hour))
+	session, err = store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "updated@example.com", session.User.Email)
+
+	session, err = store.Get(ctx, "unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	assert.Nil(t, store.Delete(ctx, "session-1"))
+	session, err = store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+}
+
+func TestSqlSessionStore_GC(t *testing.T) {
+	ctx := context.Background()
+	store := newSqlSessionStoreForTest(t)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Minute))
+	now = now.Add(time.Minute)
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	assert.Nil(t, store.GC(ctx))
+	var count int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, count)
+}
+
+func TestSqlSessionStore_Bind(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		dialect  SqlDialect
+		expected string
+	}{
+		{"sqlite", SqliteDialect, "DELETE FROM sessions WHERE id = ? AND expires_at > ?"},
+		{"mysql", MySqlDialect, "DELETE FROM sessions WHERE id = ? AND expires_at > ?"},
+		{"postgres", PostgresDialect, "DELETE FROM sessions WHERE id = $1 AND expires_at > $2"},
+	}
+
+	for _, pattern := range patterns {
+		store := NewSqlSessionStore(nil, pattern.dialect)
+		assert.Equal(t, pattern.expected, store.bind("DELETE FROM sessions WHERE id = ? AND expires_at > ?"), pattern.desc)
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultMaxLoginStates はmemoryStateStoreに保存できるログイン中の状態の数の既定値
+const defaultMaxLoginStates = 100000
+
+var (
+	errStateStoreFull     = errors.New("state store is full")
+	errLoginStateNotFound = errors.New("login state not found")
+)
+
+// LoginState はログインからコールバックまでの間サーバー側に保存する値
+type LoginState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// StateStore はログイン中のstate、nonce、code_verifierをサーバー側に保存するストレージ
+//
+// ブラウザとはCookieに保存したランダムなIDで紐付ける。
+// 複数のインスタンスで共有するストレージを使えば、ログインを始めたインスタンスとは別のインスタンスでコールバックを処理できる
+type StateStore interface {
+	// Save はidでstateをttlの間保存する
+	Save(ctx context.Context, id string, state *LoginState, ttl time.Duration) error
+	// Consume はidで保存したstateを取り出し、再利用できないように削除する。見つからない場合や期限切れの場合はnilを返す
+	Consume(ctx context.Context, id string) (*LoginState, error)
+}
+
+type memoryLoginState struct {
+	state     *LoginState
+	expiresAt time.Time
+}
+
+// memoryStateStore はログイン中の状態をプロセスのメモリに保存するStateStore
+type memoryStateStore struct {
+	// MaxStates は保存できる数。上限に達した場合は期限切れのものを削除し、それでも空きがなければSaveがエラーを返す
+	MaxStates int
+	mu        sync.Mutex
+	states    map[string]memoryLoginState
+	now       func() time.Time
+}
+
+// NewMemoryStateStore はログイン中の状態をメモリに保存するStateStoreを返す
+func NewMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{MaxStates: defaultMaxLoginStates, states: map[string]memoryLoginState{}, now: time.Now}
+}
+
+func (s *memoryStateStore) Save(_ context.Context, id string, state *LoginState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.states[id]; !ok && len(s.states) >= s.MaxStates {
+		now := s.now()
+		for id, saved := range s.states {
+			if !now.Before(saved.expiresAt) {
+				delete(s.states, id)
+			}
+		}
+		if len(s.states) >= s.MaxStates {
+			return errStateStoreFull
+		}
+	}
+	s.states[id] = memoryLoginState{state: state, expiresAt: s.now().Add(ttl)}
+
+	return nil
+}
+
+func (s *memoryStateStore) Consume(_ context.Context, id string) (*LoginState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, ok := s.states[id]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.states, id)
+	if !s.now().Before(saved.expiresAt) {
+		return nil, nil
+	}
+
+	return saved.state, nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStateStore()
+	store.now = func() time.Time { return now }
+
+	assert.Nil(t, store.Save(ctx, "login-1", &LoginState{State: "DummyState", Nonce: "DummyNonce"}, time.Minute))
+	state, err := store.Consume(ctx, "login-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyNonce", state.Nonce)
+
+	// 取り出せるのは1回だけ
+	state, err = store.Consume(ctx, "login-1")
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	assert.Nil(t, store.Save(ctx, "login-2", &LoginState{State: "DummyState"}, time.Minute))
+	now = now.Add(time.Minute)
+	state, err = store.Consume(ctx, "login-2")
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+}
+
+func TestMemoryStateStore_MaxStates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStateStore()
+	store.MaxStates = 1
+	store.now = func() time.Time { return now }
+
+	assert.Nil(t, store.Save(ctx, "expired", &LoginState{}, -time.Minute))
+	// 上限に達した場合は期限切れのものを削除して保存する
+	assert.Nil(t, store.Save(ctx, "login-1", &LoginState{}, time.Minute))
+	assert.ErrorIs(t, store.Save(ctx, "login-2", &LoginState{}, time.Minute), errStateStoreFull)
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sns-login/oidc"
+	"sync"
+)
+
+// dataKeyBytes はトークンごとに生成するデータ鍵のバイト数。AES-256を使う
+const dataKeyBytes = 32
+
+var (
+	errTokenKeyNotFound  = errors.New("key encryption key not found")
+	errTokenKeyIdMissing = errors.New("current key id is not in key source")
+)
+
+// TokenStore はリフレッシュトークンなどのトークンをセッションIDやユーザーをキーにして保存するストレージ
+type TokenStore interface {
+	// Get はkeyで保存したトークンを返す。見つからない場合はnilを返す
+	Get(ctx context.Context, key string) (*oidc.Token, error)
+	// Set はtokenをkeyで保存する。同じkeyのトークンは上書きする
+	Set(ctx context.Context, key string, token *oidc.Token) error
+	// Delete はkeyのトークンを削除する。見つからない場合もエラーにしない
+	Delete(ctx context.Context, key string) error
+}
+
+// TokenBackend はencryptedTokenStoreが暗号化したトークンを保存する先
+//
+// RedisやDBに保存する場合はこのinterfaceを実装する。見つからない場合はLoadがnilを返す
+type TokenBackend interface {
+	Load(ctx context.Context, key string) ([]byte, error)
+	Save(ctx context.Context, key string, data []byte) error
+	Remove(ctx context.Context, key string) error
+}
+
+// KeySource はエンベロープ暗号化でデータ鍵を暗号化する鍵暗号化鍵を持つ
+//
+// 鍵暗号化鍵をKMSで管理する場合は、データ鍵の暗号化と復号をKMSのAPIに任せる実装にする
+type KeySource interface {
+	// WrapKey はデータ鍵を現在の鍵暗号化鍵で暗号化し、使った鍵のIDと一緒に返す
+	WrapKey(ctx context.Context, dataKey []byte) (keyId string, wrapped []byte, err error)
+	// UnwrapKey はkeyIdの鍵暗号化鍵でデータ鍵を復号する
+	UnwrapKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error)
+}
+
+// encryptedTokenStore はトークンをAES-GCMのエンベロープ暗号化で暗号化してbackendに保存するTokenStore
+//
+// トークンごとにランダムなデータ鍵で暗号化し、データ鍵をKeySourceの鍵暗号化鍵で暗号化して一緒に保存する。
+// 鍵暗号化鍵をローテーションしても、保存済みのトークンを暗号化し直す必要はない
+type encryptedTokenStore struct {
+	backend TokenBackend
+	keys    KeySource
+}
+
+// tokenEnvelope はbackendに保存する暗号化したトークンとデータ鍵
+type tokenEnvelope struct {
+	KeyId      string `json:"kid"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewEncryptedTokenStore はkeysでトークンを暗号化してbackendに保存するTokenStoreを返す
+func NewEncryptedTokenStore(backend TokenBackend, keys KeySource) *encryptedTokenStore {
+	return &encryptedTokenStore{backend: backend, keys: keys}
+}
+
+func (s *encryptedTokenStore) Get(ctx context.Context, key string) (*oidc.Token, error) {
+	data, err := s.backend.Load(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var envelope tokenEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token envelope: %w", err)
+	}
+	dataKey, err := s.keys.UnwrapKey(ctx, envelope.KeyId, envelope.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAesGcm(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	// 追加データのkeyが一致しない場合は復号に失敗するので、別のキーのトークンに差し替えられても検出できる
+	plain, err := open(aead, envelope.Ciphertext, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var record tokenRecord
+	if err := json.Unmarshal(plain, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+
+	return record.token()
+}
+
+func (s *encryptedTokenStore) Set(ctx context.Context, key string, token *oidc.Token) error {
+	plain, err := json.Marshal(newTokenRecord(token))
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	dataKey := make([]byte, dataKeyBytes)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newAesGcm(dataKey)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := seal(aead, plain, []byte(key))
+	if err != nil {
+		return err
+	}
+	keyId, wrapped, err := s.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	data, err := json.Marshal(tokenEnvelope{KeyId: keyId, WrappedKey: wrapped, Ciphertext: ciphertext})
+	if err != nil {
+		return fmt.Errorf("failed to marshal token envelope: %w", err)
+	}
+	if err := s.backend.Save(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *encryptedTokenStore) Delete(ctx context.Context, key string) error {
+	if err := s.backend.Remove(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	return nil
+}
+
+// staticKeySource はアプリケーションの設定で渡した鍵暗号化鍵を使うKeySource
+type staticKeySource struct {
+	currentId string
+	aeads     map[string]cipher.AEAD
+}
+
+// NewStaticKeySource はkeysの鍵暗号化鍵でデータ鍵を暗号化するKeySourceを返す
+//
+// 暗号化にはcurrentIdの鍵を使う。ローテーションする場合は新しい鍵を追加してcurrentIdを切り替え、
+// 古い鍵はそれで暗号化したトークンがなくなるまで残しておく
+func NewStaticKeySource(currentId string, keys map[string][]byte) (*staticKeySource, error) {
+	if _, ok := keys[currentId]; !ok {
+		return nil, fmt.Errorf("%w: %s", errTokenKeyIdMissing, currentId)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		aead, err := newAesGcm(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher of key %s: %w", id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &staticKeySource{currentId: currentId, aeads: aeads}, nil
+}
+
+func (s *staticKeySource) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(s.aeads[s.currentId], dataKey, []byte(s.currentId))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.currentId, wrapped, nil
+}
+
+func (s *staticKeySource) UnwrapKey(_ context.Context, keyId string, wrapped []byte) ([]byte, error) {
+	aead, ok := s.aeads[keyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errTokenKeyNotFound, keyId)
+	}
+
+	return open(aead, wrapped, []byte(keyId))
+}
+
+// memoryTokenBackend は暗号化したトークンをプロセスのメモリに保存するTokenBackend
+type memoryTokenBackend struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryTokenBackend はメモリに保存するTokenBackendを返す
+func NewMemoryTokenBackend() *memoryTokenBackend {
+	return &memoryTokenBackend{data: map[string][]byte{}}
+}
+
+func (b *memoryTokenBackend) Load(_ context.Context, key string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.data[key], nil
+}
+
+func (b *memoryTokenBackend) Save(_ context.Context, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data[key] = data
+
+	return nil
+}
+
+func (b *memoryTokenBackend) Remove(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.data, key)
+
+	return nil
+}
+
+// newAesGcm はkeyのAES-GCMを返す
+func newAesGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
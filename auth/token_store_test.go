@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sns-login/oidc"
+	"testing"
+)
+
+func newEncryptedTokenStoreForTest(t *testing.T, currentId string, keys map[string][]byte) (*encryptedTokenStore, *memoryTokenBackend) {
+	keySource, err := NewStaticKeySource(currentId, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewMemoryTokenBackend()
+
+	return NewEncryptedTokenStore(backend, keySource), backend
+}
+
+func TestEncryptedTokenStore(t *testing.T) {
+	ctx := context.Background()
+	store, backend := newEncryptedTokenStoreForTest(t, "key-1", map[string][]byte{"key-1": bytes.Repeat([]byte{1}, 32)})
+
+	idToken, err := oidc.NewIdToken(rawIdTokenForTest(), oidc.Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, store.Set(ctx, "user-1", &oidc.Token{AccessToken: "DummyAccessToken", RefreshToken: "DummyRefreshToken", IdToken: idToken}))
+	assert.NotContains(t, string(backend.data["user-1"]), "DummyRefreshToken")
+
+	token, err := store.Get(ctx, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyRefreshToken", token.RefreshToken)
+	assert.Equal(t, rawIdTokenForTest(), token.IdToken.Raw())
+	assert.Equal(t, oidc.Google, token.IdToken.IdProvider)
+
+	token, err = store.Get(ctx, "unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	// 別のキーにコピーされたトークンは復号できない
+	backend.data["user-2"] = backend.data["user-1"]
+	_, err = store.Get(ctx, "user-2")
+	assert.Error(t, err)
+
+	assert.Nil(t, store.Delete(ctx, "user-1"))
+	token, err = store.Get(ctx, "user-1")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+}
+
+func TestEncryptedTokenStore_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	store, backend := newEncryptedTokenStoreForTest(t, "key-1", map[string][]byte{"key-1": oldKey})
+	assert.Nil(t, store.Set(ctx, "user-1", &oidc.Token{RefreshToken: "DummyRefreshToken"}))
+
+	// 鍵暗号化鍵を切り替えても古い鍵で暗号化したトークンを読み込める
+	keySource, err := NewStaticKeySource("key-2", map[string][]byte{"key-1": oldKey, "key-2": newKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := NewEncryptedTokenStore(backend, keySource)
+	token, err := rotated.Get(ctx, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyRefreshToken", token.RefreshToken)
+
+	// 古い鍵を外すと読み込めない
+	keySource, err = NewStaticKeySource("key-2", map[string][]byte{"key-2": newKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewEncryptedTokenStore(backend, keySource).Get(ctx, "user-1")
+	assert.ErrorIs(t, err, errTokenKeyNotFound)
+}
+
+func TestNewStaticKeySource(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		currentId     string
+		keys          map[string][]byte
+	}{
+		{"valid", true, "key-1", map[string][]byte{"key-1": bytes.Repeat([]byte{1}, 32)}},
+		{"current key missing", false, "key-2", map[string][]byte{"key-1": bytes.Repeat([]byte{1}, 32)}},
+		{"invalid key size", false, "key-1", map[string][]byte{"key-1": []byte("short")}},
+	}
+
+	for _, pattern := range patterns {
+		_, err := NewStaticKeySource(pattern.currentId, pattern.keys)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package chiadapter
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"sns-login/auth"
+	"sns-login/oidc"
+	"sort"
+)
+
+// mounter はプロバイダごとのログインのルートをchiのルーターに登録する
+//
+// chiのミドルウェアはnet/httpと同じ型なので、ルートの保護にはauthenticatorのRequireLoginをそのまま使える
+type mounter struct {
+	authenticators map[string]auth.Handlers
+}
+
+// NewMounter はプロバイダが登録されていないmounterを返す
+func NewMounter() *mounter {
+	return &mounter{authenticators: map[string]auth.Handlers{}}
+}
+
+// Register はnameのプロバイダでログインするaを登録する。同じ名前が登録済みの場合はエラーを返す
+//
+// aのLoginPathはMountするパスに合わせて"/auth/{name}/login"のように設定する
+func (m *mounter) Register(name string, a auth.Handlers) error {
+	if name == "" || a == nil {
+		return fmt.Errorf("failed to register authenticator: name and authenticator are required")
+	}
+	if _, ok := m.authenticators[name]; ok {
+		return fmt.Errorf("failed to register authenticator: %s is already registered", name)
+	}
+	m.authenticators[name] = a
+
+	return nil
+}
+
+// Mount はRegisterしたプロバイダごとに/{name}/login、/{name}/callback、/{name}/logoutのルートをrに登録する
+//
+// r.Route("/auth", m.Mount)とすると/auth/google/loginのようなルートになる
+func (m *mounter) Mount(r chi.Router) {
+	names := make([]string, 0, len(m.authenticators))
+	for name := range m.authenticators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a := m.authenticators[name]
+		r.Route("/"+name, func(r chi.Router) {
+			r.Method(http.MethodGet, "/login", a.LoginHandler())
+			r.Method(http.MethodGet, "/callback", a.CallbackHandler())
+			r.Method(http.MethodPost, "/callback", a.CallbackHandler())
+			r.Method(http.MethodPost, "/logout", a.LogoutHandler())
+		})
+	}
+}
+
+// User はRequireLoginで保護されたルートでログインしているユーザーを返す
+func User(r *http.Request) (*oidc.User, bool) {
+	return auth.UserFromContext(r.Context())
+}
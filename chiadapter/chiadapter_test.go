@@ -0,0 +1,110 @@
+package chiadapter
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sns-login/auth"
+	"sns-login/oidc"
+	"testing"
+)
+
+// fakeProvider はIdPにアクセスせずに常に同じユーザーでログインさせるProvider
+type fakeProvider struct {
+	idProvider oidc.IdProvider
+}
+
+func (p fakeProvider) LoginUrl(state string, nonce string, _ ...oidc.AuthCodeOption) (string, oidc.Pkce, error) {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode(), oidc.Pkce{}, nil
+}
+
+func (p fakeProvider) Login(_ context.Context, _ string, _ string, _ ...oidc.AuthCodeOption) (*oidc.User, error) {
+	return &oidc.User{IdProvider: p.idProvider, Sub: "1234567890"}, nil
+}
+
+func (p fakeProvider) Idp() oidc.IdProvider {
+	return p.idProvider
+}
+
+// loginForTest はnameのプロバイダでログインしてセッションのCookieを返す
+func loginForTest(t *testing.T, router http.Handler, name string) []*http.Cookie {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/"+name+"/login", nil))
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+	location, err := url.Parse(w.Result().Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/"+name+"/callback?code=DummyCode&state="+location.Query().Get("state"), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+
+	return w.Result().Cookies()
+}
+
+func TestMounter_Register(t *testing.T) {
+	m := NewMounter()
+	assert.Nil(t, m.Register("google", auth.NewAuthenticator(fakeProvider{oidc.Google})))
+	assert.Error(t, m.Register("google", auth.NewAuthenticator(fakeProvider{oidc.Google})))
+	assert.Error(t, m.Register("", auth.NewAuthenticator(fakeProvider{oidc.Google})))
+	assert.Error(t, m.Register("line", nil))
+}
+
+func TestMounter_Mount(t *testing.T) {
+	google := auth.NewAuthenticator(fakeProvider{oidc.Google})
+	google.LoginPath = "/auth/google/login"
+	line := auth.NewAuthenticator(fakeProvider{oidc.Line})
+	line.LoginPath = "/auth/line/login"
+
+	m := NewMounter()
+	if err := m.Register("google", google); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register("line", line); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Route("/auth", m.Mount)
+	r.With(line.RequireLogin).Get("/mypage", func(w http.ResponseWriter, r *http.Request) {
+		user, ok := User(r)
+		assert.True(t, ok)
+		assert.Equal(t, oidc.Line, user.IdProvider)
+		_, _ = w.Write([]byte(user.Sub))
+	})
+
+	// 未ログインの場合はプロバイダのログインにリダイレクトされる
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mypage", nil))
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+	assert.Equal(t, "/auth/line/login", w.Result().Header.Get("Location"))
+
+	// 登録していないプロバイダのルートはない
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/github/login", nil))
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	// ログアウトはPOSTのみ
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/google/logout", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+
+	_ = loginForTest(t, r, "google")
+	cookies := loginForTest(t, r, "line")
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/mypage", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "1234567890", w.Body.String())
+}
@@ -0,0 +1,18 @@
+module sns-login/chiadapter
+
+go 1.21
+
+require (
+	github.com/go-chi/chi/v5 v5.2.3
+	github.com/stretchr/testify v1.9.0
+	sns-login v0.0.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace sns-login => ../
@@ -0,0 +1,70 @@
+package echoadapter
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sns-login/auth"
+	"sns-login/oidc"
+)
+
+// UserKey はRequireLoginがecho.Contextにログインしているユーザーを入れるキー
+const UserKey = "sns-login/user"
+
+// routes はecho.Echoとecho.Groupのルートを登録するメソッド
+type routes interface {
+	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+// RequireLogin はログインしていないユーザーからルートを保護するEchoのミドルウェア
+//
+// セッションが有効な場合はユーザーの情報をecho.ContextのUserKeyとリクエストのcontextの両方に入れてnextを呼ぶ。
+// 未ログインの場合はauthのRequireLoginと同じくログインにリダイレクトし、nextは呼ばない
+func RequireLogin(a auth.Handlers) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			a.RequireLogin(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				if user, ok := auth.UserFromContext(r.Context()); ok {
+					c.Set(UserKey, user)
+				}
+				err = next(c)
+			})).ServeHTTP(c.Response(), c.Request())
+
+			return err
+		}
+	}
+}
+
+// User はRequireLoginで保護されたルートでログインしているユーザーを返す
+func User(c echo.Context) (*oidc.User, bool) {
+	user, ok := c.Get(UserKey).(*oidc.User)
+
+	return user, ok
+}
+
+// Login はユーザーをプロバイダのログイン画面にリダイレクトするハンドラ
+func Login(a auth.Handlers) echo.HandlerFunc {
+	return echo.WrapHandler(a.LoginHandler())
+}
+
+// Callback はプロバイダからのコールバックでセッションを作成するハンドラ
+func Callback(a auth.Handlers) echo.HandlerFunc {
+	return echo.WrapHandler(a.CallbackHandler())
+}
+
+// Logout はセッションを破棄するハンドラ。POSTのルートに登録する
+func Logout(a auth.Handlers) echo.HandlerFunc {
+	return echo.WrapHandler(a.LogoutHandler())
+}
+
+// Register はrに/login、/callback、/logoutのルートを登録する
+//
+// e.Group("/auth")を渡すと/auth/loginのようなルートになる
+func Register(r routes, a auth.Handlers) {
+	r.GET("/login", Login(a))
+	r.GET("/callback", Callback(a))
+	r.POST("/callback", Callback(a))
+	r.POST("/logout", Logout(a))
+}
@@ -0,0 +1,79 @@
+package echoadapter
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sns-login/auth"
+	"sns-login/oidc"
+	"testing"
+)
+
+// fakeProvider はIdPにアクセスせずに常に同じユーザーでログインさせるProvider
+type fakeProvider struct{}
+
+func (p fakeProvider) LoginUrl(state string, nonce string, _ ...oidc.AuthCodeOption) (string, oidc.Pkce, error) {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode(), oidc.Pkce{}, nil
+}
+
+func (p fakeProvider) Login(_ context.Context, _ string, _ string, _ ...oidc.AuthCodeOption) (*oidc.User, error) {
+	return &oidc.User{IdProvider: oidc.Google, Sub: "1234567890"}, nil
+}
+
+func (p fakeProvider) Idp() oidc.IdProvider {
+	return oidc.Google
+}
+
+// loginForTest はログインしてセッションのCookieを返す
+func loginForTest(t *testing.T, router http.Handler) []*http.Cookie {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	location, err := url.Parse(w.Result().Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/callback?code=DummyCode&state="+location.Query().Get("state"), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+
+	return w.Result().Cookies()
+}
+
+func TestRequireLogin(t *testing.T) {
+	a := auth.NewAuthenticator(fakeProvider{})
+	e := echo.New()
+	Register(e.Group("/auth"), a)
+	e.GET("/mypage", func(c echo.Context) error {
+		user, ok := User(c)
+		assert.True(t, ok)
+		fromRequest, ok := auth.UserFromContext(c.Request().Context())
+		assert.True(t, ok)
+		assert.Same(t, user, fromRequest)
+
+		return c.String(http.StatusOK, user.Sub)
+	}, RequireLogin(a))
+
+	// 未ログインの場合はログインにリダイレクトされ、ハンドラは呼ばれない
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mypage", nil))
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+	assert.Equal(t, "/auth/login", w.Result().Header.Get("Location"))
+
+	cookies := loginForTest(t, e)
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/mypage", nil)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	e.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "1234567890", w.Body.String())
+}
@@ -0,0 +1,71 @@
+package fiberadapter
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"net/http"
+	"sns-login/auth"
+	"sns-login/oidc"
+)
+
+// UserKey はRequireLoginがfiber.CtxのLocalsにログインしているユーザーを入れるキー
+const UserKey = "sns-login/user"
+
+// RequireLogin はログインしていないユーザーからルートを保護するFiberのミドルウェア
+//
+// Fiberはfasthttpを使うのでnet/httpのcontextをハンドラに渡せない。
+// セッションが有効な場合はユーザーの情報をLocalsのUserKeyに入れて次のハンドラを呼ぶ。
+// 未ログインの場合はauthのRequireLoginと同じくログインにリダイレクトし、次のハンドラは呼ばない
+func RequireLogin(a auth.Handlers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			user *oidc.User
+			next bool
+		)
+		handler := adaptor.HTTPHandler(a.RequireLogin(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			user, next = auth.UserFromContext(r.Context())
+		})))
+		if err := handler(c); err != nil {
+			return err
+		}
+		if !next {
+			return nil
+		}
+
+		c.Locals(UserKey, user)
+
+		return c.Next()
+	}
+}
+
+// User はRequireLoginで保護されたルートでログインしているユーザーを返す
+func User(c *fiber.Ctx) (*oidc.User, bool) {
+	user, ok := c.Locals(UserKey).(*oidc.User)
+
+	return user, ok
+}
+
+// Login はユーザーをプロバイダのログイン画面にリダイレクトするハンドラ
+func Login(a auth.Handlers) fiber.Handler {
+	return adaptor.HTTPHandler(a.LoginHandler())
+}
+
+// Callback はプロバイダからのコールバックでセッションを作成するハンドラ
+func Callback(a auth.Handlers) fiber.Handler {
+	return adaptor.HTTPHandler(a.CallbackHandler())
+}
+
+// Logout はセッションを破棄するハンドラ。POSTのルートに登録する
+func Logout(a auth.Handlers) fiber.Handler {
+	return adaptor.HTTPHandler(a.LogoutHandler())
+}
+
+// Register はrに/login、/callback、/logoutのルートを登録する
+//
+// app.Group("/auth")を渡すと/auth/loginのようなルートになる
+func Register(r fiber.Router, a auth.Handlers) {
+	r.Get("/login", Login(a))
+	r.Get("/callback", Callback(a))
+	r.Post("/callback", Callback(a))
+	r.Post("/logout", Logout(a))
+}
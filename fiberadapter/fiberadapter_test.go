@@ -0,0 +1,89 @@
+package fiberadapter
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sns-login/auth"
+	"sns-login/oidc"
+	"testing"
+)
+
+// fakeProvider はIdPにアクセスせずに常に同じユーザーでログインさせるProvider
+type fakeProvider struct{}
+
+func (p fakeProvider) LoginUrl(state string, nonce string, _ ...oidc.AuthCodeOption) (string, oidc.Pkce, error) {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode(), oidc.Pkce{}, nil
+}
+
+func (p fakeProvider) Login(_ context.Context, _ string, _ string, _ ...oidc.AuthCodeOption) (*oidc.User, error) {
+	return &oidc.User{IdProvider: oidc.Google, Sub: "1234567890"}, nil
+}
+
+func (p fakeProvider) Idp() oidc.IdProvider {
+	return oidc.Google
+}
+
+// loginForTest はログインしてセッションのCookieを返す
+func loginForTest(t *testing.T, app *fiber.App) []*http.Cookie {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/callback?code=DummyCode&state="+location.Query().Get("state"), nil)
+	for _, cookie := range resp.Cookies() {
+		r.AddCookie(cookie)
+	}
+	resp, err = app.Test(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	return resp.Cookies()
+}
+
+func TestRequireLogin(t *testing.T) {
+	a := auth.NewAuthenticator(fakeProvider{})
+	app := fiber.New()
+	Register(app.Group("/auth"), a)
+	app.Get("/mypage", RequireLogin(a), func(c *fiber.Ctx) error {
+		user, ok := User(c)
+		assert.True(t, ok)
+
+		return c.SendString(user.Sub)
+	})
+
+	// 未ログインの場合はログインにリダイレクトされ、ハンドラは呼ばれない
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/mypage", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/auth/login", resp.Header.Get("Location"))
+
+	cookies := loginForTest(t, app)
+	r := httptest.NewRequest(http.MethodGet, "/mypage", nil)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	resp, err = app.Test(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1234567890", string(body))
+}
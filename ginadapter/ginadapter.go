@@ -0,0 +1,71 @@
+package ginadapter
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sns-login/auth"
+	"sns-login/oidc"
+)
+
+// UserKey はRequireLoginがgin.Contextにログインしているユーザーを入れるキー
+const UserKey = "sns-login/user"
+
+// RequireLogin はログインしていないユーザーからルートを保護するginのミドルウェア
+//
+// セッションが有効な場合はユーザーの情報をgin.ContextのUserKeyとリクエストのcontextの両方に入れて後続の処理を呼ぶ。
+// 未ログインの場合はauthのRequireLoginと同じくログインにリダイレクトし、後続の処理を中断する
+func RequireLogin(a auth.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticated := false
+		a.RequireLogin(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			authenticated = true
+			c.Request = r
+			if user, ok := auth.UserFromContext(r.Context()); ok {
+				c.Set(UserKey, user)
+			}
+		})).ServeHTTP(c.Writer, c.Request)
+
+		if !authenticated {
+			c.Abort()
+
+			return
+		}
+		c.Next()
+	}
+}
+
+// User はRequireLoginで保護されたルートでログインしているユーザーを返す
+func User(c *gin.Context) (*oidc.User, bool) {
+	value, ok := c.Get(UserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := value.(*oidc.User)
+
+	return user, ok
+}
+
+// Login はユーザーをプロバイダのログイン画面にリダイレクトするハンドラ
+func Login(a auth.Handlers) gin.HandlerFunc {
+	return gin.WrapH(a.LoginHandler())
+}
+
+// Callback はプロバイダからのコールバックでセッションを作成するハンドラ
+func Callback(a auth.Handlers) gin.HandlerFunc {
+	return gin.WrapH(a.CallbackHandler())
+}
+
+// Logout はセッションを破棄するハンドラ。POSTのルートに登録する
+func Logout(a auth.Handlers) gin.HandlerFunc {
+	return gin.WrapH(a.LogoutHandler())
+}
+
+// Register はrに/login、/callback、/logoutのルートを登録する
+//
+// r.Group("/auth")を渡すと/auth/loginのようなルートになる
+func Register(r gin.IRoutes, a auth.Handlers) {
+	r.GET("/login", Login(a))
+	r.GET("/callback", Callback(a))
+	r.POST("/callback", Callback(a))
+	r.POST("/logout", Logout(a))
+}
@@ -0,0 +1,107 @@
+package grpcauth
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sns-login/oidc"
+	"strings"
+)
+
+// authorizationKey はbearerトークンを送るメタデータのキー。gRPCのメタデータのキーは小文字になる
+const authorizationKey = "authorization"
+
+type contextKey int
+
+const claimsContextKey contextKey = iota
+
+// tokenVerifier はoidcClientのVerifierが返すid_tokenの検証
+type tokenVerifier interface {
+	VerifyRawToken(ctx context.Context, rawToken string) (oidc.IdTokenClaims, error)
+}
+
+// UnaryServerInterceptor はメタデータのbearerトークンをid_tokenとして検証するUnaryの
+// インターセプタを返す
+//
+// 署名、iss、aud、expの検証に成功した場合はクレームをcontextに入れてhandlerを呼び、
+// トークンがない場合や検証に失敗した場合はcodes.Unauthenticatedを返す
+func UnaryServerInterceptor(v tokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor はUnaryServerInterceptorと同じ検証を行うStreamのインターセプタを返す
+func StreamServerInterceptor(v tokenVerifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), v)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// ClaimsFromContext はインターセプタで検証したid_tokenのクレームを返す
+func ClaimsFromContext(ctx context.Context) (oidc.IdTokenClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(oidc.IdTokenClaims)
+
+	return claims, ok
+}
+
+// serverStream はハンドラにクレームを入れたcontextを渡すためにContextを差し替えたgrpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate はメタデータのbearerトークンを検証し、クレームを入れたcontextを返す
+//
+// 検証に失敗した理由はクライアントに返さない
+func authenticate(ctx context.Context, v tokenVerifier) (context.Context, error) {
+	rawToken, ok := bearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "bearer token is required")
+	}
+
+	claims, err := v.VerifyRawToken(ctx, rawToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+
+	return context.WithValue(ctx, claimsContextKey, claims), nil
+}
+
+// bearerToken はメタデータのauthorizationからbearerトークンを取り出す
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc6750#section-2.1
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(authorizationKey)
+	if len(values) != 1 {
+		return "", false
+	}
+
+	// 認証スキームは大文字小文字を区別しない
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
@@ -0,0 +1,92 @@
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sns-login/oidc"
+	"testing"
+)
+
+// fakeVerifier は"valid-token"のみを正しいid_tokenとして扱うtokenVerifier
+type fakeVerifier struct{}
+
+func (v fakeVerifier) VerifyRawToken(_ context.Context, rawToken string) (oidc.IdTokenClaims, error) {
+	if rawToken != "valid-token" {
+		return oidc.IdTokenClaims{}, errors.New("invalid token")
+	}
+
+	return oidc.IdTokenClaims{Sub: "1234567890"}, nil
+}
+
+// fakeServerStream はContextだけを返すgrpc.ServerStream
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		md            metadata.MD
+	}{
+		{"valid", true, metadata.Pairs("authorization", "Bearer valid-token")},
+		{"lowercase scheme", true, metadata.Pairs("authorization", "bearer valid-token")},
+		{"invalid token", false, metadata.Pairs("authorization", "Bearer invalid-token")},
+		{"basic scheme", false, metadata.Pairs("authorization", "Basic valid-token")},
+		{"no scheme", false, metadata.Pairs("authorization", "valid-token")},
+		{"empty token", false, metadata.Pairs("authorization", "Bearer ")},
+		{"multiple values", false, metadata.Pairs("authorization", "Bearer valid-token", "authorization", "Bearer valid-token")},
+		{"no authorization", false, metadata.MD{}},
+	}
+
+	interceptor := UnaryServerInterceptor(fakeVerifier{})
+	for _, pattern := range patterns {
+		called := false
+		ctx := metadata.NewIncomingContext(context.Background(), pattern.md)
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			called = true
+			claims, ok := ClaimsFromContext(ctx)
+			assert.True(t, ok, pattern.desc)
+			assert.Equal(t, "1234567890", claims.Sub, pattern.desc)
+
+			return nil, nil
+		})
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.True(t, called, pattern.desc)
+		} else {
+			assert.Equal(t, codes.Unauthenticated, status.Code(err), pattern.desc)
+			assert.False(t, called, pattern.desc)
+		}
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(fakeVerifier{})
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		claims, ok := ClaimsFromContext(ss.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "1234567890", claims.Sub)
+
+		return nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid-token"))
+	err := interceptor(nil, fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+	assert.Nil(t, err)
+
+	// メタデータがない場合はハンドラを呼ばない
+	err = interceptor(nil, fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -7,23 +7,43 @@ import (
 	"sns-login/logger"
 	"sns-login/model"
 	"sns-login/oidc"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// loginCookieTtl はnonceとcode_verifierのCookieの有効期間。stateと同じくコールバックまでの間だけ保存する
+const loginCookieTtl = 10 * time.Minute
+
 func AuthGoogleSignUpHandler(w http.ResponseWriter, r *http.Request) {
 	l := logger.New(false)
 	client := oidc.NewGoogleOidcClient()
 
 	// CSRFを防ぐためにstateを保存し、後の処理でstateが一致するか確認する
-	state, err := oidc.RandomState()
+	state, err := oidc.NewStateManager(stateStore(), 0).Issue(w, r)
+	if err != nil {
+		l.Logger.Error().Err(err)
+
+		return
+	}
+
+	// リプレイ攻撃を防ぐためにnonceを保存し、id_tokenのnonceクレームと一致するか確認する
+	nonce, err := oidc.RandomNonce()
+	if err != nil {
+		l.Logger.Error().Err(err)
+
+		return
+	}
+	setLoginCookie(w, "nonce", nonce)
+
+	// 認可コードが横取りされてもトークンを取得されないように、PKCEのcode_verifierを保存してトークンリクエストで送る
+	pkce, err := oidc.NewPkce()
 	if err != nil {
 		l.Logger.Error().Err(err)
 
 		return
 	}
-	cookie := http.Cookie{Name: "state", Value: state}
-	http.SetCookie(w, &cookie)
+	setLoginCookie(w, "code_verifier", pkce.Verifier)
 
 	// ユーザーをGoogleのログイン画面にリダイレクト
 	redirectUrl := client.AuthUrl(
@@ -36,55 +56,55 @@ func AuthGoogleSignUpHandler(w http.ResponseWriter, r *http.Request) {
 			os.Getenv("SERVER_PORT"),
 		),
 		state,
+		nonce,
+		oidc.WithCodeChallenge(pkce),
 	)
 	http.Redirect(w, r, redirectUrl, http.StatusMovedPermanently)
 }
 
-func AuthGoogleSignUpCallbackHandler(_ http.ResponseWriter, r *http.Request, db *gorm.DB) {
+func AuthGoogleSignUpCallbackHandler(w http.ResponseWriter, r *http.Request, db *gorm.DB) {
 	l := logger.New(false)
 
-	// 認可リクエストを送る前に設定したstateと一致するかを確認してCSRF攻撃を防ぐ
-	cookieState, err := r.Cookie("state")
-	if err != nil {
-		l.Logger.Error().Err(err)
+	// nonceとcode_verifierは1回のログインでのみ使うので、検証に失敗した場合も最初に削除しておく
+	codeVerifier, codeVerifierErr := consumeLoginCookie(w, r, "code_verifier")
+	nonce, nonceErr := consumeLoginCookie(w, r, "nonce")
 
-		return
-	}
-	queryState := r.URL.Query().Get("state")
-	if queryState != cookieState.Value {
-		err = fmt.Errorf("state parameter does not match for query: %s, cookie: %s", queryState, cookieState)
+	// 認可リクエストを送る前に設定したstateと一致するかを確認してCSRF攻撃を防ぐ
+	if err := oidc.NewStateManager(stateStore(), 0).Verify(w, r); err != nil {
 		l.Logger.Error().Err(err)
 
 		return
 	}
 
 	// 認可コードを取り出しトークンエンドポイントに投げることでid_tokenを取得できる
+	// id_token(JWT)の署名はJWKsエンドポイントから取得した公開鍵で検証され、改竄されていないことが確認される
 	client := oidc.NewGoogleOidcClient()
-	tokenResp, err := client.PostTokenEndpoint(
-		r.URL.Query().Get("code"),
-		fmt.Sprintf(
-			"%s://%s:%s/auth/google/sign_up/callback",
-			os.Getenv("SERVER_PROTO"),
-			os.Getenv("SERVER_HOST"),
-			os.Getenv("SERVER_PORT"),
-		),
-		"authorization_code",
+	client.RedirectUrl = fmt.Sprintf(
+		"%s://%s:%s/auth/google/sign_up/callback",
+		os.Getenv("SERVER_PROTO"),
+		os.Getenv("SERVER_HOST"),
+		os.Getenv("SERVER_PORT"),
 	)
-	if err != nil {
-		l.Logger.Error().Err(err)
+	if codeVerifierErr != nil {
+		l.Logger.Error().Err(codeVerifierErr)
 
 		return
 	}
-
-	// JWKsエンドポイントから公開鍵を取得しid_token(JWT)の署名を検証。改竄されていないことを確認する
-	idToken, err := oidc.NewIdToken(tokenResp.IdToken, oidc.Google)
+	token, err := client.Exchange(r.Context(), r.URL.Query().Get("code"), oidc.WithCodeVerifier(codeVerifier))
 	if err != nil {
 		l.Logger.Error().Err(err)
 
 		return
 	}
+	idToken := token.IdToken
 
-	if err = idToken.Validate(client.JwksEndpoint, client.ClientId); err != nil {
+	// 認可リクエストに含めたnonceと一致するかを確認し、別のフローで発行されたid_tokenの再利用を防ぐ
+	if nonceErr != nil {
+		l.Logger.Error().Err(nonceErr)
+
+		return
+	}
+	if err = idToken.VerifyNonce(nonce); err != nil {
 		l.Logger.Error().Err(err)
 
 		return
@@ -104,3 +124,42 @@ func AuthGoogleSignUpCallbackHandler(_ http.ResponseWriter, r *http.Request, db
 	db.Create(user)
 	l.Logger.Info().Msg("success to create user")
 }
+
+// setLoginCookie はコールバックで使うnonceやcode_verifierをCookieに保存する
+//
+// JavaScriptから読めないようにHttpOnlyにし、IdPからのリダイレクトでは送られるようにSameSite=Laxにする
+func setLoginCookie(w http.ResponseWriter, name string, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(loginCookieTtl / time.Second),
+		Secure:   secureCookie(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// consumeLoginCookie はCookieの値を返し、再利用できないように保存した時と同じ属性で削除する
+func consumeLoginCookie(w http.ResponseWriter, r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{Name: name, Path: "/", MaxAge: -1, Secure: secureCookie(), HttpOnly: true, SameSite: http.SameSiteLaxMode})
+
+	return cookie.Value, nil
+}
+
+// stateStore はnonceやcode_verifierのCookieと同じSecure属性でstateをCookieに保存するStateStoreを返す
+func stateStore() oidc.StateStore {
+	store := oidc.NewCookieStateStore()
+	store.Secure = secureCookie()
+
+	return store
+}
+
+// secureCookie はサーバーをHTTPSで公開している場合にCookieにSecure属性を付けるかどうかを返す
+func secureCookie() bool {
+	return os.Getenv("SERVER_PROTO") == "https"
+}
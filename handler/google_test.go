@@ -23,3 +23,48 @@ func TestAuthGoogleSignUpHandler(t *testing.T) {
 
 	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
 }
+
+func TestAuthGoogleSignUpHandler_Cookies(t *testing.T) {
+	t.Setenv("SERVER_PROTO", "https")
+	w := httptest.NewRecorder()
+	AuthGoogleSignUpHandler(w, httptest.NewRequest(http.MethodGet, "/auth/google/sign_up", nil))
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	// stateもnonceやcode_verifierと同じようにHTTPSの場合はSecureにする
+	if assert.NotNil(t, cookies["state"]) {
+		assert.True(t, cookies["state"].Secure)
+	}
+	for _, name := range []string{"nonce", "code_verifier"} {
+		cookie := cookies[name]
+		if assert.NotNil(t, cookie, name) {
+			assert.True(t, cookie.HttpOnly, name)
+			assert.True(t, cookie.Secure, name)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite, name)
+			assert.Equal(t, "/", cookie.Path, name)
+			assert.Positive(t, cookie.MaxAge, name)
+		}
+	}
+
+	// コールバックではstateの検証に失敗してもnonceとcode_verifierを削除する
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/auth/google/sign_up/callback?state=another", nil)
+	r.AddCookie(cookies["nonce"])
+	r.AddCookie(cookies["code_verifier"])
+	AuthGoogleSignUpCallbackHandler(w, r, nil)
+
+	deleted := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		deleted[cookie.Name] = cookie
+	}
+	for _, name := range []string{"nonce", "code_verifier"} {
+		cookie := deleted[name]
+		if assert.NotNil(t, cookie, name) {
+			assert.Equal(t, -1, cookie.MaxAge, name)
+			assert.True(t, cookie.Secure, name)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite, name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sns-login/oidc"
+	"time"
+)
+
+const namespace = "sns_login"
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultHit     = "hit"
+	resultMiss    = "miss"
+)
+
+// collector はログインのフローの計測値をPrometheusのメトリクスとして公開する
+//
+// oidc.MetricsRecorderを実装しているので、oidcClientのMetricsとauthenticatorのMetricsに設定し、
+// prometheus.Registererに登録して使う
+type collector struct {
+	loginsStarted           *prometheus.CounterVec
+	loginsCompleted         *prometheus.CounterVec
+	loginsFailed            *prometheus.CounterVec
+	tokenValidationDuration *prometheus.HistogramVec
+	jwksFetchDuration       *prometheus.HistogramVec
+	cacheRequests           *prometheus.CounterVec
+}
+
+// NewCollector はメトリクスを登録していないcollectorを返す
+func NewCollector() *collector {
+	return &collector{
+		loginsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_started_total",
+			Help:      "Number of logins redirected to the provider.",
+		}, []string{"provider"}),
+		loginsCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_completed_total",
+			Help:      "Number of logins that created a session.",
+		}, []string{"provider"}),
+		loginsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_failed_total",
+			Help:      "Number of logins that failed in the callback.",
+		}, []string{"provider"}),
+		tokenValidationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "token_validation_duration_seconds",
+			Help:      "Time taken to validate an id_token.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+		}, []string{"provider", "result"}),
+		jwksFetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "jwks_fetch_duration_seconds",
+			Help:      "Time taken to fetch JWKs from the provider.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Number of JWKs and discovery cache lookups.",
+		}, []string{"cache", "result"}),
+	}
+}
+
+func (c *collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.loginsStarted,
+		c.loginsCompleted,
+		c.loginsFailed,
+		c.tokenValidationDuration,
+		c.jwksFetchDuration,
+		c.cacheRequests,
+	}
+}
+
+// Describe はprometheus.Collectorの実装
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.collectors() {
+		m.Describe(ch)
+	}
+}
+
+// Collect はprometheus.Collectorの実装
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.collectors() {
+		m.Collect(ch)
+	}
+}
+
+func (c *collector) LoginStarted(provider string) {
+	c.loginsStarted.WithLabelValues(provider).Inc()
+}
+
+func (c *collector) LoginCompleted(provider string) {
+	c.loginsCompleted.WithLabelValues(provider).Inc()
+}
+
+func (c *collector) LoginFailed(provider string) {
+	c.loginsFailed.WithLabelValues(provider).Inc()
+}
+
+func (c *collector) TokenValidated(provider string, duration time.Duration, err error) {
+	c.tokenValidationDuration.WithLabelValues(provider, result(err)).Observe(duration.Seconds())
+}
+
+func (c *collector) JwksFetched(duration time.Duration, err error) {
+	c.jwksFetchDuration.WithLabelValues(result(err)).Observe(duration.Seconds())
+}
+
+// CacheAccessed はキャッシュのヒット率を出せるように、ヒットとミスをresultラベルで分けて数える
+func (c *collector) CacheAccessed(cache string, hit bool) {
+	if hit {
+		c.cacheRequests.WithLabelValues(cache, resultHit).Inc()
+
+		return
+	}
+
+	c.cacheRequests.WithLabelValues(cache, resultMiss).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+
+	return resultSuccess
+}
+
+var _ oidc.MetricsRecorder = (*collector)(nil)
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"sns-login/oidc"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.LoginStarted("Google")
+	c.LoginStarted("Google")
+	c.LoginStarted("Line")
+	c.LoginCompleted("Google")
+	c.LoginFailed("Line")
+	c.TokenValidated("Google", 2*time.Millisecond, nil)
+	c.TokenValidated("Google", time.Millisecond, errors.New("invalid"))
+	c.JwksFetched(100*time.Millisecond, nil)
+	c.CacheAccessed(oidc.JwksCacheName, true)
+	c.CacheAccessed(oidc.JwksCacheName, true)
+	c.CacheAccessed(oidc.JwksCacheName, false)
+
+	expected := `
+# HELP sns_login_logins_started_total Number of logins redirected to the provider.
+# TYPE sns_login_logins_started_total counter
+sns_login_logins_started_total{provider="Google"} 2
+sns_login_logins_started_total{provider="Line"} 1
+# HELP sns_login_logins_completed_total Number of logins that created a session.
+# TYPE sns_login_logins_completed_total counter
+sns_login_logins_completed_total{provider="Google"} 1
+# HELP sns_login_logins_failed_total Number of logins that failed in the callback.
+# TYPE sns_login_logins_failed_total counter
+sns_login_logins_failed_total{provider="Line"} 1
+# HELP sns_login_cache_requests_total Number of JWKs and discovery cache lookups.
+# TYPE sns_login_cache_requests_total counter
+sns_login_cache_requests_total{cache="jwks",result="hit"} 2
+sns_login_cache_requests_total{cache="jwks",result="miss"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"sns_login_logins_started_total",
+		"sns_login_logins_completed_total",
+		"sns_login_logins_failed_total",
+		"sns_login_cache_requests_total",
+	)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(c, "sns_login_token_validation_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "sns_login_jwks_fetch_duration_seconds"))
+}
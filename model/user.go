@@ -9,6 +9,25 @@ type idProvider int
 
 const (
 	Google idProvider = iota + 1
+	Apple
+	Line
+	YahooJapan
+	Microsoft
+	Facebook
+	X
+	GitHub
+	Slack
+	Discord
+	Cognito
+	Auth0
+	Okta
+	Keycloak
+	Salesforce
+	GenericOidc
+	Twitch
+	GitLab
+	PayPal
+	Spotify
 )
 
 type User struct {
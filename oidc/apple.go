@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	appleIssuer = "https://appleid.apple.com"
+	// appleClientSecretTtl は生成するclient_secretのJWTの有効期間。Appleでは最大6ヶ月まで許される
+	appleClientSecretTtl = 1 * time.Hour
+	// appleClientSecretRenewBefore は有効期限のどれだけ前にclient_secretを生成し直すか
+	appleClientSecretRenewBefore = 5 * time.Minute
+)
+
+// NewAppleOidcClient はSign in with Appleのクライアントを返す
+//
+// clientIdにはServices ID、teamIdとkeyIdにはApple Developerで発行したチームIDとキーIDを渡す。
+// keyは.p8ファイルの秘密鍵で、ParseAppleKeyで読み込める。
+// Appleはclient_secretとしてこの鍵でES256署名したJWTを要求するので、クライアントがリクエストのたびに生成する
+//
+// 認可リクエストのscopeにnameやemailを含める場合は、WithResponseMode("form_post")でform_postを指定する必要がある。
+// コールバックではParseAuthResponseで認可レスポンスを取り出し、初回のログイン時のみ返されるユーザー情報をUserから保存する。
+// optsでリダイレクト先やHttpClientなどの設定を変更できる
+//
+// refs: https://developer.apple.com/documentation/sign_in_with_apple/generate_and_validate_tokens
+func NewAppleOidcClient(clientId string, teamId string, keyId string, key crypto.Signer, opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		Apple,
+		appleIssuer,
+		clientId,
+		"",
+		"https://appleid.apple.com/auth/authorize",
+		"https://appleid.apple.com/auth/token",
+		"https://appleid.apple.com/auth/keys",
+		[]string{"RS256"},
+	)
+	client.RevocationEndpoint = "https://appleid.apple.com/auth/revoke"
+	client.Apply(opts...)
+	// client_secretのsubはclient_idなので、WithClientIdを反映した後に作る
+	client.clientSecretSource = newAppleClientSecret(client.ClientId, teamId, keyId, key).get
+
+	return client
+}
+
+// ParseAppleKey はApple Developerからダウンロードした.p8ファイルのPEMからES256の秘密鍵を読み込む
+func ParseAppleKey(p8 []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(p8)
+	if block == nil {
+		return nil, fmt.Errorf("%w: PEM block not found", errInvalidAppleKey)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAppleKey, err)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an EC private key", errInvalidAppleKey)
+	}
+
+	return ecKey, nil
+}
+
+// appleClientSecretClaims はAppleのclient_secretのJWTのpayload
+type appleClientSecretClaims struct {
+	Iss string `json:"iss"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+	Aud string `json:"aud"`
+	Sub string `json:"sub"`
+}
+
+// appleClientSecret はAppleのclient_secretを生成し、有効期限が近づくまで使い回す
+type appleClientSecret struct {
+	mu        sync.Mutex
+	clientId  string
+	teamId    string
+	keyId     string
+	key       crypto.Signer
+	secret    clientSecret
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+func newAppleClientSecret(clientId string, teamId string, keyId string, key crypto.Signer) *appleClientSecret {
+	return &appleClientSecret{
+		clientId: clientId,
+		teamId:   teamId,
+		keyId:    keyId,
+		key:      key,
+		now:      time.Now,
+	}
+}
+
+// get は有効なclient_secretを返す。有効期限が近い場合は生成し直す
+func (s *appleClientSecret) get() (clientSecret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.secret != "" && now.Add(appleClientSecretRenewBefore).Before(s.expiresAt) {
+		return s.secret, nil
+	}
+
+	expiresAt := now.Add(appleClientSecretTtl)
+	secret, err := signJwt("ES256", s.keyId, s.key, appleClientSecretClaims{
+		Iss: s.teamId,
+		Iat: now.Unix(),
+		Exp: expiresAt.Unix(),
+		Aud: appleIssuer,
+		Sub: s.clientId,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.secret, s.expiresAt = clientSecret(secret), expiresAt
+
+	return s.secret, nil
+}
@@ -0,0 +1,52 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// appleIdTokenPayload はAppleのid_tokenのpayloadをunmarshalするための構造体
+//
+// Appleはemail_verifiedとis_private_emailを真偽値ではなく"true"のような文字列で返すことがあるため、両方の形式を受け入れる
+//
+// refs: https://developer.apple.com/documentation/sign_in_with_apple/sign_in_with_apple_rest_api/authenticating_users_with_sign_in_with_apple
+type appleIdTokenPayload struct {
+	IdTokenClaims
+	EmailVerified stringBool `json:"email_verified"`
+	// IsPrivateEmail はメールアドレスがAppleのプライベートリレーのものかどうか
+	IsPrivateEmail stringBool `json:"is_private_email"`
+	// RealUserStatus は実在のユーザーである可能性。2の場合は実在のユーザーである可能性が高い
+	RealUserStatus int `json:"real_user_status"`
+}
+
+func (payload appleIdTokenPayload) standardClaims() IdTokenClaims {
+	claims := payload.IdTokenClaims
+	claims.EmailVerified = bool(payload.EmailVerified)
+
+	return claims
+}
+
+// stringBool は真偽値と"true", "false"の文字列のどちらの形式もunmarshalできる真偽値
+type stringBool bool
+
+func (b *stringBool) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		parsed, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("failed to parse boolean string: %w", err)
+		}
+		*b = stringBool(parsed)
+
+		return nil
+	}
+
+	var value bool
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to unmarshal boolean: %w", err)
+	}
+	*b = stringBool(value)
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAppleKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDer, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDer, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		p8            []byte
+	}{
+		{"valid", true, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecDer})},
+		{"RSA key", false, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDer})},
+		{"not PEM", false, []byte("invalid")},
+		{"invalid DER", false, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("invalid")})},
+	}
+
+	for _, pattern := range patterns {
+		key, err := ParseAppleKey(pattern.p8)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.True(t, key.Equal(ecKey), pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errInvalidAppleKey, pattern.desc)
+		}
+	}
+}
+
+func TestAppleClientSecret_Get(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	source := newAppleClientSecret("com.example.services", "TEAMID1234", "KEYID12345", ecKey)
+	source.now = func() time.Time { return now }
+
+	secret, err := source.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := strings.Split(string(secret), ".")
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, verifySignature("ES256", &ecKey.PublicKey, segments[0]+"."+segments[1], signature))
+
+	token, err := NewIdToken(string(secret), Apple)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "KEYID12345", token.header.Kid)
+	claims := appleClientSecretClaims{}
+	assert.Nil(t, token.Claims(&claims))
+	assert.Equal(t, appleClientSecretClaims{
+		Iss: "TEAMID1234",
+		Iat: now.Unix(),
+		Exp: now.Add(appleClientSecretTtl).Unix(),
+		Aud: "https://appleid.apple.com",
+		Sub: "com.example.services",
+	}, claims)
+
+	// 有効期限が近づくまでは同じclient_secretを使い回す
+	now = now.Add(appleClientSecretTtl - appleClientSecretRenewBefore - time.Second)
+	reused, _ := source.get()
+	assert.Equal(t, secret, reused)
+
+	now = now.Add(time.Second)
+	renewed, _ := source.get()
+	assert.NotEqual(t, secret, renewed)
+}
+
+func TestNewAppleOidcClient_ClientAuth(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewAppleOidcClient("com.example.services", "TEAMID1234", "KEYID12345", ecKey)
+
+	secret, err := client.secret()
+	assert.Nil(t, err)
+	assert.Len(t, strings.Split(string(secret), "."), 3)
+	assert.Equal(t, []string{"https://appleid.apple.com"}, client.issuers())
+}
+
+func TestNewAppleOidcClient_WithClientId(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewAppleOidcClient("com.example.services", "TEAMID1234", "KEYID12345", ecKey, WithClientId("com.example.another"))
+
+	secret, err := client.secret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewIdToken(string(secret), Apple)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := appleClientSecretClaims{}
+	assert.Nil(t, token.Claims(&claims))
+	assert.Equal(t, "com.example.another", client.ClientId)
+	assert.Equal(t, "com.example.another", claims.Sub)
+}
+
+func TestAppleIdTokenPayload(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		payload       string
+		expected      bool
+	}{
+		{"string true", true, `{"sub": "001234.abcd", "email_verified": "true", "is_private_email": "true"}`, true},
+		{"string false", true, `{"sub": "001234.abcd", "email_verified": "false"}`, false},
+		{"boolean", true, `{"sub": "001234.abcd", "email_verified": true, "is_private_email": false}`, true},
+		{"invalid string", false, `{"sub": "001234.abcd", "email_verified": "yes"}`, false},
+	}
+
+	for _, pattern := range patterns {
+		payload := &appleIdTokenPayload{}
+		err := json.Unmarshal([]byte(pattern.payload), payload)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "001234.abcd", payload.GetSub(), pattern.desc)
+			assert.Equal(t, pattern.expected, payload.standardClaims().EmailVerified, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// auth0Issuer はAuth0のテナントのドメインからissuerを返す。Auth0のissuerは末尾に"/"が付く
+func auth0Issuer(domain string) string {
+	return "https://" + strings.TrimSuffix(domain, "/") + "/"
+}
+
+// NewAuth0OidcClient はAuth0のテナントのクライアントを返す
+//
+// domainにはexample.us.auth0.comのようなテナントのドメインもしくはカスタムドメインを渡す
+//
+// refs: https://auth0.com/docs/authenticate/protocols/openid-connect-protocol
+func NewAuth0OidcClient(domain string, opts ...ClientOption) *oidcClient {
+	issuer := auth0Issuer(domain)
+	client := newOidcClient(
+		Auth0,
+		issuer,
+		os.Getenv("AUTH0_CLIENT_ID"),
+		clientSecret(os.Getenv("AUTH0_CLIENT_SECRET")),
+		issuer+"authorize",
+		issuer+"oauth/token",
+		issuer+".well-known/jwks.json",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = issuer + "userinfo"
+	client.RevocationEndpoint = issuer + "oauth/revoke"
+	client.EndSessionEndpoint = issuer + "oidc/logout"
+	client.DeviceAuthEndpoint = issuer + "oauth/device/code"
+	client.Apply(opts...)
+
+	return client
+}
+
+// DiscoverAuth0OidcClient はAuth0のテナントのDiscoveryドキュメントの内容で設定したクライアントを返す
+func DiscoverAuth0OidcClient(ctx context.Context, domain string) (*oidcClient, error) {
+	metadata, err := DiscoverProvider(ctx, auth0Issuer(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.NewOidcClient(Auth0, os.Getenv("AUTH0_CLIENT_ID"), os.Getenv("AUTH0_CLIENT_SECRET")), nil
+}
+
+// WithAudience は認可リクエストにaudienceを含め、APIを呼び出すためのJWT形式のアクセストークンを要求する
+//
+// audienceを指定しない場合、Auth0はUserInfoにしか使えないアクセストークンを発行する
+//
+// refs: https://auth0.com/docs/secure/tokens/access-tokens/get-access-tokens
+func WithAudience(audience string) AuthCodeOption {
+	return func(values url.Values) {
+		values.Set("audience", audience)
+	}
+}
+
+// NamespacedClaims はid_tokenのクレームのうちnamespaceで始まるものを、namespaceを取り除いた名前で返す
+//
+// Auth0のActionsで追加するカスタムクレームは標準のクレームとの衝突を避けるため、
+// https://example.com/roles のようにURLの名前空間を付ける必要がある。namespaceには"https://example.com/"のように区切りの"/"まで含めて渡す
+//
+// refs: https://auth0.com/docs/secure/tokens/json-web-tokens/create-custom-claims
+func NamespacedClaims(token *idToken, namespace string) (map[string]interface{}, error) {
+	all := map[string]interface{}{}
+	if err := token.Claims(&all); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	for key, value := range all {
+		if name := strings.TrimPrefix(key, namespace); name != key && name != "" {
+			claims[name] = value
+		}
+	}
+
+	return claims, nil
+}
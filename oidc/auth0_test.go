@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestDiscoverAuth0OidcClient(t *testing.T) {
+	defaultDiscoveryCache.Purge()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		"https://example.us.auth0.com/.well-known/openid-configuration",
+		httpmock.NewStringResponder(http.StatusOK, `{
+  "issuer": "https://example.us.auth0.com/",
+  "authorization_endpoint": "https://example.us.auth0.com/authorize",
+  "token_endpoint": "https://example.us.auth0.com/oauth/token",
+  "userinfo_endpoint": "https://example.us.auth0.com/userinfo",
+  "jwks_uri": "https://example.us.auth0.com/.well-known/jwks.json",
+  "revocation_endpoint": "https://example.us.auth0.com/oauth/revoke",
+  "end_session_endpoint": "https://example.us.auth0.com/oidc/logout",
+  "device_authorization_endpoint": "https://example.us.auth0.com/oauth/device/code",
+  "id_token_signing_alg_values_supported": ["HS256", "RS256", "PS256"]
+}`),
+	)
+
+	client, err := DiscoverAuth0OidcClient(context.Background(), "example.us.auth0.com")
+	assert.Nil(t, err)
+
+	expected := NewAuth0OidcClient("example.us.auth0.com")
+	assert.Equal(t, expected.Issuer, client.Issuer)
+	assert.Equal(t, expected.authEndpoint, client.authEndpoint)
+	assert.Equal(t, expected.tokenEndpoint, client.tokenEndpoint)
+	assert.Equal(t, expected.JwksEndpoint, client.JwksEndpoint)
+	assert.Equal(t, expected.UserInfoEndpoint, client.UserInfoEndpoint)
+	assert.Equal(t, expected.RevocationEndpoint, client.RevocationEndpoint)
+	assert.Equal(t, expected.EndSessionEndpoint, client.EndSessionEndpoint)
+	assert.Equal(t, expected.DeviceAuthEndpoint, client.DeviceAuthEndpoint)
+}
+
+func TestWithAudience(t *testing.T) {
+	client := NewAuth0OidcClient("example.us.auth0.com")
+
+	rawUrl, _, err := client.LoginUrl("DummyState", "DummyNonce", WithAudience("https://api.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://api.example.com", authUrl.Query().Get("audience"))
+}
+
+func TestNamespacedClaims(t *testing.T) {
+	payload := validGooglePayloadForTest()
+	payload["https://example.com/roles"] = []interface{}{"admin"}
+	payload["https://example.com/plan"] = "pro"
+	payload["https://example.com/"] = "ignored"
+	payload["https://other.example.com/plan"] = "free"
+	token, err := NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256"}, payload, hmacSignerForTest("unused")), Auth0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := NamespacedClaims(token, "https://example.com/")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"roles": []interface{}{"admin"},
+		"plan":  "pro",
+	}, claims)
+}
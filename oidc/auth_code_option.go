@@ -0,0 +1,23 @@
+package oidc
+
+import "net/url"
+
+// AuthCodeOption は認可リクエストやトークンリクエストに追加するパラメータ
+type AuthCodeOption func(values url.Values)
+
+// WithParam は任意のパラメータを追加する。Auth0のaudienceなどIdP固有のパラメータを渡す場合に使う
+func WithParam(key string, value string) AuthCodeOption {
+	return func(values url.Values) {
+		values.Set(key, value)
+	}
+}
+
+// hasParam はoptsがkeyのパラメータを追加するかどうかを返す
+func hasParam(opts []AuthCodeOption, key string) bool {
+	values := url.Values{}
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	return values.Get(key) != ""
+}
@@ -0,0 +1,103 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AuthResponse は認可エンドポイントからリダイレクトで返されるレスポンス
+type AuthResponse struct {
+	Code  string
+	State string
+	// IdToken はresponse_typeにid_tokenを含めた場合に返される生のid_token
+	IdToken string
+	// AccessToken はインプリシットフローでresponse_typeに"id_token token"を指定した場合に返されるアクセストークン
+	AccessToken string
+	// SessionState はOP Session Managementに対応したIdPが返すログイン状態。CheckSessionMessageで使う
+	SessionState string
+	// User はAppleが初回のログイン時のみform_postで返すユーザー情報。それ以外の場合はnil
+	User *AuthResponseUser
+}
+
+// AuthResponseUser はAppleがform_postのuserパラメータで返すユーザー情報
+//
+// Appleはid_tokenに名前を含めず、初回のログイン時にのみこのパラメータで返すので、必要な場合はこの時点で保存する
+type AuthResponseUser struct {
+	Name struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	} `json:"name"`
+	Email string `json:"email"`
+}
+
+// AuthError は認可エンドポイントがエラーを返した場合のエラー
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.2.1
+type AuthError struct {
+	// Code はaccess_deniedなどのエラーコード
+	Code        string
+	Description string
+	// Uri はエラーの詳細を説明するページのURL
+	Uri   string
+	State string
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("authorization endpoint returned error: %s %s", e.Code, e.Description)
+	if e.Uri != "" {
+		msg += " (" + e.Uri + ")"
+	}
+
+	return msg
+}
+
+// ParseAuthResponse はコールバックのリクエストから認可レスポンスを取り出す
+//
+// クエリで返される場合とresponse_mode=form_postでPOSTのボディで返される場合の両方に対応する。
+// responseパラメータがある場合はJARMのレスポンスとして検証する
+func (c oidcClient) ParseAuthResponse(ctx context.Context, r *http.Request) (*AuthResponse, error) {
+	// ParseFormはPOSTのボディとクエリの両方を読み込む
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("failed to parse authorization response: %w", err)
+	}
+
+	if rawResponse := r.Form.Get("response"); rawResponse != "" {
+		return c.ParseJarmResponse(ctx, rawResponse)
+	}
+
+	if errCode := r.Form.Get("error"); errCode != "" {
+		return nil, &AuthError{
+			Code:        errCode,
+			Description: r.Form.Get("error_description"),
+			Uri:         r.Form.Get("error_uri"),
+			State:       r.Form.Get("state"),
+		}
+	}
+
+	resp := &AuthResponse{
+		Code:         r.Form.Get("code"),
+		State:        r.Form.Get("state"),
+		IdToken:      r.Form.Get("id_token"),
+		AccessToken:  r.Form.Get("access_token"),
+		SessionState: r.Form.Get("session_state"),
+	}
+	// Google One Tapはid_tokenをcredentialパラメータでPOSTする
+	if resp.IdToken == "" {
+		resp.IdToken = r.Form.Get("credential")
+	}
+	if rawUser := r.Form.Get("user"); rawUser != "" {
+		resp.User = &AuthResponseUser{}
+		if err := json.Unmarshal([]byte(rawUser), resp.User); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user of authorization response: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// WithResponseMode は認可リクエストにresponse_modeを含める。JARMの場合は"jwt"、Appleなどのform_postの場合は"form_post"を指定する
+func WithResponseMode(mode string) AuthCodeOption {
+	return WithParam("response_mode", mode)
+}
@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOidcClient_ParseAuthResponse(t *testing.T) {
+	formPostRequest := func(values url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return r
+	}
+	expectedUser := &AuthResponseUser{Email: "user@privaterelay.appleid.com"}
+	expectedUser.Name.FirstName = "Taro"
+	expectedUser.Name.LastName = "Yamada"
+
+	patterns := []struct {
+		desc     string
+		request  *http.Request
+		expected *AuthResponse
+	}{
+		{
+			"query",
+			httptest.NewRequest(http.MethodGet, "/callback?code=DummyCode&state=12345678", nil),
+			&AuthResponse{Code: "DummyCode", State: "12345678"},
+		},
+		{
+			"form_post",
+			formPostRequest(url.Values{"code": {"DummyCode"}, "state": {"12345678"}, "id_token": {"DummyIdToken"}}),
+			&AuthResponse{Code: "DummyCode", State: "12345678", IdToken: "DummyIdToken"},
+		},
+		{
+			"implicit with access token",
+			formPostRequest(url.Values{"id_token": {"DummyIdToken"}, "access_token": {"DummyAccessToken"}, "state": {"12345678"}}),
+			&AuthResponse{State: "12345678", IdToken: "DummyIdToken", AccessToken: "DummyAccessToken"},
+		},
+		{
+			"credential of Google One Tap",
+			formPostRequest(url.Values{"credential": {"DummyIdToken"}, "g_csrf_token": {"DummyCsrfToken"}}),
+			&AuthResponse{IdToken: "DummyIdToken"},
+		},
+		{
+			"form_post with user of Apple",
+			formPostRequest(url.Values{
+				"code":  {"DummyCode"},
+				"state": {"12345678"},
+				"user":  {`{"name": {"firstName": "Taro", "lastName": "Yamada"}, "email": "user@privaterelay.appleid.com"}`},
+			}),
+			&AuthResponse{Code: "DummyCode", State: "12345678", User: expectedUser},
+		},
+	}
+
+	client := NewGoogleOidcClient()
+	for _, pattern := range patterns {
+		actual, err := client.ParseAuthResponse(context.Background(), pattern.request)
+
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, pattern.expected, actual, pattern.desc)
+	}
+
+	_, err := client.ParseAuthResponse(context.Background(), formPostRequest(url.Values{
+		"error":     {"user_cancelled_authorize"},
+		"error_uri": {"https://example.com/errors"},
+		"state":     {"12345678"},
+	}))
+	var authErr *AuthError
+	assert.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "user_cancelled_authorize", authErr.Code)
+	assert.Equal(t, "https://example.com/errors", authErr.Uri)
+
+	_, err = client.ParseAuthResponse(context.Background(), formPostRequest(url.Values{"code": {"DummyCode"}, "user": {"invalid"}}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AuthnPolicy はid_tokenのacrとamrに対する要件
+//
+// 多要素認証を必須にしたいなど、IdPでの認証方法を制限したい場合に使う
+type AuthnPolicy struct {
+	// AcrValues は受け入れるacrの値。空でない場合はacrがいずれかと一致する必要がある
+	AcrValues []string
+	// AmrMethods は必須の認証方法。空でない場合はamrに全て含まれている必要がある
+	AmrMethods []string
+}
+
+// AuthnPolicyError はid_tokenがAuthnPolicyを満たさない場合のエラー
+type AuthnPolicyError struct {
+	// Claim は要件を満たさなかったクレーム。"acr"もしくは"amr"
+	Claim    string
+	Required []string
+	Actual   []string
+}
+
+func (e *AuthnPolicyError) Error() string {
+	return fmt.Sprintf(
+		"%s claim does not satisfy policy: required %s, actual %s",
+		e.Claim,
+		strings.Join(e.Required, " "),
+		strings.Join(e.Actual, " "),
+	)
+}
+
+func (e *AuthnPolicyError) Unwrap() error {
+	return errAuthnPolicy
+}
+
+// validate はacrとamrが要件を満たしているかを確認する
+func (p AuthnPolicy) validate(claims IdTokenClaims) error {
+	if len(p.AcrValues) > 0 && !contains(p.AcrValues, claims.Acr) {
+		return &AuthnPolicyError{Claim: "acr", Required: p.AcrValues, Actual: []string{claims.Acr}}
+	}
+
+	for _, method := range p.AmrMethods {
+		if !contains(claims.Amr, method) {
+			return &AuthnPolicyError{Claim: "amr", Required: p.AmrMethods, Actual: claims.Amr}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+
+	return false
+}
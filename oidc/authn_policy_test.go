@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuthnPolicy_Validate(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		policy        AuthnPolicy
+		claims        IdTokenClaims
+		expectedClaim string
+	}{
+		{"no policy", true, AuthnPolicy{}, IdTokenClaims{}, ""},
+		{"acr matches", true, AuthnPolicy{AcrValues: []string{"urn:mace:incommon:iap:silver", "phr"}}, IdTokenClaims{Acr: "phr"}, ""},
+		{"acr mismatch", false, AuthnPolicy{AcrValues: []string{"phr"}}, IdTokenClaims{Acr: "0"}, "acr"},
+		{"acr missing", false, AuthnPolicy{AcrValues: []string{"phr"}}, IdTokenClaims{}, "acr"},
+		{"amr contains all", true, AuthnPolicy{AmrMethods: []string{"mfa", "hwk"}}, IdTokenClaims{Amr: []string{"pwd", "hwk", "mfa"}}, ""},
+		{"amr lacks method", false, AuthnPolicy{AmrMethods: []string{"mfa", "hwk"}}, IdTokenClaims{Amr: []string{"pwd", "mfa"}}, "amr"},
+	}
+
+	for _, pattern := range patterns {
+		err := pattern.policy.validate(pattern.claims)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			var policyErr *AuthnPolicyError
+			assert.True(t, errors.As(err, &policyErr), pattern.desc)
+			assert.Equal(t, pattern.expectedClaim, policyErr.Claim, pattern.desc)
+			assert.ErrorIs(t, err, errAuthnPolicy, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// backchannelLogoutEvent はlogout tokenのeventsクレームに含まれるバックチャネルログアウトのイベントの識別子
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// LogoutToken はバックチャネルログアウトでIdPから送られる検証済みのlogout tokenのクレーム
+//
+// refs: https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+type LogoutToken struct {
+	Iss    string                     `json:"iss"`
+	Aud    Audience                   `json:"aud"`
+	Iat    int64                      `json:"iat"`
+	Exp    int64                      `json:"exp"`
+	Jti    string                     `json:"jti"`
+	Sub    string                     `json:"sub"`
+	Sid    string                     `json:"sid"`
+	Events map[string]json.RawMessage `json:"events"`
+	Nonce  string                     `json:"nonce"`
+}
+
+// VerifyLogoutToken はlogout tokenの署名とクレームを検証する
+//
+// id_tokenと同じ鍵で署名を検証し、iss, aud, iat, expに加え、
+// eventsにバックチャネルログアウトのイベントがあること、nonceがないこと、subかsidのどちらかがあることを確認する。
+// nonceを禁止することでid_tokenをlogout tokenとして送り付けられることを防ぐ
+//
+// refs: https://openid.net/specs/openid-connect-backchannel-1_0.html#Validation
+func (c oidcClient) VerifyLogoutToken(ctx context.Context, rawToken string) (*LogoutToken, error) {
+	token, err := NewIdToken(rawToken, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse logout token: %w", err)
+	}
+
+	v := c.Verifier()
+	if err := v.checkAlg(token.header.Alg); err != nil {
+		return nil, err
+	}
+	if err := v.verifySignature(ctx, token); err != nil {
+		return nil, err
+	}
+
+	claims := &LogoutToken{}
+	if err := token.Claims(claims); err != nil {
+		return nil, err
+	}
+	if err := v.tenantClaims(token).validateIss(claims.Iss); err != nil {
+		return nil, err
+	}
+	if !claims.Aud.contains(c.ClientId) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAudience, []string(claims.Aud))
+	}
+	if err := v.claims.validateIat(claims.Iat); err != nil {
+		return nil, err
+	}
+	if err := v.claims.validateExp(claims.Exp); err != nil {
+		return nil, err
+	}
+
+	// イベントの値はJSONのオブジェクトである必要がある
+	event, ok := claims.Events[backchannelLogoutEvent]
+	if !ok || json.Unmarshal(event, &map[string]interface{}{}) != nil {
+		return nil, errLogoutEventMissing
+	}
+	if claims.Nonce != "" {
+		return nil, errLogoutNonceFound
+	}
+	if claims.Sub == "" && claims.Sid == "" {
+		return nil, errLogoutSubSidMissing
+	}
+
+	return claims, nil
+}
+
+// BackchannelLogoutHandler はIdPからのバックチャネルログアウトのリクエストを受け付けるhttp.Handlerを返す
+//
+// logout tokenを検証した後にterminateを呼ぶので、terminateではsubやsidに一致するセッションを破棄する。
+// logout tokenが不正な場合は400を返す。terminateがエラーを返した場合はRP側の失敗なので500を返す
+//
+// refs: https://openid.net/specs/openid-connect-backchannel-1_0.html#BCResponse
+func (c oidcClient) BackchannelLogoutHandler(terminate func(ctx context.Context, token *LogoutToken) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		rawToken := r.PostFormValue("logout_token")
+		if rawToken == "" {
+			writeLogoutError(w, http.StatusBadRequest, errLogoutTokenMissing)
+
+			return
+		}
+		token, err := c.VerifyLogoutToken(r.Context(), rawToken)
+		if err != nil {
+			writeLogoutError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		// セッションの破棄に失敗した理由はIdPに返す必要がないので、ステータスコードのみ返す
+		if err := terminate(r.Context(), token); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// writeLogoutError はバックチャネルログアウトのエラーレスポンスを返す
+func writeLogoutError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             "invalid_request",
+		"error_description": err.Error(),
+	})
+}
@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validLogoutTokenPayloadForTest() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    "https://accounts.google.com",
+		"aud":    os.Getenv("GOOGLE_CLIENT_ID"),
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(2 * time.Minute).Unix(),
+		"jti":    "bWJq",
+		"sub":    "1234567890",
+		"sid":    "08a5019c-17e1-4977-8f42-65a12843ea02",
+		"events": map[string]interface{}{backchannelLogoutEvent: map[string]interface{}{}},
+	}
+}
+
+func TestOidcClient_VerifyLogoutToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	logoutPayload := func(modify func(payload map[string]interface{})) map[string]interface{} {
+		payload := validLogoutTokenPayloadForTest()
+		modify(payload)
+
+		return payload
+	}
+
+	patterns := []struct {
+		desc     string
+		payload  map[string]interface{}
+		expected error
+	}{
+		{"valid", logoutPayload(func(map[string]interface{}) {}), nil},
+		{"only sid", logoutPayload(func(p map[string]interface{}) { delete(p, "sub") }), nil},
+		{"iss mismatch", logoutPayload(func(p map[string]interface{}) { p["iss"] = "https://example.com" }), ErrInvalidIssuer},
+		{"aud mismatch", logoutPayload(func(p map[string]interface{}) { p["aud"] = "another-client" }), ErrInvalidAudience},
+		{"iat missing", logoutPayload(func(p map[string]interface{}) { delete(p, "iat") }), errIatMissing},
+		{"expired", logoutPayload(func(p map[string]interface{}) { p["exp"] = time.Now().Add(-time.Hour).Unix() }), ErrTokenExpired},
+		{"events missing", logoutPayload(func(p map[string]interface{}) { delete(p, "events") }), errLogoutEventMissing},
+		{
+			"event is not object",
+			logoutPayload(func(p map[string]interface{}) { p["events"] = map[string]interface{}{backchannelLogoutEvent: true} }),
+			errLogoutEventMissing,
+		},
+		{"id_token with nonce", logoutPayload(func(p map[string]interface{}) { p["nonce"] = "DummyNonce" }), errLogoutNonceFound},
+		{"sub and sid missing", logoutPayload(func(p map[string]interface{}) { delete(p, "sub"); delete(p, "sid") }), errLogoutSubSidMissing},
+	}
+
+	for _, pattern := range patterns {
+		rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1", "typ": "logout+jwt"}, pattern.payload, rsaSignerForTest(rsaKey))
+		token, err := client.VerifyLogoutToken(context.Background(), rawToken)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "08a5019c-17e1-4977-8f42-65a12843ea02", token.Sid, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_BackchannelLogoutHandler(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	validToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, validLogoutTokenPayloadForTest(), rsaSignerForTest(rsaKey))
+	noncePayload := validLogoutTokenPayloadForTest()
+	noncePayload["nonce"] = "DummyNonce"
+	nonceToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, noncePayload, rsaSignerForTest(rsaKey))
+
+	patterns := []struct {
+		desc           string
+		method         string
+		logoutToken    string
+		terminateErr   error
+		expectedStatus int
+		expectedCalled bool
+	}{
+		{"valid", http.MethodPost, validToken, nil, http.StatusOK, true},
+		{"GET", http.MethodGet, validToken, nil, http.StatusMethodNotAllowed, false},
+		{"logout_token missing", http.MethodPost, "", nil, http.StatusBadRequest, false},
+		{"invalid logout_token", http.MethodPost, validToken + "x", nil, http.StatusBadRequest, false},
+		{"logout_token with nonce", http.MethodPost, nonceToken, nil, http.StatusBadRequest, false},
+		{"terminate failed", http.MethodPost, validToken, errors.New("session store unavailable"), http.StatusInternalServerError, true},
+	}
+
+	for _, pattern := range patterns {
+		called := false
+		h := client.BackchannelLogoutHandler(func(ctx context.Context, token *LogoutToken) error {
+			called = true
+			assert.Equal(t, "1234567890", token.Sub, pattern.desc)
+
+			return pattern.terminateErr
+		})
+
+		r := httptest.NewRequest(pattern.method, "/backchannel_logout", strings.NewReader(url.Values{"logout_token": {pattern.logoutToken}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, pattern.expectedStatus, w.Code, pattern.desc)
+		assert.Equal(t, pattern.expectedCalled, called, pattern.desc)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), pattern.desc)
+	}
+}
@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cibaGrantType = "urn:openid:params:grant-type:ciba"
+
+// BackchannelAuthRequest はCIBAのバックチャネル認証リクエスト
+//
+// ユーザーはLoginHint, LoginHintToken, IdTokenHintのいずれか1つで指定する
+//
+// refs: https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_request
+type BackchannelAuthRequest struct {
+	// Scopes はリクエストするスコープ。openidが含まれていない場合は追加する
+	Scopes         []string
+	LoginHint      string
+	LoginHintToken string
+	IdTokenHint    string
+	// BindingMessage はユーザーの端末と認証を要求した端末の両方に表示し、同じリクエストであることを確認させるメッセージ
+	BindingMessage string
+	UserCode       string
+	// RequestedExpiry はauth_req_idの有効期間として要求する秒数。0の場合はIdPが決める
+	RequestedExpiry int
+	// ClientNotificationToken はpingモードでIdPからの通知に付けられるトークン
+	ClientNotificationToken string
+}
+
+// BackchannelAuth はバックチャネル認証エンドポイントのレスポンス
+type BackchannelAuth struct {
+	AuthReqId string `json:"auth_req_id"`
+	// ExpiresIn はauth_req_idの有効期間(秒)
+	ExpiresIn int `json:"expires_in"`
+	// Interval はpollモードでトークンエンドポイントをポーリングする間隔(秒)
+	Interval int `json:"interval"`
+}
+
+// AuthorizeBackchannel はバックチャネル認証エンドポイントにリクエストし、ユーザーの端末での認証を開始する
+func (c oidcClient) AuthorizeBackchannel(ctx context.Context, req BackchannelAuthRequest) (*BackchannelAuth, error) {
+	if c.BackchannelAuthEndpoint == "" {
+		return nil, errCibaEndpointMissing
+	}
+	if req.LoginHint == "" && req.LoginHintToken == "" && req.IdTokenHint == "" {
+		return nil, errLoginHintMissing
+	}
+
+	values := url.Values{}
+	scopes := req.Scopes
+	if !contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	values.Add("scope", strings.Join(scopes, " "))
+	for key, value := range map[string]string{
+		"login_hint":                req.LoginHint,
+		"login_hint_token":          req.LoginHintToken,
+		"id_token_hint":             req.IdTokenHint,
+		"binding_message":           req.BindingMessage,
+		"user_code":                 req.UserCode,
+		"client_notification_token": req.ClientNotificationToken,
+	} {
+		if value != "" {
+			values.Add(key, value)
+		}
+	}
+	if req.RequestedExpiry > 0 {
+		values.Add("requested_expiry", strconv.Itoa(req.RequestedExpiry))
+	}
+
+	auth := &BackchannelAuth{}
+	if err := c.postFormWithClientAuth(ctx, c.BackchannelAuthEndpoint, values, auth); err != nil {
+		return nil, fmt.Errorf("failed to POST backchannel authentication endpoint: %w", err)
+	}
+
+	return auth, nil
+}
+
+// PollBackchannelToken はpollモードでユーザーが認証を完了するまでトークンエンドポイントをポーリングし、
+// 検証済みのid_tokenを含むトークンを返す
+func (c oidcClient) PollBackchannelToken(ctx context.Context, auth *BackchannelAuth) (*Token, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	tokenResp, err := c.pollToken(ctx, cibaTokenValues(auth.AuthReqId), interval, slowDownInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.verifyTokenResponse(ctx, tokenResp)
+}
+
+// BackchannelToken はpingモードでIdPから通知を受け取った後にトークンを取得し、検証済みのid_tokenを含むトークンを返す
+func (c oidcClient) BackchannelToken(ctx context.Context, authReqId string) (*Token, error) {
+	tokenResp, err := c.postToken(ctx, cibaTokenValues(authReqId))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.verifyTokenResponse(ctx, tokenResp)
+}
+
+func cibaTokenValues(authReqId string) url.Values {
+	values := url.Values{}
+	values.Add("grant_type", cibaGrantType)
+	values.Add("auth_req_id", authReqId)
+
+	return values
+}
@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testBackchannelAuthEndpoint = "https://example.com/bc-authorize"
+
+func TestOidcClient_AuthorizeBackchannel(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	httpmock.RegisterResponder(http.MethodPost, testBackchannelAuthEndpoint, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = req.PostForm
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"auth_req_id": "DummyAuthReqId", "expires_in": 120, "interval": 2}`), nil
+	})
+
+	client := NewGoogleOidcClient()
+	client.BackchannelAuthEndpoint = testBackchannelAuthEndpoint
+	auth, err := client.AuthorizeBackchannel(context.Background(), BackchannelAuthRequest{
+		Scopes:         []string{"email"},
+		LoginHint:      "user@example.com",
+		BindingMessage: "W4SCT",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &BackchannelAuth{AuthReqId: "DummyAuthReqId", ExpiresIn: 120, Interval: 2}, auth)
+	assert.Equal(t, "openid email", form.Get("scope"))
+	assert.Equal(t, "user@example.com", form.Get("login_hint"))
+	assert.Equal(t, "W4SCT", form.Get("binding_message"))
+	assert.Empty(t, form.Get("id_token_hint"))
+
+	_, err = client.AuthorizeBackchannel(context.Background(), BackchannelAuthRequest{})
+	assert.ErrorIs(t, err, errLoginHintMissing)
+
+	_, err = NewGoogleOidcClient().AuthorizeBackchannel(context.Background(), BackchannelAuthRequest{LoginHint: "user@example.com"})
+	assert.ErrorIs(t, err, errCibaEndpointMissing)
+}
+
+func TestOidcClient_BackchannelToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	validIdToken := encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		validGooglePayloadForTest(),
+		rsaSignerForTest(rsaKey),
+	)
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		body          string
+	}{
+		{"valid", true, `{"access_token": "DummyAccessToken", "id_token": "` + validIdToken + `"}`},
+		{"id_token missing", false, `{"access_token": "DummyAccessToken"}`},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(http.StatusOK, pattern.body))
+
+		token, err := client.BackchannelToken(context.Background(), "DummyAuthReqId")
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "1234567890", token.IdToken.StandardClaims().Sub, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errIdTokenMissing, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_PollBackchannelToken(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewGoogleOidcClient()
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.ResponderFromMultipleResponses([]*http.Response{
+		httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+		httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "access_denied"}`),
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.PollBackchannelToken(ctx, &BackchannelAuth{AuthReqId: "DummyAuthReqId", Interval: 1})
+
+	var tokenErr *OAuthError
+	assert.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, "access_denied", tokenErr.Code)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
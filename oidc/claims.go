@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultLeeway はサーバー間の時刻のずれとして許容するデフォルトの時間
+const defaultLeeway = 30 * time.Second
+
+// Audience はaudクレーム。OIDC Coreでは文字列と文字列の配列のどちらの形式も許されている
+type Audience []string
+
+// UnmarshalJSON は文字列と文字列の配列のどちらの形式のaudもunmarshalする
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(b, &multi); err != nil {
+		return fmt.Errorf("failed to unmarshal aud claim: %w", err)
+	}
+	*a = multi
+
+	return nil
+}
+
+func (a Audience) contains(clientId string) bool {
+	return contains(a, clientId)
+}
+
+// IdTokenClaims はid_tokenのpayloadのうちOIDC Coreで定義されているクレーム
+//
+// Google以外のIdPのid_tokenはこの構造体にunmarshalする。
+// IdP固有のクレームが必要な場合はidToken.Claimsで独自の構造体にunmarshalする
+type IdTokenClaims struct {
+	Iss string `json:"iss"`
+	// ID Provider内でのID。メアドではなくこちらがユーザー識別子となる
+	Sub string `json:"sub"`
+	// クライアントID
+	Aud   Audience `json:"aud"`
+	Exp   int64    `json:"exp"`
+	Iat   int64    `json:"iat"`
+	Nbf   int64    `json:"nbf"`
+	Email string   `json:"email"`
+	// EmailVerified はIdPがメールアドレスの所有を確認済みかどうか
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	// Picture はプロフィール画像のURL
+	Picture string `json:"picture"`
+	// Locale はBCP47形式の言語タグ。例: ja-JP
+	Locale string `json:"locale"`
+	// 認可リクエストに含めたnonce
+	Nonce string `json:"nonce"`
+	// アクセストークンのハッシュ
+	AtHash string `json:"at_hash"`
+	// 認可コードのハッシュ。ハイブリッドフローで認可エンドポイントから返されるid_tokenに含まれる
+	CHash string `json:"c_hash"`
+	// トークンが発行された相手。audが複数ある場合に含まれる
+	Azp string `json:"azp"`
+	// エンドユーザーが認証を行った時刻
+	AuthTime int64 `json:"auth_time"`
+	// 認証コンテキストのクラス。IdPが満たした認証の強度を表す
+	Acr string `json:"acr"`
+	// 認証に使われた方法。例: pwd, mfa, hwk
+	Amr []string `json:"amr"`
+	// トークンの一意な識別子。ReplayCacheで同じトークンの再利用を検出するのに使う
+	Jti string `json:"jti"`
+}
+
+func (claims IdTokenClaims) standardClaims() IdTokenClaims {
+	return claims
+}
+
+func (claims IdTokenClaims) GetSub() string {
+	return claims.Sub
+}
+
+// GetEmail はid_tokenからメールアドレスを取得する
+//
+// IdPによってはid_tokenにメールアドレスが入っていないので、その場合はエラーを返す
+func (claims IdTokenClaims) GetEmail() (string, error) {
+	if claims.Email == "" {
+		return "", errEmailNotFound
+	}
+
+	return claims.Email, nil
+}
+
+// claimsValidator はid_tokenのクレームを検証する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+type claimsValidator struct {
+	// issuers はissとして受け入れる値。Googleのように複数の表記を使うIdPがあるため複数指定できる
+	issuers  []string
+	clientId string
+	// leeway はexp, iat, nbfの検証で許容する時刻のずれ
+	leeway time.Duration
+	// requireAzp はazpがclientIdと一致することを必須にするかどうか
+	requireAzp bool
+	// requireAuthTime はauth_timeを必須にするかどうか
+	requireAuthTime bool
+	// maxAge はauth_timeからの経過時間として許容する最大の時間。0の場合は確認しない
+	maxAge time.Duration
+	// policy はacrとamrに対する要件
+	policy AuthnPolicy
+	now    func() time.Time
+}
+
+func newClaimsValidator(issuers []string, clientId string, leeway time.Duration) claimsValidator {
+	return claimsValidator{issuers: issuers, clientId: clientId, leeway: leeway, now: time.Now}
+}
+
+// forTenant はissuersのテナントIDのプレースホルダをtenantIdに置き換えたclaimsValidatorを返す
+//
+// マルチテナントのIdPではissにid_tokenのテナントIDが入るので、テナントIDのクレームからissを組み立てて比較する
+func (v claimsValidator) forTenant(tenantId string) claimsValidator {
+	issuers := make([]string, len(v.issuers))
+	for i, issuer := range v.issuers {
+		issuers[i] = strings.ReplaceAll(issuer, tenantIdPlaceholder, tenantId)
+	}
+	v.issuers = issuers
+
+	return v
+}
+
+// leewayOrDefault は0の場合にデフォルトの許容時間を返す。負の値の場合はずれを許容しない
+func leewayOrDefault(leeway time.Duration) time.Duration {
+	if leeway == 0 {
+		return defaultLeeway
+	}
+	if leeway < 0 {
+		return 0
+	}
+
+	return leeway
+}
+
+// validate はクレームを検証する
+//
+// - Issuer: 想定しているIdPが発行したか
+//
+// - Subject: ユーザーの識別子が含まれているか
+//
+// - Audience: 自分のクライアント向けに発行されたか
+//
+// - Authorized Party: audが複数ある場合に自分のクライアントに対して発行されたか
+//
+// - Expiration: 有効期限が切れていないか
+//
+// - Not Before: 有効になる時刻を過ぎているか
+//
+// - Issued At: 未来の時刻に発行されたことになっていないか
+//
+// - Authentication Time: maxAgeを指定した場合に、認証からの経過時間がmaxAgeを超えていないか
+//
+// - Authentication Context: acrとamrがpolicyの要件を満たしているか
+//
+// を確認する。時刻に関する検証ではleewayの分だけずれを許容する
+func (v claimsValidator) validate(claims IdTokenClaims) error {
+	if err := v.validateIss(claims.Iss); err != nil {
+		return err
+	}
+
+	if claims.Sub == "" {
+		return errSubMissing
+	}
+
+	if !claims.Aud.contains(v.clientId) {
+		return fmt.Errorf("%w: %v", ErrInvalidAudience, []string(claims.Aud))
+	}
+
+	if err := v.validateAzp(claims); err != nil {
+		return err
+	}
+
+	if err := v.validateExp(claims.Exp); err != nil {
+		return err
+	}
+
+	now := v.now()
+
+	if claims.Nbf != 0 && now.Add(v.leeway).Before(time.Unix(claims.Nbf, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if err := v.validateIat(claims.Iat); err != nil {
+		return err
+	}
+
+	if err := v.validateAuthTime(claims, now); err != nil {
+		return err
+	}
+
+	return v.policy.validate(claims)
+}
+
+// validateAuthTime はauth_timeを検証する
+//
+// max_ageを指定した認可リクエストではauth_timeは必須となる
+func (v claimsValidator) validateAuthTime(claims IdTokenClaims, now time.Time) error {
+	if claims.AuthTime == 0 {
+		if v.requireAuthTime || v.maxAge > 0 {
+			return errAuthTimeMissing
+		}
+
+		return nil
+	}
+
+	if v.maxAge > 0 && now.Add(-v.leeway).After(time.Unix(claims.AuthTime, 0).Add(v.maxAge)) {
+		return ErrAuthTooOld
+	}
+
+	return nil
+}
+
+// validateExp は有効期限が切れていないかを確認する
+func (v claimsValidator) validateExp(exp int64) error {
+	if !v.now().Add(-v.leeway).Before(time.Unix(exp, 0)) {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+// validateIat は発行時刻があり、未来の時刻でないかを確認する
+func (v claimsValidator) validateIat(iat int64) error {
+	if iat == 0 {
+		return errIatMissing
+	}
+	if v.now().Add(v.leeway).Before(time.Unix(iat, 0)) {
+		return errIatInFuture
+	}
+
+	return nil
+}
+
+// validateIss はissがissuersのいずれかと一致するかを確認する
+//
+// マルチテナントのIdPのissuerに含まれるテナントIDのプレースホルダは、任意のテナントIDと一致する。
+// トークンのテナントIDと照合する場合は、forTenantで置き換えたclaimsValidatorで確認する
+func (v claimsValidator) validateIss(iss string) error {
+	for _, issuer := range v.issuers {
+		if strings.Contains(issuer, tenantIdPlaceholder) {
+			if matchTenantIssuer(issuer, iss) {
+				return nil
+			}
+		} else if iss == issuer {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidIssuer, iss)
+}
+
+// matchTenantIssuer はissuerのテナントIDのプレースホルダの部分をテナントIDに置き換えるとissになるかを返す
+func matchTenantIssuer(issuer string, iss string) bool {
+	prefix, suffix, _ := strings.Cut(issuer, tenantIdPlaceholder)
+	if len(iss) <= len(prefix)+len(suffix) || !strings.HasPrefix(iss, prefix) || !strings.HasSuffix(iss, suffix) {
+		return false
+	}
+
+	return isTenantId(iss[len(prefix) : len(iss)-len(suffix)])
+}
+
+// isTenantId はsが英数字とハイフンのみからなるかを返す。パスの区切りやプレースホルダそのものは受け入れない
+func isTenantId(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// validateAzp はazpを検証する
+//
+// audが複数ある場合はazpが必須で、azpがある場合はclientIdと一致する必要がある
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func (v claimsValidator) validateAzp(claims IdTokenClaims) error {
+	if claims.Azp == "" {
+		if v.requireAzp || len(claims.Aud) > 1 {
+			return errAzpMissing
+		}
+
+		return nil
+	}
+
+	if claims.Azp != v.clientId {
+		return fmt.Errorf("%w: %s", errAzpMismatch, claims.Azp)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// ClaimsRequest は認可リクエストのclaimsパラメータ
+//
+// キーはクレーム名で、値をnilにすると任意のクレームとして要求する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
+type ClaimsRequest struct {
+	IdToken  map[string]*ClaimRequest `json:"id_token,omitempty"`
+	UserInfo map[string]*ClaimRequest `json:"userinfo,omitempty"`
+}
+
+// ClaimRequest は個別のクレームに対する要求
+type ClaimRequest struct {
+	// Essential は必須のクレームとして要求するかどうか
+	Essential bool     `json:"essential,omitempty"`
+	Value     string   `json:"value,omitempty"`
+	Values    []string `json:"values,omitempty"`
+}
+
+// WithClaims は認可リクエストにclaimsを含める
+func WithClaims(claims ClaimsRequest) AuthCodeOption {
+	return func(values url.Values) {
+		// map[string]*ClaimRequestのmarshalは失敗しない
+		raw, _ := json.Marshal(claims)
+		values.Set("claims", string(raw))
+	}
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestWithClaims(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		claims   ClaimsRequest
+		expected string
+	}{
+		{
+			"voluntary claims",
+			ClaimsRequest{IdToken: map[string]*ClaimRequest{"email": nil}},
+			`{"id_token":{"email":null}}`,
+		},
+		{
+			"essential claim and value",
+			ClaimsRequest{
+				IdToken:  map[string]*ClaimRequest{"auth_time": {Essential: true}},
+				UserInfo: map[string]*ClaimRequest{"acr": {Values: []string{"urn:mace:incommon:iap:silver"}}},
+			},
+			`{"id_token":{"auth_time":{"essential":true}},"userinfo":{"acr":{"values":["urn:mace:incommon:iap:silver"]}}}`,
+		},
+	}
+
+	for _, pattern := range patterns {
+		values := url.Values{}
+		WithClaims(pattern.claims)(values)
+
+		assert.JSONEq(t, pattern.expected, values.Get("claims"), pattern.desc)
+	}
+}
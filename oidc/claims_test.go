@@ -0,0 +1,211 @@
+package oidc
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAudience_UnmarshalJSON(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		raw           string
+		expected      Audience
+	}{
+		{"string", true, `"client-1"`, Audience{"client-1"}},
+		{"array", true, `["client-1", "client-2"]`, Audience{"client-1", "client-2"}},
+		{"number", false, `1`, nil},
+	}
+
+	for _, pattern := range patterns {
+		var actual Audience
+		err := json.Unmarshal([]byte(pattern.raw), &actual)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, actual, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestClaimsValidator_Validate(t *testing.T) {
+	const (
+		issuer   = "https://example.com"
+		clientId = "client-1"
+	)
+	now := time.Now()
+	validClaims := func() IdTokenClaims {
+		return IdTokenClaims{
+			Iss: issuer,
+			Sub: "1234567890",
+			Aud: Audience{"client-0", clientId},
+			Exp: now.Add(time.Hour).Unix(),
+			Iat: now.Add(-time.Minute).Unix(),
+			Azp: clientId,
+		}
+	}
+
+	patterns := []struct {
+		desc     string
+		modify   func(claims *IdTokenClaims)
+		expected error
+	}{
+		{"valid", func(claims *IdTokenClaims) {}, nil},
+		{"iss mismatch", func(claims *IdTokenClaims) { claims.Iss = "https://example.org" }, ErrInvalidIssuer},
+		{"sub missing", func(claims *IdTokenClaims) { claims.Sub = "" }, errSubMissing},
+		{"aud mismatch", func(claims *IdTokenClaims) { claims.Aud = Audience{"client-2"} }, ErrInvalidAudience},
+		{"expired", func(claims *IdTokenClaims) { claims.Exp = now.Unix() }, ErrTokenExpired},
+		{"nbf in future", func(claims *IdTokenClaims) { claims.Nbf = now.Add(time.Minute).Unix() }, ErrTokenNotYetValid},
+		{"nbf passed", func(claims *IdTokenClaims) { claims.Nbf = now.Unix() }, nil},
+		{"iat missing", func(claims *IdTokenClaims) { claims.Iat = 0 }, errIatMissing},
+		{"iat in future", func(claims *IdTokenClaims) { claims.Iat = now.Add(time.Minute).Unix() }, errIatInFuture},
+		{"azp matches", func(claims *IdTokenClaims) { claims.Azp = clientId }, nil},
+		{"azp mismatch", func(claims *IdTokenClaims) { claims.Azp = "client-0" }, errAzpMismatch},
+		{"multiple aud without azp", func(claims *IdTokenClaims) { claims.Azp = "" }, errAzpMissing},
+		{"single aud without azp", func(claims *IdTokenClaims) { claims.Aud = Audience{clientId} }, nil},
+	}
+
+	v := newClaimsValidator([]string{issuer}, clientId, 0)
+	v.now = func() time.Time { return now }
+	for _, pattern := range patterns {
+		claims := validClaims()
+		pattern.modify(&claims)
+		err := v.validate(claims)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestClaimsValidator_Leeway(t *testing.T) {
+	const (
+		issuer   = "https://example.com"
+		clientId = "client-1"
+	)
+	now := time.Now()
+
+	patterns := []struct {
+		desc     string
+		leeway   time.Duration
+		claims   IdTokenClaims
+		expected error
+	}{
+		{
+			"expired within leeway",
+			30 * time.Second,
+			IdTokenClaims{Exp: now.Add(-10 * time.Second).Unix(), Iat: now.Add(-time.Hour).Unix()},
+			nil,
+		},
+		{
+			"expired beyond leeway",
+			30 * time.Second,
+			IdTokenClaims{Exp: now.Add(-time.Minute).Unix(), Iat: now.Add(-time.Hour).Unix()},
+			ErrTokenExpired,
+		},
+		{
+			"iat in future within leeway",
+			30 * time.Second,
+			IdTokenClaims{Exp: now.Add(time.Hour).Unix(), Iat: now.Add(10 * time.Second).Unix()},
+			nil,
+		},
+		{
+			"nbf in future within leeway",
+			30 * time.Second,
+			IdTokenClaims{Exp: now.Add(time.Hour).Unix(), Iat: now.Unix(), Nbf: now.Add(10 * time.Second).Unix()},
+			nil,
+		},
+		{
+			"iat in future without leeway",
+			0,
+			IdTokenClaims{Exp: now.Add(time.Hour).Unix(), Iat: now.Add(10 * time.Second).Unix()},
+			errIatInFuture,
+		},
+	}
+
+	for _, pattern := range patterns {
+		v := newClaimsValidator([]string{issuer}, clientId, pattern.leeway)
+		v.now = func() time.Time { return now }
+		pattern.claims.Iss = issuer
+		pattern.claims.Sub = "1234567890"
+		pattern.claims.Aud = Audience{clientId}
+		err := v.validate(pattern.claims)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestLeewayOrDefault(t *testing.T) {
+	assert.Equal(t, defaultLeeway, leewayOrDefault(0))
+	assert.Equal(t, time.Minute, leewayOrDefault(time.Minute))
+	assert.Equal(t, time.Duration(0), leewayOrDefault(-1))
+}
+
+func TestClaimsValidator_RequireAzp(t *testing.T) {
+	const clientId = "client-1"
+
+	patterns := []struct {
+		desc     string
+		azp      string
+		expected error
+	}{
+		{"azp matches", clientId, nil},
+		{"azp missing", "", errAzpMissing},
+		{"azp mismatch", "client-2", errAzpMismatch},
+	}
+
+	v := newClaimsValidator([]string{"https://example.com"}, clientId, 0)
+	v.requireAzp = true
+	for _, pattern := range patterns {
+		err := v.validateAzp(IdTokenClaims{Aud: Audience{clientId}, Azp: pattern.azp})
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestClaimsValidator_AuthTime(t *testing.T) {
+	now := time.Now()
+
+	patterns := []struct {
+		desc            string
+		requireAuthTime bool
+		maxAge          time.Duration
+		authTime        int64
+		expected        error
+	}{
+		{"not required", false, 0, 0, nil},
+		{"required but missing", true, 0, 0, errAuthTimeMissing},
+		{"required", true, 0, now.Add(-time.Hour).Unix(), nil},
+		{"max_age without auth_time", false, 5 * time.Minute, 0, errAuthTimeMissing},
+		{"within max_age", false, 5 * time.Minute, now.Add(-4 * time.Minute).Unix(), nil},
+		{"within max_age and leeway", false, 5 * time.Minute, now.Add(-5*time.Minute - 10*time.Second).Unix(), nil},
+		{"too old", false, 5 * time.Minute, now.Add(-6 * time.Minute).Unix(), ErrAuthTooOld},
+	}
+
+	for _, pattern := range patterns {
+		v := newClaimsValidator(nil, "", 30*time.Second)
+		v.requireAuthTime = pattern.requireAuthTime
+		v.maxAge = pattern.maxAge
+		err := v.validateAuthTime(IdTokenClaims{AuthTime: pattern.authTime}, now)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
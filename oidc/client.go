@@ -4,71 +4,234 @@ package oidc
 import (
 	"context"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
-const httpTimeoutSec = 10
-
 type oidcClient struct {
 	IdProvider
+	// Issuer はid_tokenのissとなるIdPの識別子
+	Issuer        string
 	ClientId      string
 	clientSecret  clientSecret
 	authEndpoint  string
 	tokenEndpoint string
 	JwksEndpoint  string
+	// clientSecretSource はclient_secretを都度生成する場合に設定する。nilの場合はclientSecretを使う
+	clientSecretSource func() (clientSecret, error)
+	// UserInfoEndpoint はUserInfoで使うUserInfoエンドポイント
+	UserInfoEndpoint string
+	// IntrospectionEndpoint はIntrospectで使うトークンイントロスペクションエンドポイント
+	IntrospectionEndpoint string
+	// IntrospectionCache はイントロスペクションの結果のキャッシュ。nilの場合はキャッシュせず毎回問い合わせる
+	IntrospectionCache *introspectionCache
+	// RevocationEndpoint はRevokeで使うトークン失効エンドポイント
+	RevocationEndpoint string
+	// EndSessionEndpoint はEndSessionUrlで使うRP-Initiated Logoutのエンドポイント
+	EndSessionEndpoint string
+	// CheckSessionIframe はOP Session Managementでログイン状態を確認するIdPのiframeのURL
+	CheckSessionIframe string
+	// DeviceAuthEndpoint はデバイス認可グラントで使うデバイス認可エンドポイント
+	DeviceAuthEndpoint string
+	// BackchannelAuthEndpoint はCIBAで使うバックチャネル認証エンドポイント
+	BackchannelAuthEndpoint string
+	// ParEndpoint はPushed Authorization Requestsのエンドポイント。設定されている場合はAuthorizationUrlがPARを使う
+	ParEndpoint string
+	// RequestObjectSigner はリクエストオブジェクトに署名する秘密鍵。設定されている場合はPARでもリクエストオブジェクトを送る
+	RequestObjectSigner *RequestObjectSigner
+	// RequestObjectEncrypter はリクエストオブジェクトを暗号化するIdPの公開鍵。nilの場合は暗号化しない
+	RequestObjectEncrypter *RequestObjectEncrypter
+	// AllowedAlgs はid_tokenの署名アルゴリズムとして受け入れるもの。ヘッダのalgがこれに含まれない場合は検証に失敗する
+	AllowedAlgs []string
+	// AllowHS256 はclient_secretを鍵としたHS256で署名されたid_tokenを受け入れるかどうか
+	//
+	// LINEなど一部のIdPでのみ必要になるため、明示的に有効にした場合のみ受け入れる
+	AllowHS256 bool
+	// JwksCache は取得したJWKsのキャッシュ。nilの場合はキャッシュせず毎回JWKsエンドポイントから取得する
+	JwksCache *jwksCache
+	// X5cRoots はJWKsのx5cの証明書チェーンを検証するルート証明書。nilの場合はチェーンを検証しない
+	X5cRoots *x509.CertPool
+	// HttpClient はIdPへのリクエストに使うクライアント。プロキシや計測用のTransportを差し込みたい場合に設定する
+	//
+	// nilの場合はデフォルトのクライアントを使う。UrlPolicyのBlockPrivateAddressesとは併用できない
+	HttpClient *http.Client
+	// Transport はHttpClientがnilの場合に使うプロキシやTLSの設定
+	Transport TransportOptions
+	// Timeouts はIdPへのリクエストのタイムアウト。0の項目はデフォルト値を使う
+	Timeouts Timeouts
+	// Retry はIdPへのリクエストが一時的なエラーで失敗した場合のリトライの設定。0の項目はデフォルト値を使う
+	Retry RetryPolicy
+	// MaxResponseBytes はIdPからのレスポンスボディとして読み込む最大のサイズ。0の場合は1MiB
+	MaxResponseBytes int64
+	// KeyProvider はJWKsエンドポイントの代わりに署名検証の公開鍵を提供する。nilの場合はJwksEndpointから取得する
+	KeyProvider KeyProvider
+	// RedirectUrl は認可リクエストとトークンリクエストに含めるリダイレクトURI
+	RedirectUrl string
+	// Scopes はLoginUrlで認可リクエストに含めるscope
+	Scopes []string
+	// DisablePkce はLoginUrlでPKCEのcode_challengeを生成せず、Exchangeでcode_verifierを必須にしないかどうか
+	//
+	// PKCEに対応していないIdPを使う場合にのみtrueにする
+	DisablePkce bool
+	// ClientAuthMethod はトークンエンドポイントなどでのクライアント認証の方式。ゼロ値の場合はclient_secret_post
+	ClientAuthMethod ClientAuthMethod
+	// Leeway はid_tokenのexp, iat, nbfを検証する際に許容する時刻のずれ。0の場合は30秒、負の値の場合はずれを許容しない
+	Leeway time.Duration
+	// RequireAzp はid_tokenのazpクレームがClientIdと一致することを必須にするかどうか
+	//
+	// falseの場合でもaudが複数ある場合やazpがある場合はazpを検証する
+	RequireAzp bool
+	// RequireAuthTime はid_tokenのauth_timeクレームを必須にするかどうか
+	RequireAuthTime bool
+	// MaxAge はユーザーが認証してからの経過時間として許容する最大の時間
+	//
+	// 0より大きい場合は認可リクエストにmax_ageを含め、id_tokenのauth_timeからの経過時間がこれを超えた場合は検証に失敗する
+	MaxAge time.Duration
+	// AuthnPolicy はid_tokenのacrとamrに対する要件
+	//
+	// AcrValuesを指定した場合は認可リクエストにacr_valuesを含める
+	AuthnPolicy AuthnPolicy
+	// ClaimsRequest は認可リクエストのclaimsパラメータで個別に要求するクレーム。nilの場合はclaimsを含めない
+	ClaimsRequest *ClaimsRequest
+	// Logger はJWKsの取得、トークンリクエスト、id_tokenの検証の失敗などを出力するロガー。nilの場合は出力しない
+	//
+	// トークンやシークレットの値は出力しない
+	Logger *slog.Logger
+	// Metrics はid_tokenの検証やJWKsの取得の計測値を受け取る。nilの場合は記録しない
+	Metrics MetricsRecorder
+	// Tracer はIdPへのリクエストとid_tokenの検証のスパンを作成する。nilの場合は作成しない
+	Tracer Tracer
+	// Debug はIdPへのリクエストとレスポンスの内容をLoggerにDebugレベルで出力するかどうか
+	//
+	// トークンエンドポイントがリクエストを拒否した理由を調べる場合に使う。
+	// シークレット、トークン、Authorizationヘッダなどの値はマスクするが、本番環境では有効にしない
+	Debug bool
+	// UrlPolicy はJWKsとDiscoveryドキュメントを取得するURLの制限。既定ではhttpsのみを許可する
+	UrlPolicy UrlPolicy
+	// ReplayCache はid_tokenのjtiを記録し、同じトークンの再利用を拒否する。nilの場合は確認しない
+	//
+	// 設定した場合はjtiのないid_tokenも拒否する。id_tokenをAPIの認証情報として直接受け付ける場合に設定する
+	ReplayCache ReplayCache
+	// Dpop はトークンエンドポイントとリソースへのリクエストに付けるDPoP proofに署名する。nilの場合はDPoPを使わない
+	//
+	// 設定した場合、アクセストークンはAuthorizationヘッダにBearerではなくDPoPスキームで付ける
+	Dpop *dpopSigner
 }
 
 // tokenResponse はトークンエンドポイントのレスポンスをunmarshalするため構造体
 type tokenResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	Scope       string `json:"scope"`
-	TokenType   string `json:"token_type"`
-	IdToken     string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+	IdToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	// IssuedTokenType はトークンエクスチェンジで発行されたトークンの種類
+	IssuedTokenType string `json:"issued_token_type"`
+}
+
+// OAuthError はトークンエンドポイントなどがOAuth 2.0のエラーレスポンスを返した場合のエラー
+//
+// ボディがJSONでない場合もStatusCodeにはレスポンスのステータスコードが入る。
+// GitHubのようにステータスコード200でerrorを返す場合も含む
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+type OAuthError struct {
+	StatusCode int `json:"-"`
+	// Code はinvalid_grantなどのエラーコード
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	// Uri はエラーの詳細を説明するページのURL
+	Uri string `json:"error_uri"`
+}
+
+func (e *OAuthError) Error() string {
+	msg := fmt.Sprintf("endpoint returned %d: %s %s", e.StatusCode, e.Code, e.Description)
+	if e.Uri != "" {
+		msg += " (" + e.Uri + ")"
+	}
+
+	return msg
+}
+
+// Unwrap はステータスコードが2xxでない場合にerrUnexpectedStatusを返す
+//
+// GitHubのように200でerrorを返す場合はステータスコードとしては成功なのでnilを返す
+func (e *OAuthError) Unwrap() error {
+	if e.StatusCode >= http.StatusOK && e.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	return errUnexpectedStatus
 }
 
 func newOidcClient(
 	idProvider IdProvider,
+	issuer string,
 	clientId string,
 	clientSecret clientSecret,
 	authEndpoint string,
 	tokenEndpoint string,
 	jwksEndpoint string,
+	allowedAlgs []string,
 ) *oidcClient {
 	return &oidcClient{
 		IdProvider:    idProvider,
+		Issuer:        issuer,
 		ClientId:      clientId,
 		clientSecret:  clientSecret,
 		authEndpoint:  authEndpoint,
 		tokenEndpoint: tokenEndpoint,
 		JwksEndpoint:  jwksEndpoint,
+		AllowedAlgs:   allowedAlgs,
+		JwksCache:     defaultJwksCache,
+		Scopes:        defaultScopes,
 	}
 }
 
+// defaultScopes はLoginUrlで認可リクエストに含めるデフォルトのscope
+var defaultScopes = []string{"openid", "email", "profile"}
+
 // NewGoogleOidcClient はGoogleのクライアントを返す
-func NewGoogleOidcClient() *oidcClient {
-	return newOidcClient(
+func NewGoogleOidcClient(opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
 		Google,
+		"https://accounts.google.com",
 		os.Getenv("GOOGLE_CLIENT_ID"),
 		clientSecret(os.Getenv("GOOGLE_CLIENT_SECRET")),
 		"https://accounts.google.com/o/oauth2/v2/auth",
 		"https://oauth2.googleapis.com/token",
 		"https://www.googleapis.com/oauth2/v3/certs",
+		[]string{"RS256"},
 	)
+	client.UserInfoEndpoint = "https://openidconnect.googleapis.com/v1/userinfo"
+	client.RevocationEndpoint = "https://oauth2.googleapis.com/revoke"
+	client.Apply(opts...)
+
+	return client
 }
 
 // AuthUrl は認可エンドポイントのURLを返す
-func (c oidcClient) AuthUrl(respType string, scopes []string, redirectUrl string, state string) string {
-	return fmt.Sprintf(
+//
+// nonceが空の場合はnonceパラメータを付けない。PKCEのcode_challengeなどはoptsで追加する
+func (c oidcClient) AuthUrl(
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts ...AuthCodeOption,
+) string {
+	authUrl := fmt.Sprintf(
 		"%s?client_id=%s&response_type=%s&scope=%s&redirect_uri=%s&state=%s",
 		c.authEndpoint,
 		c.ClientId,
@@ -77,48 +240,222 @@ func (c oidcClient) AuthUrl(respType string, scopes []string, redirectUrl string
 		redirectUrl,
 		state,
 	)
+	if extras := c.authRequestExtras(nonce, opts); len(extras) > 0 {
+		authUrl += "&" + extras.Encode()
+	}
+
+	return authUrl
+}
+
+// authRequestExtras は認可リクエストに含める任意のパラメータを返す
+func (c oidcClient) authRequestExtras(nonce string, opts []AuthCodeOption) url.Values {
+	values := url.Values{}
+	if nonce != "" {
+		values.Set("nonce", nonce)
+	}
+	if c.MaxAge > 0 {
+		values.Set("max_age", strconv.FormatInt(int64(c.MaxAge/time.Second), 10))
+	}
+	if len(c.AuthnPolicy.AcrValues) > 0 {
+		values.Set("acr_values", strings.Join(c.AuthnPolicy.AcrValues, " "))
+	}
+	if c.ClaimsRequest != nil {
+		WithClaims(*c.ClaimsRequest)(values)
+	}
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	return values
 }
 
 // PostTokenEndpoint はトークンエンドポイントに認可コードを渡してトークンを得る
-func (c oidcClient) PostTokenEndpoint(code string, redirectUrl string, grantType string) (tokenResponse, error) {
+func (c oidcClient) PostTokenEndpoint(
+	ctx context.Context,
+	code string,
+	redirectUrl string,
+	grantType string,
+	opts ...AuthCodeOption,
+) (tokenResponse, error) {
 	values := url.Values{}
 	values.Add("code", code)
-	values.Add("client_id", c.ClientId)
-	values.Add("client_secret", string(c.clientSecret))
 	values.Add("redirect_uri", redirectUrl)
 	values.Add("grant_type", grantType)
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	return c.postToken(ctx, values)
+}
+
+// postToken はクライアント認証の情報を付けてトークンエンドポイントにPOSTする
+func (c oidcClient) postToken(ctx context.Context, values url.Values) (_ tokenResponse, err error) {
+	ctx, endSpan := startSpan(ctx, c.Tracer, "oidc.token", SpanAttribute{"oauth.grant_type", values.Get("grant_type")})
+	defer func() { endSpan(err) }()
+
+	logger := loggerOrDiscard(c.Logger).With(slog.String("endpoint", c.tokenEndpoint), slog.String("grant_type", values.Get("grant_type")))
+	tokenResp := tokenResponse{}
+	if err = c.postFormWithClientAuth(ctx, c.tokenEndpoint, values, &tokenResp); err != nil {
+		logger.WarnContext(ctx, "token request failed", slog.Any("error", err))
+
+		return tokenResponse{}, fmt.Errorf("failed to POST token endpoint: %w", err)
+	}
+	if c.Dpop != nil {
+		if err = c.Dpop.checkTokenResponse(tokenResp); err != nil {
+			return tokenResponse{}, err
+		}
+	}
+	logger.DebugContext(ctx, "token request succeeded", slog.Any("params", redactedValues(values)))
+
+	return tokenResp, nil
+}
+
+// postFormWithClientAuth はClientAuthMethodに従ってクライアント認証の情報を付け、endpointにフォームをPOSTする
+//
+// client_secretが空の場合はPKCEを使うパブリッククライアントとみなし、client_idのみを送る
+func (c oidcClient) postFormWithClientAuth(ctx context.Context, endpoint string, values url.Values, v interface{}) error {
+	secret, err := c.secret()
+	if err != nil {
+		return err
+	}
+
+	if c.ClientAuthMethod == ClientSecretBasic && secret != "" {
+		return c.sendForm(ctx, endpoint, values, v, func(req *http.Request) {
+			// RFC 6749ではBasic認証の前にclient_idとclient_secretをURLエンコードする
+			req.SetBasicAuth(url.QueryEscape(c.ClientId), url.QueryEscape(string(secret)))
+		})
+	}
 
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), httpTimeoutSec*time.Second)
+	values.Set("client_id", c.ClientId)
+	if secret != "" {
+		values.Set("client_secret", string(secret))
+	}
+
+	return c.sendForm(ctx, endpoint, values, v, nil)
+}
+
+// secret はクライアント認証に使うclient_secretを返す
+//
+// Appleのようにclient_secretを都度生成するIdPの場合はclientSecretSourceで生成する
+func (c oidcClient) secret() (clientSecret, error) {
+	if c.clientSecretSource == nil {
+		return c.clientSecret, nil
+	}
+
+	secret, err := c.clientSecretSource()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// postForm はendpointにフォームをPOSTし、レスポンスのJSONをvにunmarshalする。vがnilの場合はボディを読み捨てる
+func (c oidcClient) postForm(ctx context.Context, endpoint string, values url.Values, v interface{}) error {
+	return c.sendForm(ctx, endpoint, values, v, nil)
+}
+
+// sendForm はendpointにフォームをPOSTする。prepareがnilでない場合は送信前にリクエストを変更する
+func (c oidcClient) sendForm(ctx context.Context, endpoint string, values url.Values, v interface{}, prepare func(req *http.Request)) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.Timeouts.token())
 	defer cancel()
-	reqWithCtx, err := http.NewRequestWithContext(
-		ctxWithTimeout,
-		http.MethodPost,
-		c.tokenEndpoint,
-		strings.NewReader(values.Encode()),
-	)
+	req, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
 	if err != nil {
-		return tokenResponse{}, fmt.Errorf("failed to create request of POST token endpoint: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHubのようにAcceptを指定しないとJSON以外で返すプロバイダがある
+	req.Header.Set("Accept", "application/json")
+	if prepare != nil {
+		prepare(req)
+	}
+	cfg := c.httpConfig()
+	if values.Has("grant_type") {
+		cfg.retry = cfg.retry.forGrant(values.Get("grant_type"))
+	}
+	var resp *http.Response
+	var body []byte
+	// DPoPはトークンエンドポイントへのリクエストにのみ付け、イントロスペクションなどには付けない
+	if c.Dpop != nil && endpoint == c.tokenEndpoint {
+		resp, body, err = c.Dpop.send(cfg, req, "")
+	} else {
+		resp, body, err = cfg.send(req)
 	}
-	reqWithCtx.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	httpClient := &http.Client{}
-	resp, err := httpClient.Do(reqWithCtx)
 	if err != nil {
-		return tokenResponse{}, fmt.Errorf("failed to POST token endpoint: %w", err)
+		return err
+	}
+	// PARエンドポイントは201を返すので2xxを成功とみなす
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		oauthErr := &OAuthError{}
+		_ = json.Unmarshal(body, oauthErr)
+		oauthErr.StatusCode = resp.StatusCode
+
+		return oauthErr
+	}
+
+	// 失効エンドポイントのようにボディを返さないものはvをnilにする
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// getJson はアクセストークンを付けてendpointにGETし、レスポンスのJSONをvにunmarshalする
+//
+// OAuth 2.0のみに対応したプロバイダのAPIからユーザーの情報を取得する場合に使う
+func (c oidcClient) getJson(ctx context.Context, endpoint string, accessToken string, v interface{}) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.Timeouts.userInfo())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, body, err := c.sendWithAccessToken(req, accessToken)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s returned %d", errUnexpectedStatus, req.URL.Redacted(), resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			panic(err)
-		}
-	}(resp.Body)
-	bRespBody, _ := ioutil.ReadAll(resp.Body)
 
-	tokenResp := &tokenResponse{}
-	if err := json.Unmarshal(bRespBody, tokenResp); err != nil {
-		return tokenResponse{}, fmt.Errorf("failed to unmarshal token response: %w", err)
+	return nil
+}
+
+// sendWithAccessToken はアクセストークンを付けてリクエストを送る
+//
+// Dpopが設定されている場合はDPoPスキームでアクセストークンを付け、DPoP proofを添える
+func (c oidcClient) sendWithAccessToken(req *http.Request, accessToken string) (*http.Response, []byte, error) {
+	if c.Dpop != nil {
+		return c.Dpop.send(c.httpConfig(), req, accessToken)
 	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	return *tokenResp, nil
+	return c.httpConfig().send(req)
+}
+
+// httpConfig はIdPへのリクエストの設定を返す
+func (c oidcClient) httpConfig() httpConfig {
+	return httpConfig{
+		client:           c.HttpClient,
+		timeouts:         c.Timeouts,
+		retry:            c.Retry,
+		maxResponseBytes: c.MaxResponseBytes,
+		transport:        c.Transport,
+		logger:           c.Logger,
+		metrics:          c.Metrics,
+		tracer:           c.Tracer,
+		debug:            c.Debug,
+		urlPolicy:        c.UrlPolicy,
+	}
 }
 
 // RandomState はCSRF攻撃の対策に使うためにランダムな文字列を返す。
@@ -0,0 +1,38 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// ClientCredentials はクライアントクレデンシャルズグラントでアクセストークンを取得する
+//
+// ユーザーを介さないサーバー間の通信で使う。id_tokenは発行されない
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+func (c oidcClient) ClientCredentials(ctx context.Context, scopes []string, opts ...AuthCodeOption) (*Token, error) {
+	values := url.Values{}
+	values.Add("grant_type", "client_credentials")
+	if len(scopes) > 0 {
+		values.Add("scope", strings.Join(scopes, " "))
+	}
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	tokenResp, err := c.postToken(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return newToken(tokenResp), nil
+}
+
+// ClientCredentialsTokenSource はクライアントクレデンシャルズグラントで取得したアクセストークンをキャッシュし、
+// 有効期限が近づいたら取得し直すTokenSourceを返す
+func (c oidcClient) ClientCredentialsTokenSource(scopes []string, opts ...AuthCodeOption) *reuseTokenSource {
+	return newReuseTokenSource(nil, func(ctx context.Context, _ *Token) (*Token, error) {
+		return c.ClientCredentials(ctx, scopes, opts...)
+	})
+}
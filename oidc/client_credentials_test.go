@@ -0,0 +1,45 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestOidcClient_ClientCredentials(t *testing.T) {
+	client := NewGoogleOidcClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form map[string]string
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = map[string]string{
+			"grant_type": req.PostForm.Get("grant_type"),
+			"scope":      req.PostForm.Get("scope"),
+			"audience":   req.PostForm.Get("audience"),
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken", "expires_in": 3600, "token_type": "Bearer"}`), nil
+	})
+
+	source := client.ClientCredentialsTokenSource([]string{"read:users", "write:users"}, WithParam("audience", "https://api.example.com"))
+	for i := 0; i < 2; i++ {
+		token, err := source.Token(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "DummyAccessToken", token.AccessToken)
+	}
+
+	// 有効期限内はキャッシュしたトークンを使う
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.Equal(t, map[string]string{
+		"grant_type": "client_credentials",
+		"scope":      "read:users write:users",
+		"audience":   "https://api.example.com",
+	}, form)
+}
@@ -0,0 +1,225 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ClientOption はNewとApplyでクライアントの設定を変更する
+//
+// 設定項目を増やしても引数の並びを変えずに済むように、コンストラクタの引数ではなくオプションで渡す。
+// NewGoogleOidcClientなどのプリセットのコンストラクタも、環境変数から読み込んだclient_idなどをこのオプションで上書きできる
+type ClientOption func(c *oidcClient)
+
+// NoClockSkew はWithClockSkewに渡すと、id_tokenの時刻のクレームの検証でずれを許容しない
+const NoClockSkew time.Duration = -1
+
+// New はissuerのIdPのクライアントをoptsで設定して返す
+//
+// プリセットのないIdPで、Discoveryを使わずにエンドポイントを指定する場合に使う。
+// WithClientIdとWithEndpointsは必須で、指定しない場合はエラーを返す。
+// WithHttpClientとBlockPrivateAddressesのUrlPolicyを併用した場合もエラーを返す
+func New(issuer string, opts ...ClientOption) (*oidcClient, error) {
+	client := newOidcClient(GenericOidc, issuer, "", "", "", "", "", []string{"RS256"})
+	client.Apply(opts...)
+	if err := client.validateConfig(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// NewFromIssuer はissuerのDiscoveryドキュメントから設定したクライアントをoptsで設定して返す
+//
+// プリセットのないOIDCに準拠したIdPを使う場合に使う。WithClientIdは必須で、指定しない場合はエラーを返す。
+// WithScopesを指定しない場合はopenid、email、profileを要求し、openidが含まれない場合は先頭に追加する
+func NewFromIssuer(ctx context.Context, issuer string, opts ...ClientOption) (*oidcClient, error) {
+	metadata, err := DiscoverProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	client := metadata.NewOidcClient(GenericOidc, "", "", opts...)
+	if !contains(client.Scopes, "openid") {
+		client.Scopes = append([]string{"openid"}, client.Scopes...)
+	}
+	if err := client.validateConfig(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// validateConfig はNewとNewFromIssuerで必須の設定と、併用できない設定を確認する
+func (c *oidcClient) validateConfig() error {
+	if c.ClientId == "" {
+		return fmt.Errorf("%w: client_id", errClientConfigMissing)
+	}
+	if c.authEndpoint == "" || c.tokenEndpoint == "" || c.JwksEndpoint == "" {
+		return fmt.Errorf("%w: endpoints", errClientConfigMissing)
+	}
+	if c.HttpClient != nil && c.UrlPolicy.BlockPrivateAddresses {
+		return errUnenforceableUrlPolicy
+	}
+
+	return nil
+}
+
+// Apply はoptsを順にクライアントに適用する
+//
+// NewGoogleOidcClientなどのプリセットの設定を変更する場合にも使える
+func (c *oidcClient) Apply(opts ...ClientOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithIdProvider はクライアントのIdProviderを設定する
+func WithIdProvider(idProvider IdProvider) ClientOption {
+	return func(c *oidcClient) {
+		c.IdProvider = idProvider
+	}
+}
+
+// WithClientId はclient_idを設定する
+func WithClientId(clientId string) ClientOption {
+	return func(c *oidcClient) {
+		c.ClientId = clientId
+	}
+}
+
+// WithClientSecret はclient_secretを設定する
+func WithClientSecret(secret string) ClientOption {
+	return func(c *oidcClient) {
+		c.clientSecret = clientSecret(secret)
+	}
+}
+
+// WithEndpoints は認可、トークン、JWKsのエンドポイントを設定する
+func WithEndpoints(authEndpoint string, tokenEndpoint string, jwksEndpoint string) ClientOption {
+	return func(c *oidcClient) {
+		c.authEndpoint = authEndpoint
+		c.tokenEndpoint = tokenEndpoint
+		c.JwksEndpoint = jwksEndpoint
+	}
+}
+
+// WithRedirectUrl はリダイレクトURIを設定する
+func WithRedirectUrl(redirectUrl string) ClientOption {
+	return func(c *oidcClient) {
+		c.RedirectUrl = redirectUrl
+	}
+}
+
+// WithScopes はLoginUrlで要求するscopeを設定する
+func WithScopes(scopes ...string) ClientOption {
+	return func(c *oidcClient) {
+		c.Scopes = scopes
+	}
+}
+
+// WithHttpClient はIdPへのリクエストに使うクライアントを設定する
+func WithHttpClient(httpClient *http.Client) ClientOption {
+	return func(c *oidcClient) {
+		c.HttpClient = httpClient
+	}
+}
+
+// WithClockSkew はid_tokenの時刻のクレームを検証する際に許容するずれを設定する
+//
+// 0を渡した場合は既定の30秒になる。ずれを許容しない場合はNoClockSkewを渡す
+func WithClockSkew(leeway time.Duration) ClientOption {
+	return func(c *oidcClient) {
+		c.Leeway = leeway
+	}
+}
+
+// WithMaxAge はユーザーが認証してからの経過時間として許容する最大の時間を設定する
+//
+// 認可リクエストにmax_ageを含め、id_tokenのauth_timeからの経過時間がmaxAgeを超えた場合は検証に失敗する
+func WithMaxAge(maxAge time.Duration) ClientOption {
+	return func(c *oidcClient) {
+		c.MaxAge = maxAge
+	}
+}
+
+// WithRequireAzp はid_tokenのazpクレームがclient_idと一致することを必須にする
+func WithRequireAzp() ClientOption {
+	return func(c *oidcClient) {
+		c.RequireAzp = true
+	}
+}
+
+// WithAuthnPolicy はid_tokenのacrとamrに対する要件を設定する
+func WithAuthnPolicy(policy AuthnPolicy) ClientOption {
+	return func(c *oidcClient) {
+		c.AuthnPolicy = policy
+	}
+}
+
+// WithAllowedAlgs はid_tokenの署名アルゴリズムとして受け入れるものを設定する
+func WithAllowedAlgs(algs ...string) ClientOption {
+	return func(c *oidcClient) {
+		c.AllowedAlgs = algs
+	}
+}
+
+// WithKeyProvider はJWKsエンドポイントの代わりに署名検証の公開鍵を提供するものを設定する
+func WithKeyProvider(keyProvider KeyProvider) ClientOption {
+	return func(c *oidcClient) {
+		c.KeyProvider = keyProvider
+	}
+}
+
+// WithClientAuthMethod はトークンエンドポイントなどでのクライアント認証の方式を設定する
+func WithClientAuthMethod(method ClientAuthMethod) ClientOption {
+	return func(c *oidcClient) {
+		c.ClientAuthMethod = method
+	}
+}
+
+// WithLogger はJWKsの取得やトークンリクエストなどを出力するロガーを設定する
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *oidcClient) {
+		c.Logger = logger
+	}
+}
+
+// WithDebug はIdPへのリクエストとレスポンスの内容をマスクしてロガーに出力する
+func WithDebug(logger *slog.Logger) ClientOption {
+	return func(c *oidcClient) {
+		c.Logger = logger
+		c.Debug = true
+	}
+}
+
+// WithUrlPolicy はJWKsとDiscoveryドキュメントを取得するURLの制限を設定する
+func WithUrlPolicy(policy UrlPolicy) ClientOption {
+	return func(c *oidcClient) {
+		c.UrlPolicy = policy
+	}
+}
+
+// WithReplayCache はid_tokenの再利用を検出するReplayCacheを設定する
+func WithReplayCache(cache ReplayCache) ClientOption {
+	return func(c *oidcClient) {
+		c.ReplayCache = cache
+	}
+}
+
+// WithoutPkce はLoginUrlとExchangeでPKCEを使わないようにする。PKCEに対応していないIdPを使う場合にのみ指定する
+func WithoutPkce() ClientOption {
+	return func(c *oidcClient) {
+		c.DisablePkce = true
+	}
+}
+
+// WithDpop はDPoP proofに署名するdpopSignerを設定する
+func WithDpop(signer *dpopSigner) ClientOption {
+	return func(c *oidcClient) {
+		c.Dpop = signer
+	}
+}
@@ -0,0 +1,152 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Second}
+	endpoints := WithEndpoints("https://op.example.com/authorize", "https://op.example.com/token", "https://op.example.com/jwks")
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		opts          []ClientOption
+	}{
+		{"valid", true, []ClientOption{WithClientId("DummyClientId"), endpoints}},
+		{"client_id missing", false, []ClientOption{endpoints}},
+		{"endpoints missing", false, []ClientOption{WithClientId("DummyClientId")}},
+	}
+
+	for _, pattern := range patterns {
+		_, err := New("https://op.example.com", pattern.opts...)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errClientConfigMissing, pattern.desc)
+		}
+	}
+
+	client, err := New(
+		"https://op.example.com",
+		WithIdProvider(Keycloak),
+		WithClientId("DummyClientId"),
+		WithClientSecret("DummyClientSecret"),
+		endpoints,
+		WithRedirectUrl("https://rp.example.com/callback"),
+		WithScopes("openid", "email"),
+		WithHttpClient(httpClient),
+		WithClockSkew(time.Minute),
+		WithMaxAge(time.Hour),
+		WithRequireAzp(),
+		WithAuthnPolicy(AuthnPolicy{AcrValues: []string{"urn:example:mfa"}}),
+		WithAllowedAlgs("ES256"),
+		WithClientAuthMethod(ClientSecretBasic),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, Keycloak, client.IdProvider)
+	assert.Equal(t, "https://op.example.com", client.Issuer)
+	assert.Equal(t, clientSecret("DummyClientSecret"), client.clientSecret)
+	assert.Equal(t, "https://op.example.com/token", client.tokenEndpoint)
+	assert.Equal(t, "https://rp.example.com/callback", client.RedirectUrl)
+	assert.Equal(t, []string{"openid", "email"}, client.Scopes)
+	assert.Same(t, httpClient, client.HttpClient)
+	assert.Equal(t, time.Minute, client.Leeway)
+	claims := client.Verifier().claims
+	assert.Equal(t, time.Hour, claims.maxAge)
+	assert.True(t, claims.requireAzp)
+	assert.Equal(t, []string{"urn:example:mfa"}, claims.policy.AcrValues)
+	assert.Equal(t, []string{"ES256"}, client.AllowedAlgs)
+	assert.Equal(t, ClientSecretBasic, client.ClientAuthMethod)
+}
+
+func TestWithClockSkew(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		skew     time.Duration
+		expected time.Duration
+	}{
+		{"default", 0, defaultLeeway},
+		{"no clock skew", NoClockSkew, 0},
+		{"custom", time.Minute, time.Minute},
+	}
+
+	for _, pattern := range patterns {
+		client := NewGoogleOidcClient()
+		client.Apply(WithClockSkew(pattern.skew))
+
+		assert.Equal(t, pattern.expected, client.Verifier().claims.leeway, pattern.desc)
+	}
+}
+
+func TestOidcClient_Apply(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.Apply(WithClientId("DummyClientId"), WithScopes("openid"), WithoutPkce())
+
+	assert.Equal(t, "DummyClientId", client.ClientId)
+	assert.Equal(t, []string{"openid"}, client.Scopes)
+	assert.True(t, client.DisablePkce)
+	assert.Equal(t, "https://oauth2.googleapis.com/token", client.tokenEndpoint)
+}
+
+func TestPresets_ClientOptions(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc      string
+		newClient func(opts ...ClientOption) *oidcClient
+	}{
+		{"Google", func(opts ...ClientOption) *oidcClient { return NewGoogleOidcClient(opts...) }},
+		{"Apple", func(opts ...ClientOption) *oidcClient {
+			return NewAppleOidcClient("com.example.services", "TEAMID1234", "KEYID12345", ecKey, opts...)
+		}},
+		{"Microsoft", func(opts ...ClientOption) *oidcClient { return NewMicrosoftOidcClient(MicrosoftTenantCommon, opts...) }},
+		{"LINE", func(opts ...ClientOption) *oidcClient { return NewLineOidcClient(opts...) }},
+		{"Slack", func(opts ...ClientOption) *oidcClient { return NewSlackOidcClient(opts...) }},
+		{"Yahoo! JAPAN", func(opts ...ClientOption) *oidcClient { return NewYahooJapanOidcClient(opts...) }},
+		{"Twitch", func(opts ...ClientOption) *oidcClient { return NewTwitchOidcClient(opts...) }},
+		{"Auth0", func(opts ...ClientOption) *oidcClient { return NewAuth0OidcClient("example.us.auth0.com", opts...) }},
+		{"Cognito", func(opts ...ClientOption) *oidcClient {
+			return NewCognitoOidcClient("ap-northeast-1", "ap-northeast-1_example", "example", opts...)
+		}},
+		{"GitLab", func(opts ...ClientOption) *oidcClient { return NewGitLabOidcClient("https://gitlab.com", opts...) }},
+		{"Keycloak", func(opts ...ClientOption) *oidcClient {
+			return NewKeycloakOidcClient("https://keycloak.example.com", "example", opts...)
+		}},
+		{"Okta", func(opts ...ClientOption) *oidcClient {
+			return NewOktaOidcClient("example.okta.com", "default", opts...)
+		}},
+		{"Salesforce", func(opts ...ClientOption) *oidcClient {
+			return NewSalesforceOidcClient("https://login.salesforce.com", opts...)
+		}},
+		{"Facebook", func(opts ...ClientOption) *oidcClient { return NewFacebookProvider(opts...).oidcClient }},
+		{"GitHub", func(opts ...ClientOption) *oidcClient { return NewGitHubProvider(opts...).oidcClient }},
+		{"Discord", func(opts ...ClientOption) *oidcClient { return NewDiscordProvider(opts...).oidcClient }},
+		{"Spotify", func(opts ...ClientOption) *oidcClient { return NewSpotifyProvider(opts...).oidcClient }},
+		{"X", func(opts ...ClientOption) *oidcClient { return NewXProvider(opts...).oidcClient }},
+		{"PayPal", func(opts ...ClientOption) *oidcClient { return NewPayPalProvider(false, opts...).oidcClient }},
+	}
+
+	for _, pattern := range patterns {
+		client := pattern.newClient(
+			WithClientId("DummyClientId"),
+			WithRedirectUrl("https://rp.example.com/callback"),
+			WithScopes("openid", "email"),
+		)
+
+		assert.Equal(t, "DummyClientId", client.ClientId, pattern.desc)
+		assert.Equal(t, "https://rp.example.com/callback", client.RedirectUrl, pattern.desc)
+		// プリセットが設定したscopeもオプションで上書きできる
+		assert.Equal(t, []string{"openid", "email"}, client.Scopes, pattern.desc)
+	}
+}
@@ -1,10 +1,15 @@
 package oidc
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestOidcClient_AuthUrl(t *testing.T) {
@@ -15,6 +20,7 @@ func TestOidcClient_AuthUrl(t *testing.T) {
 		scopes      []string
 		redirectUrl string
 		state       string
+		nonce       string
 		expected    string
 	}{
 		{
@@ -24,6 +30,7 @@ func TestOidcClient_AuthUrl(t *testing.T) {
 			[]string{"openid", "email", "profile"},
 			"http://localhost:8000/auth/google/sign_up/callback",
 			"12345678",
+			"",
 			fmt.Sprintf(
 				"https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&response_type=%s&scope=%s&redirect_uri=%s&state=%s",
 				"",
@@ -40,6 +47,7 @@ func TestOidcClient_AuthUrl(t *testing.T) {
 			[]string{"profile"},
 			"http://localhost:8000/auth/google/sign_up/callback",
 			"12345678",
+			"",
 			fmt.Sprintf(
 				"https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&response_type=%s&scope=%s&redirect_uri=%s&state=%s",
 				"",
@@ -49,6 +57,24 @@ func TestOidcClient_AuthUrl(t *testing.T) {
 				"12345678",
 			),
 		},
+		{
+			"nonceを指定した時",
+			NewGoogleOidcClient(),
+			"code",
+			[]string{"openid"},
+			"http://localhost:8000/auth/google/sign_up/callback",
+			"12345678",
+			"n-0S6_WzA2Mj",
+			fmt.Sprintf(
+				"https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&response_type=%s&scope=%s&redirect_uri=%s&state=%s&nonce=%s",
+				"",
+				"code",
+				"openid",
+				"http://localhost:8000/auth/google/sign_up/callback",
+				"12345678",
+				"n-0S6_WzA2Mj",
+			),
+		},
 	}
 
 	for _, pattern := range patterns {
@@ -57,11 +83,20 @@ func TestOidcClient_AuthUrl(t *testing.T) {
 			pattern.scopes,
 			pattern.redirectUrl,
 			pattern.state,
+			pattern.nonce,
 		)
 		assert.Equal(t, pattern.expected, actual)
 	}
 }
 
+func TestOidcClient_AuthUrl_MaxAge(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.MaxAge = 5 * time.Minute
+
+	actual := client.AuthUrl("code", []string{"openid"}, "http://localhost:8000/callback", "12345678", "")
+	assert.True(t, strings.HasSuffix(actual, "&state=12345678&max_age=300"))
+}
+
 func TestOidcClient_PostTokenEndpoint(t *testing.T) {
 	client := NewGoogleOidcClient()
 
@@ -81,7 +116,7 @@ func TestOidcClient_PostTokenEndpoint(t *testing.T) {
 		),
 	)
 
-	actual, _ := client.PostTokenEndpoint("", "", "")
+	actual, _ := client.PostTokenEndpoint(context.Background(), "", "", "")
 	expected := tokenResponse{
 		AccessToken: "DummyAccessToken",
 		ExpiresIn:   3566,
@@ -93,6 +128,44 @@ func TestOidcClient_PostTokenEndpoint(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestOidcClient_PostTokenEndpoint_OAuthError(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	patterns := []struct {
+		desc     string
+		status   int
+		body     string
+		expected OAuthError
+	}{
+		{
+			"error response",
+			http.StatusBadRequest,
+			`{"error": "invalid_grant", "error_description": "Bad Request", "error_uri": "https://example.com/errors/invalid_grant"}`,
+			OAuthError{StatusCode: http.StatusBadRequest, Code: "invalid_grant", Description: "Bad Request", Uri: "https://example.com/errors/invalid_grant"},
+		},
+		{
+			"not json",
+			http.StatusBadGateway,
+			`<html>Bad Gateway</html>`,
+			OAuthError{StatusCode: http.StatusBadGateway},
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	for _, pattern := range patterns {
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(pattern.status, pattern.body))
+
+		_, err := client.PostTokenEndpoint(context.Background(), "DummyCode", "", "authorization_code")
+		var oauthErr *OAuthError
+		if assert.ErrorAs(t, err, &oauthErr, pattern.desc) {
+			assert.Equal(t, pattern.expected, *oauthErr, pattern.desc)
+		}
+		assert.ErrorIs(t, err, errUnexpectedStatus, pattern.desc)
+	}
+}
+
 func TestRandomState(t *testing.T) {
 	state, err := RandomState()
 
@@ -100,3 +173,58 @@ func TestRandomState(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expectedLength, len(state))
 }
+
+func TestOidcClient_PostFormWithClientAuth(t *testing.T) {
+	patterns := []struct {
+		desc               string
+		authMethod         ClientAuthMethod
+		clientSecret       clientSecret
+		expectBasic        bool
+		expectClientSecret string
+	}{
+		{"client_secret_post", ClientSecretPost, "DummySecret", false, "DummySecret"},
+		{"client_secret_basic", ClientSecretBasic, "DummySecret", true, ""},
+		{"public client", ClientSecretBasic, "", false, ""},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		client := NewGoogleOidcClient()
+		client.ClientId = "DummyClientId"
+		client.clientSecret = pattern.clientSecret
+		client.ClientAuthMethod = pattern.authMethod
+
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+			_, _, ok := req.BasicAuth()
+			assert.Equal(t, pattern.expectBasic, ok, pattern.desc)
+			assert.Nil(t, req.ParseForm(), pattern.desc)
+			assert.Equal(t, pattern.expectClientSecret, req.PostForm.Get("client_secret"), pattern.desc)
+			if !pattern.expectBasic {
+				assert.Equal(t, "DummyClientId", req.PostForm.Get("client_id"), pattern.desc)
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, `{}`), nil
+		})
+
+		err := client.postFormWithClientAuth(context.Background(), client.tokenEndpoint, url.Values{}, nil)
+		assert.Nil(t, err, pattern.desc)
+	}
+}
+
+func TestOidcClient_PostForm_ErrorFieldWithStatus200(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(http.StatusOK, `{"error": "unknown", "access_token": "DummyAccessToken"}`))
+
+	// GitHub以外のIdPでは2xxのレスポンスをerrorの有無によらず成功とみなす
+	resp := tokenResponse{}
+	err := client.postForm(context.Background(), client.tokenEndpoint, url.Values{}, &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "DummyAccessToken", resp.AccessToken)
+}
@@ -13,3 +13,15 @@ func (s clientSecret) String() string {
 func (s clientSecret) GoString() string {
 	return secretMaskingStr
 }
+
+// ClientAuthMethod はトークンエンドポイントなどでのクライアント認証の方式
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+type ClientAuthMethod int
+
+const (
+	// ClientSecretPost はclient_idとclient_secretをリクエストボディで送る
+	ClientSecretPost ClientAuthMethod = iota
+	// ClientSecretBasic はclient_idとclient_secretをBasic認証のヘッダで送る
+	ClientSecretBasic
+)
@@ -0,0 +1,66 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cognitoIdTokenPayload はAmazon Cognitoのid_tokenのpayloadをunmarshalするための構造体
+//
+// refs: https://docs.aws.amazon.com/cognito/latest/developerguide/amazon-cognito-user-pools-using-the-id-token.html
+type cognitoIdTokenPayload struct {
+	IdTokenClaims
+	// TokenUse はトークンの種類。id_tokenでは"id"、アクセストークンでは"access"になる
+	TokenUse string `json:"token_use"`
+	// Username はユーザープール内のユーザー名
+	Username string `json:"cognito:username"`
+	// Groups はユーザーが所属するグループ
+	Groups []string `json:"cognito:groups"`
+}
+
+// validate はアクセストークンをid_tokenとして受け入れないようにtoken_useを確認する
+func (payload cognitoIdTokenPayload) validate() error {
+	if payload.TokenUse != "id" {
+		return fmt.Errorf("%w: %s", errInvalidTokenUse, payload.TokenUse)
+	}
+
+	return nil
+}
+
+// NewCognitoOidcClient はAmazon Cognitoのユーザープールのクライアントを返す
+//
+// issuerとJWKsはリージョンとユーザープールIDから組み立てる。
+// domainにはホストされたUIのドメインのプレフィックスもしくはカスタムドメインを渡す
+//
+// refs: https://docs.aws.amazon.com/cognito/latest/developerguide/cognito-userpools-server-contract-reference.html
+func NewCognitoOidcClient(region string, userPoolId string, domain string, opts ...ClientOption) *oidcClient {
+	issuer := fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolId)
+	hostedUiUrl := cognitoHostedUiUrl(region, domain)
+	client := newOidcClient(
+		Cognito,
+		issuer,
+		os.Getenv("COGNITO_CLIENT_ID"),
+		clientSecret(os.Getenv("COGNITO_CLIENT_SECRET")),
+		hostedUiUrl+"/oauth2/authorize",
+		hostedUiUrl+"/oauth2/token",
+		issuer+"/.well-known/jwks.json",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = hostedUiUrl + "/oauth2/userInfo"
+	client.RevocationEndpoint = hostedUiUrl + "/oauth2/revoke"
+	// Cognitoのクライアントシークレットはclient_secret_basicで送る
+	client.ClientAuthMethod = ClientSecretBasic
+	client.Apply(opts...)
+
+	return client
+}
+
+// cognitoHostedUiUrl はホストされたUIのURLを返す。domainに"."を含まない場合はCognitoのドメインのプレフィックスとみなす
+func cognitoHostedUiUrl(region string, domain string) string {
+	if strings.Contains(domain, ".") {
+		return "https://" + domain
+	}
+
+	return fmt.Sprintf("https://%s.auth.%s.amazoncognito.com", domain, region)
+}
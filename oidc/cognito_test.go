@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewCognitoOidcClient(t *testing.T) {
+	patterns := []struct {
+		desc             string
+		domain           string
+		expectedAuthUrl  string
+		expectedTokenUrl string
+	}{
+		{
+			"domain prefix",
+			"example",
+			"https://example.auth.ap-northeast-1.amazoncognito.com/oauth2/authorize",
+			"https://example.auth.ap-northeast-1.amazoncognito.com/oauth2/token",
+		},
+		{
+			"custom domain",
+			"auth.example.com",
+			"https://auth.example.com/oauth2/authorize",
+			"https://auth.example.com/oauth2/token",
+		},
+	}
+
+	for _, pattern := range patterns {
+		client := NewCognitoOidcClient("ap-northeast-1", "ap-northeast-1_AbCdEf123", pattern.domain)
+
+		assert.Equal(t, "https://cognito-idp.ap-northeast-1.amazonaws.com/ap-northeast-1_AbCdEf123", client.Issuer, pattern.desc)
+		assert.Equal(t, "https://cognito-idp.ap-northeast-1.amazonaws.com/ap-northeast-1_AbCdEf123/.well-known/jwks.json", client.JwksEndpoint, pattern.desc)
+		assert.Equal(t, pattern.expectedAuthUrl, client.authEndpoint, pattern.desc)
+		assert.Equal(t, pattern.expectedTokenUrl, client.tokenEndpoint, pattern.desc)
+	}
+}
+
+func TestCognito_Verify_TokenUse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewCognitoOidcClient("ap-northeast-1", "ap-northeast-1_AbCdEf123", "example")
+	client.ClientId = "DummyClientId"
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		tokenUse      string
+	}{
+		{"id token", true, "id"},
+		{"access token", false, "access"},
+		{"token_use missing", false, ""},
+	}
+
+	for _, pattern := range patterns {
+		payload := map[string]interface{}{
+			"iss":              client.Issuer,
+			"aud":              "DummyClientId",
+			"sub":              "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+			"exp":              time.Now().Add(time.Hour).Unix(),
+			"iat":              time.Now().Unix(),
+			"cognito:username": "taro",
+		}
+		if pattern.tokenUse != "" {
+			payload["token_use"] = pattern.tokenUse
+		}
+		rawIdToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+		token, err := NewIdToken(rawIdToken, Cognito)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = client.Verifier().Verify(context.Background(), token)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "taro", token.Payload.(*cognitoIdTokenPayload).Username, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errInvalidTokenUse, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"context"
+	"crypto/elliptic"
+	"sns-login/oidctest"
+	"testing"
+)
+
+const (
+	conformanceIssuerForTest   = "https://op.example.com"
+	conformanceClientIdForTest = "DummyClientId"
+)
+
+func TestVerifier_Conformance(t *testing.T) {
+	rsaKey, err := oidctest.NewRsaKey("rsa-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := oidctest.NewEcKey("ec-key", elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(
+		conformanceIssuerForTest,
+		WithClientId(conformanceClientIdForTest),
+		WithEndpoints(conformanceIssuerForTest+"/authorize", conformanceIssuerForTest+"/token", conformanceIssuerForTest+"/jwks"),
+		WithAllowedAlgs("RS256", "ES256"),
+		WithKeyProvider(StaticKeys{rsaKey.Kid(): rsaKey.Public(), ecKey.Kid(): ecKey.Public()}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := func(ctx context.Context, rawToken string, nonce string) error {
+		token, err := NewIdToken(rawToken, GenericOidc)
+		if err != nil {
+			return err
+		}
+		if err := client.Verifier().Verify(ctx, token); err != nil {
+			return err
+		}
+
+		return token.VerifyNonce(nonce)
+	}
+
+	t.Run("RS256", func(t *testing.T) {
+		oidctest.RunConformance(t, oidctest.ConformanceTarget{
+			Issuer:   conformanceIssuerForTest,
+			ClientId: conformanceClientIdForTest,
+			Key:      rsaKey,
+			Verify:   verify,
+		})
+	})
+	t.Run("ES256", func(t *testing.T) {
+		oidctest.RunConformance(t, oidctest.ConformanceTarget{
+			Issuer:   conformanceIssuerForTest,
+			ClientId: conformanceClientIdForTest,
+			Key:      ecKey,
+			Verify:   verify,
+		})
+	})
+}
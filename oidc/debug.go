@@ -0,0 +1,135 @@
+package oidc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// sensitiveHeaders はデバッグ出力で値をマスクするヘッダ
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"Dpop":                true,
+}
+
+// loggableResponseFields はデバッグ出力にそのまま出力してよいレスポンスのJSONのフィールド
+//
+// トークンエンドポイントが拒否した理由を確認できるように、エラーの内容とトークンの種類、有効期限は出力する。
+// トークンの値やユーザーのクレームが漏れないように、ここに含まれないフィールドの値はすべてredactedに置き換える
+var loggableResponseFields = map[string]bool{
+	"error":             true,
+	"error_description": true,
+	"error_uri":         true,
+	"token_type":        true,
+	"expires_in":        true,
+	"scope":             true,
+}
+
+// dump はDebugが有効な場合にIdPへのリクエストとレスポンスをシークレットとトークンの値をマスクして出力する
+//
+// respがnilの場合はerrを出力する
+func (cfg httpConfig) dump(req *http.Request, resp *http.Response, body []byte, err error) {
+	if !cfg.debug {
+		return
+	}
+
+	attrs := []any{slog.Group("request", dumpRequest(req)...)}
+	if resp == nil {
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		attrs = append(attrs, slog.Group(
+			"response",
+			slog.Int("status", resp.StatusCode),
+			slog.Any("header", redactedHeader(resp.Header)),
+			slog.Any("body", redactedBody(resp.Header.Get("Content-Type"), body)),
+		))
+	}
+	cfg.log().DebugContext(req.Context(), "IdP request dump", attrs...)
+}
+
+func dumpRequest(req *http.Request) []any {
+	redactedUrl := *req.URL
+	redactedUrl.RawQuery = ""
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", redactedUrl.Redacted()),
+		slog.Any("query", redactedValues(req.URL.Query())),
+		slog.Any("header", redactedHeader(req.Header)),
+	}
+
+	// リトライに備えてボディはGetBodyから読み直せるので、送信済みのBodyの代わりに使う
+	if req.GetBody == nil {
+		return attrs
+	}
+	reqBody, err := req.GetBody()
+	if err != nil {
+		return attrs
+	}
+	defer reqBody.Close()
+	raw, err := io.ReadAll(reqBody)
+	if err != nil {
+		return attrs
+	}
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return append(attrs, slog.Int("body_bytes", len(raw)))
+	}
+
+	return append(attrs, slog.Any("body", redactedValues(values)))
+}
+
+// redactedHeader はsensitiveHeadersの値をマスクして出力するhttp.Header
+type redactedHeader http.Header
+
+func (h redactedHeader) LogValue() slog.Value {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		value := strings.Join(h[key], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// redactedBody はレスポンスのボディをloggableResponseFields以外の値をマスクして返す
+//
+// JSONのオブジェクトでない場合は中身を出力せずにサイズのみを返す
+func redactedBody(contentType string, body []byte) slog.Value {
+	fields := map[string]json.RawMessage{}
+	if !strings.Contains(contentType, "json") || json.Unmarshal(body, &fields) != nil {
+		return slog.GroupValue(slog.Int("bytes", len(body)))
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		value := redacted
+		if loggableResponseFields[key] {
+			value = string(fields[key])
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestOidcClient_Debug(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	patterns := []struct {
+		desc       string
+		authMethod ClientAuthMethod
+		status     int
+		body       string
+		expected   []string
+	}{
+		{
+			"token response",
+			ClientSecretPost,
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken", "id_token": "DummyIdToken", "refresh_token": "DummyRefreshToken", "token_type": "Bearer", "expires_in": 3600}`,
+			[]string{`"status":200`, `"token_type":"\"Bearer\""`, `"expires_in":"3600"`, `"access_token":"[REDACTED]"`, `"client_secret":"[REDACTED]"`},
+		},
+		{
+			"error response",
+			ClientSecretBasic,
+			http.StatusBadRequest,
+			`{"error": "invalid_grant", "error_description": "Bad Request"}`,
+			[]string{`"status":400`, `"error":"\"invalid_grant\""`, `"error_description":"\"Bad Request\""`, `"Authorization":"[REDACTED]"`},
+		},
+	}
+
+	for _, pattern := range patterns {
+		client := newOidcClient(GenericOidc, "https://op.example.com", "DummyClientId", "DummyClientSecret", "", "https://op.example.com/token", "", nil)
+		client.Retry = RetryPolicy{MaxAttempts: 1}
+		client.ClientAuthMethod = pattern.authMethod
+		logger, buf := loggerForTest()
+		WithDebug(logger)(client)
+
+		httpmock.RegisterResponder(http.MethodPost, "https://op.example.com/token", func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(pattern.status, pattern.body)
+			resp.Header.Set("Content-Type", "application/json")
+
+			return resp, nil
+		})
+		_, _ = client.PostTokenEndpoint(context.Background(), "DummyCode", "https://rp.example.com/callback", "authorization_code")
+
+		assert.Contains(t, buf.String(), "IdP request dump", pattern.desc)
+		assert.Contains(t, buf.String(), `"grant_type":"authorization_code"`, pattern.desc)
+		for _, expected := range pattern.expected {
+			assert.Contains(t, buf.String(), expected, pattern.desc)
+		}
+		for _, secret := range []string{"DummyCode", "DummyClientSecret", "DummyAccessToken", "DummyIdToken", "DummyRefreshToken"} {
+			assert.NotContains(t, buf.String(), secret, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_Debug_Disabled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := newOidcClient(GenericOidc, "https://op.example.com", "DummyClientId", "DummyClientSecret", "", "https://op.example.com/token", "", nil)
+	logger, buf := loggerForTest()
+	client.Logger = logger
+
+	httpmock.RegisterResponder(http.MethodPost, "https://op.example.com/token", httpmock.NewStringResponder(http.StatusOK, `{"access_token": "DummyAccessToken"}`))
+	_, err := client.PostTokenEndpoint(context.Background(), "DummyCode", "https://rp.example.com/callback", "authorization_code")
+	assert.Nil(t, err)
+	assert.NotContains(t, buf.String(), "IdP request dump")
+}
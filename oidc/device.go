@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// defaultDevicePollInterval はintervalが返されなかった場合のポーリング間隔
+	defaultDevicePollInterval = 5 * time.Second
+	// slowDownInterval はslow_downが返された場合にポーリング間隔に加える時間
+	slowDownInterval = 5 * time.Second
+)
+
+// DeviceAuth はデバイス認可エンドポイントのレスポンス
+//
+// ユーザーにはVerificationUriとUserCodeを表示し、別の端末でログインしてもらう
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete"`
+	// ExpiresIn はdevice_codeとuser_codeの有効期間(秒)
+	ExpiresIn int `json:"expires_in"`
+	// Interval はトークンエンドポイントをポーリングする間隔(秒)
+	Interval int `json:"interval"`
+	// VerificationUrl はGoogleがverification_uriの代わりに返す項目
+	VerificationUrl string `json:"verification_url"`
+}
+
+// AuthorizeDevice はデバイス認可エンドポイントにリクエストし、ユーザーに表示するuser_codeなどを取得する
+func (c oidcClient) AuthorizeDevice(ctx context.Context, scopes []string, opts ...AuthCodeOption) (*DeviceAuth, error) {
+	if c.DeviceAuthEndpoint == "" {
+		return nil, errDeviceEndpointMissing
+	}
+
+	values := url.Values{}
+	values.Add("client_id", c.ClientId)
+	values.Add("scope", strings.Join(scopes, " "))
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	auth := &DeviceAuth{}
+	if err := c.postForm(ctx, c.DeviceAuthEndpoint, values, auth); err != nil {
+		return nil, fmt.Errorf("failed to POST device authorization endpoint: %w", err)
+	}
+	if auth.VerificationUri == "" {
+		auth.VerificationUri = auth.VerificationUrl
+	}
+
+	return auth, nil
+}
+
+// PollDeviceToken はユーザーがログインを完了するまでトークンエンドポイントをポーリングし、トークンを返す
+//
+// authorization_pendingの間はポーリングを続け、slow_downが返された場合は間隔を広げる。
+// device_codeの有効期限が切れるかctxがキャンセルされた場合はエラーを返す
+func (c oidcClient) PollDeviceToken(ctx context.Context, auth *DeviceAuth) (*Token, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	return c.pollDeviceToken(ctx, auth.DeviceCode, interval, slowDownInterval)
+}
+
+func (c oidcClient) pollDeviceToken(
+	ctx context.Context,
+	deviceCode string,
+	interval time.Duration,
+	slowDown time.Duration,
+) (*Token, error) {
+	values := url.Values{}
+	values.Add("grant_type", deviceCodeGrantType)
+	values.Add("device_code", deviceCode)
+	tokenResp, err := c.pollToken(ctx, values, interval, slowDown)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.tokenFromResponse(ctx, tokenResp)
+}
+
+// pollToken はユーザーの承認を待つグラントでトークンが発行されるまでトークンエンドポイントをポーリングする
+//
+// authorization_pendingの間はポーリングを続け、slow_downが返された場合はslowDownの分だけ間隔を広げる
+func (c oidcClient) pollToken(
+	ctx context.Context,
+	values url.Values,
+	interval time.Duration,
+	slowDown time.Duration,
+) (tokenResponse, error) {
+	for {
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return tokenResponse{}, fmt.Errorf("failed to wait for user authorization: %w", err)
+		}
+
+		tokenResp, err := c.postToken(ctx, values)
+		var tokenErr *OAuthError
+		if errors.As(err, &tokenErr) {
+			switch tokenErr.Code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += slowDown
+
+				continue
+			}
+		}
+
+		return tokenResp, err
+	}
+}
@@ -0,0 +1,113 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testDeviceAuthEndpoint = "https://example.com/device/code"
+
+func TestOidcClient_AuthorizeDevice(t *testing.T) {
+	patterns := []struct {
+		desc                    string
+		body                    string
+		expectedVerificationUri string
+	}{
+		{
+			"verification_uri",
+			`{"device_code": "DummyDeviceCode", "user_code": "WDJB-MJHT", "verification_uri": "https://example.com/device", "expires_in": 1800, "interval": 5}`,
+			"https://example.com/device",
+		},
+		{
+			"verification_url of Google",
+			`{"device_code": "DummyDeviceCode", "user_code": "WDJB-MJHT", "verification_url": "https://www.google.com/device", "expires_in": 1800, "interval": 5}`,
+			"https://www.google.com/device",
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewGoogleOidcClient()
+	client.DeviceAuthEndpoint = testDeviceAuthEndpoint
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, testDeviceAuthEndpoint, httpmock.NewStringResponder(http.StatusOK, pattern.body))
+
+		auth, err := client.AuthorizeDevice(context.Background(), []string{"openid"})
+
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, "WDJB-MJHT", auth.UserCode, pattern.desc)
+		assert.Equal(t, pattern.expectedVerificationUri, auth.VerificationUri, pattern.desc)
+	}
+
+	_, err := NewGoogleOidcClient().AuthorizeDevice(context.Background(), []string{"openid"})
+	assert.ErrorIs(t, err, errDeviceEndpointMissing)
+}
+
+func TestOidcClient_PollDeviceToken(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		responses     []*http.Response
+		expectedCalls int
+	}{
+		{
+			"pending then success",
+			true,
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+				httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "slow_down"}`),
+				httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken"}`),
+			},
+			3,
+		},
+		{
+			"access denied",
+			false,
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+				httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "access_denied"}`),
+			},
+			2,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewGoogleOidcClient()
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.ResponderFromMultipleResponses(pattern.responses))
+
+		token, err := client.pollDeviceToken(context.Background(), "DummyDeviceCode", time.Millisecond, time.Millisecond)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "DummyAccessToken", token.AccessToken, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+		assert.Equal(t, pattern.expectedCalls, httpmock.GetTotalCallCount(), pattern.desc)
+	}
+}
+
+func TestOidcClient_PollDeviceToken_Canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewGoogleOidcClient()
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(http.StatusBadRequest, `{"error": "authorization_pending"}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.pollDeviceToken(ctx, "DummyDeviceCode", time.Millisecond, time.Millisecond)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// discordApiUrl はDiscordのAPIのベースURL
+const discordApiUrl = "https://discord.com/api/v10"
+
+// discordCdnUrl はDiscordのアバター画像などのCDNのURL
+const discordCdnUrl = "https://cdn.discordapp.com"
+
+// Discordの認可リクエストのscope
+//
+// refs: https://discord.com/developers/docs/topics/oauth2#shared-resources-oauth2-scopes
+const (
+	DiscordScopeIdentify = "identify"
+	DiscordScopeEmail    = "email"
+	// DiscordScopeGuilds はユーザーが参加しているサーバーの一覧を取得するためのscope
+	DiscordScopeGuilds = "guilds"
+)
+
+// DiscordPostLoginHook はDiscordでのログイン後に呼ばれる処理。エラーを返すとログインを失敗させる
+type DiscordPostLoginHook func(ctx context.Context, p discordProvider, user *User) error
+
+// discordProvider はDiscordのOAuth2のプロバイダ
+//
+// DiscordはOIDCに対応していないため、アクセストークンでAPIからユーザーの情報を取得する
+type discordProvider struct {
+	*oidcClient
+	// ApiUrl はAPIのベースURL
+	ApiUrl string
+	// PostLoginHooks はユーザーの情報を取得した後に順に呼ばれる
+	PostLoginHooks []DiscordPostLoginHook
+}
+
+// discordUser はGET /users/@meのレスポンス
+//
+// refs: https://discord.com/developers/docs/resources/user#user-object
+type discordUser struct {
+	Id         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+	Avatar     string `json:"avatar"`
+	Email      string `json:"email"`
+	Verified   bool   `json:"verified"`
+}
+
+// DiscordGuild はユーザーが参加しているDiscordのサーバー
+type DiscordGuild struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// Owner はユーザーがサーバーのオーナーかどうか
+	Owner bool `json:"owner"`
+}
+
+// NewDiscordProvider はDiscordのプロバイダを返す
+//
+// ギルドの確認などログイン後の処理は、返されたプロバイダのPostLoginHooksに設定する
+//
+// refs: https://discord.com/developers/docs/topics/oauth2#authorization-code-grant
+func NewDiscordProvider(opts ...ClientOption) *discordProvider {
+	client := newOidcClient(
+		Discord,
+		"",
+		os.Getenv("DISCORD_CLIENT_ID"),
+		clientSecret(os.Getenv("DISCORD_CLIENT_SECRET")),
+		"https://discord.com/oauth2/authorize",
+		discordApiUrl+"/oauth2/token",
+		"",
+		nil,
+	)
+	client.Scopes = []string{DiscordScopeIdentify, DiscordScopeEmail}
+	client.RevocationEndpoint = discordApiUrl + "/oauth2/token/revoke"
+	client.Apply(opts...)
+
+	return &discordProvider{oidcClient: client, ApiUrl: discordApiUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、APIからユーザーの情報を取得してPostLoginHooksを呼ぶ
+//
+// nonceは使われない。メールアドレスはDiscordで確認済みの場合のみEmailVerifiedをtrueにする
+func (p discordProvider) Login(ctx context.Context, code string, _ string, opts ...AuthCodeOption) (*User, error) {
+	tokenResp, err := p.postAuthorizationCode(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	token := newToken(tokenResp)
+
+	me := discordUser{}
+	if err := p.getJson(ctx, p.ApiUrl+"/users/@me", token.AccessToken, &me); err != nil {
+		return nil, fmt.Errorf("failed to GET Discord user: %w", err)
+	}
+
+	// global_nameは未設定の場合があるのでusernameで補う
+	name := me.GlobalName
+	if name == "" {
+		name = me.Username
+	}
+	picture := ""
+	if me.Avatar != "" {
+		picture = fmt.Sprintf("%s/avatars/%s/%s.png", discordCdnUrl, me.Id, me.Avatar)
+	}
+	user := &User{
+		IdProvider:    Discord,
+		Sub:           me.Id,
+		Email:         me.Email,
+		EmailVerified: me.Email != "" && me.Verified,
+		Name:          name,
+		Username:      me.Username,
+		Picture:       picture,
+		Token:         token,
+	}
+
+	for _, hook := range p.PostLoginHooks {
+		if err := hook(ctx, p, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// Guilds はユーザーが参加しているサーバーの一覧を返す。guildsスコープが必要
+//
+// refs: https://discord.com/developers/docs/resources/user#get-current-user-guilds
+func (p discordProvider) Guilds(ctx context.Context, accessToken string) ([]DiscordGuild, error) {
+	guilds := []DiscordGuild{}
+	if err := p.getJson(ctx, p.ApiUrl+"/users/@me/guilds", accessToken, &guilds); err != nil {
+		return nil, fmt.Errorf("failed to GET Discord guilds: %w", err)
+	}
+
+	return guilds, nil
+}
+
+// RequireDiscordGuild はguildIdsのいずれかのサーバーに参加しているユーザーのみログインを許可するフックを返す
+//
+// Scopesにguildsを追加しておく必要がある
+func RequireDiscordGuild(guildIds ...string) DiscordPostLoginHook {
+	return func(ctx context.Context, p discordProvider, user *User) error {
+		guilds, err := p.Guilds(ctx, user.Token.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		for _, guild := range guilds {
+			for _, id := range guildIds {
+				if guild.Id == id {
+					return nil
+				}
+			}
+		}
+
+		return errDiscordGuildNotJoined
+	}
+}
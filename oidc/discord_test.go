@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var _ Provider = discordProvider{}
+
+func TestDiscordProvider_Login(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		hooks         []DiscordPostLoginHook
+	}{
+		{"no hooks", true, nil},
+		{"guild joined", true, []DiscordPostLoginHook{RequireDiscordGuild("197038439483310086")}},
+		{"guild not joined", false, []DiscordPostLoginHook{RequireDiscordGuild("000000000000000000")}},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, discordApiUrl+"/oauth2/token", httpmock.NewStringResponder(
+		http.StatusOK,
+		`{"access_token": "DummyAccessToken", "token_type": "Bearer", "expires_in": 604800, "scope": "identify email guilds"}`,
+	))
+	httpmock.RegisterResponder(http.MethodGet, discordApiUrl+"/users/@me", httpmock.NewStringResponder(
+		http.StatusOK,
+		`{"id": "80351110224678912", "username": "nelly", "global_name": "Nelly", "avatar": "8342729096ea3675442027381ff50dfe", "email": "nelly@discord.com", "verified": true}`,
+	))
+	httpmock.RegisterResponder(http.MethodGet, discordApiUrl+"/users/@me/guilds", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `[{"id": "197038439483310086", "name": "Discord Testers", "owner": false}]`), nil
+	})
+
+	for _, pattern := range patterns {
+		provider := NewDiscordProvider()
+		provider.PostLoginHooks = pattern.hooks
+		provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+		user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, &User{
+				IdProvider:    Discord,
+				Sub:           "80351110224678912",
+				Email:         "nelly@discord.com",
+				EmailVerified: true,
+				Name:          "Nelly",
+				Username:      "nelly",
+				Picture:       "https://cdn.discordapp.com/avatars/80351110224678912/8342729096ea3675442027381ff50dfe.png",
+				Token:         user.Token,
+			}, user, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errDiscordGuildNotJoined, pattern.desc)
+		}
+	}
+}
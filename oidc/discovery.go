@@ -0,0 +1,151 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// discoveryPath はissuerからDiscoveryドキュメントのURLを組み立てる際のパス
+//
+// refs: https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+const discoveryPath = "/.well-known/openid-configuration"
+
+// providerMetadata はDiscoveryドキュメント(OpenID Provider Metadata)をunmarshalするための構造体
+type providerMetadata struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint"`
+	JwksUri                          string   `json:"jwks_uri"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	IdTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	CodeChallengeMethodsSupported    []string `json:"code_challenge_methods_supported"`
+	DeviceAuthorizationEndpoint      string   `json:"device_authorization_endpoint"`
+	BackchannelAuthEndpoint          string   `json:"backchannel_authentication_endpoint"`
+	ParEndpoint                      string   `json:"pushed_authorization_request_endpoint"`
+	IntrospectionEndpoint            string   `json:"introspection_endpoint"`
+	RevocationEndpoint               string   `json:"revocation_endpoint"`
+	EndSessionEndpoint               string   `json:"end_session_endpoint"`
+	CheckSessionIframe               string   `json:"check_session_iframe"`
+	// TokenEndpointAuthMethodsSupported はトークンエンドポイントが対応しているクライアント認証の方式
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// DiscoverProvider はissuerのDiscoveryドキュメントを取得し、内容を検証して返す
+//
+// ドキュメントのissuerが指定したissuerと一致しない場合は、なりすましを防ぐためにエラーにする。
+// 取得したドキュメントはissuerごとにキャッシュされる
+func DiscoverProvider(ctx context.Context, issuer string) (*providerMetadata, error) {
+	return defaultDiscoveryCache.Discover(ctx, issuer)
+}
+
+func discoverProvider(ctx context.Context, cfg httpConfig, issuer string) (*providerMetadata, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, cfg.timeouts.discovery())
+	defer cancel()
+	discoveryUrl := strings.TrimSuffix(issuer, "/") + discoveryPath
+	if err := cfg.urlPolicy.check(ctx, discoveryUrl); err != nil {
+		return nil, fmt.Errorf("failed to check discovery url: %w", err)
+	}
+	reqWithCtx, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request of GET discovery document: %w", err)
+	}
+
+	resp, body, err := cfg.send(reqWithCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET discovery document: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: GET discovery document returned %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	metadata := &providerMetadata{}
+	if err := json.Unmarshal(body, metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal discovery document: %w", err)
+	}
+	if err := metadata.validate(issuer); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// validate はDiscoveryドキュメントのissuerが一致し、必須の項目が揃っているかを確認する
+func (m providerMetadata) validate(issuer string) error {
+	if m.Issuer != issuer {
+		return fmt.Errorf("%w: expected %s, got %s", errDiscoveryIssuerMismatch, issuer, m.Issuer)
+	}
+
+	required := map[string]string{
+		"authorization_endpoint": m.AuthorizationEndpoint,
+		"token_endpoint":         m.TokenEndpoint,
+		"jwks_uri":               m.JwksUri,
+	}
+	for field, value := range required {
+		if value == "" {
+			return fmt.Errorf("%w: %s", errDiscoveryMissingField, field)
+		}
+	}
+
+	return nil
+}
+
+// NewOidcClient はDiscoveryドキュメントの内容で設定したクライアントを返す
+//
+// 署名アルゴリズムはドキュメントに記載されたもののうち、公開鍵で検証するものだけを許可する。
+// optsはドキュメントの内容を設定した後に適用する
+func (m providerMetadata) NewOidcClient(idProvider IdProvider, clientId string, secret string, opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		idProvider,
+		m.Issuer,
+		clientId,
+		clientSecret(secret),
+		m.AuthorizationEndpoint,
+		m.TokenEndpoint,
+		m.JwksUri,
+		m.publicKeyAlgs(),
+	)
+	client.UserInfoEndpoint = m.UserinfoEndpoint
+	client.IntrospectionEndpoint = m.IntrospectionEndpoint
+	client.RevocationEndpoint = m.RevocationEndpoint
+	client.EndSessionEndpoint = m.EndSessionEndpoint
+	client.CheckSessionIframe = m.CheckSessionIframe
+	client.DeviceAuthEndpoint = m.DeviceAuthorizationEndpoint
+	client.BackchannelAuthEndpoint = m.BackchannelAuthEndpoint
+	client.ParEndpoint = m.ParEndpoint
+	client.ClientAuthMethod = m.clientAuthMethod()
+	client.Apply(opts...)
+
+	return client
+}
+
+// clientAuthMethod はclient_secret_postに対応しておらずclient_secret_basicに対応している場合のみClientSecretBasicを返す
+func (m providerMetadata) clientAuthMethod() ClientAuthMethod {
+	if !contains(m.TokenEndpointAuthMethodsSupported, "client_secret_post") &&
+		contains(m.TokenEndpointAuthMethodsSupported, "client_secret_basic") {
+		return ClientSecretBasic
+	}
+
+	return ClientSecretPost
+}
+
+// publicKeyAlgs はid_tokenの署名アルゴリズムのうち公開鍵で検証するものを返す。記載がない場合はRS256のみとする
+//
+// HS256はclient_secretを鍵とするためAllowHS256で明示的に有効にする必要があり、noneは常に許可しない
+func (m providerMetadata) publicKeyAlgs() []string {
+	algs := make([]string, 0, len(m.IdTokenSigningAlgValuesSupported))
+	for _, alg := range m.IdTokenSigningAlgValuesSupported {
+		if _, ok := algKeyTypes[alg]; ok {
+			algs = append(algs, alg)
+		}
+	}
+	if len(algs) == 0 {
+		return []string{"RS256"}
+	}
+
+	return algs
+}
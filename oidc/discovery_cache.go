@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDiscoveryCacheTtl はDiscoveryドキュメントを再取得せずに使う期間
+	defaultDiscoveryCacheTtl = 1 * time.Hour
+	// defaultDiscoveryStaleTtl は有効期限が切れた後も、裏で再取得しつつ古いドキュメントを使い続けてよい期間
+	defaultDiscoveryStaleTtl = 24 * time.Hour
+)
+
+// defaultDiscoveryCache はDiscoverProviderで使うキャッシュ
+var defaultDiscoveryCache = NewDiscoveryCache(defaultDiscoveryCacheTtl, defaultDiscoveryStaleTtl)
+
+type discoveryCacheEntry struct {
+	metadata  *providerMetadata
+	fetchedAt time.Time
+}
+
+// discoveryCache はissuerごとにDiscoveryドキュメントを保持する
+//
+// 有効期限が切れてもstaleTtlの間は古いドキュメントを返しつつ裏で再取得する(stale-while-revalidate)ので、
+// ログインのたびにDiscoveryドキュメントの取得を待つ必要がない
+type discoveryCache struct {
+	mu         sync.Mutex
+	entries    map[string]discoveryCacheEntry
+	refreshing map[string]bool
+	ttl        time.Duration
+	staleTtl   time.Duration
+	now        func() time.Time
+	// wg は裏で実行中の再取得を待つためのもの
+	wg sync.WaitGroup
+}
+
+// NewDiscoveryCache はDiscoveryドキュメントのキャッシュを返す
+//
+// ttlは再取得せずに使う期間、staleTtlは有効期限が切れた後に再取得しつつ古いドキュメントを使う期間
+func NewDiscoveryCache(ttl time.Duration, staleTtl time.Duration) *discoveryCache {
+	return &discoveryCache{
+		entries:    map[string]discoveryCacheEntry{},
+		refreshing: map[string]bool{},
+		ttl:        ttl,
+		staleTtl:   staleTtl,
+		now:        time.Now,
+	}
+}
+
+// Purge はキャッシュをすべて破棄する
+func (c *discoveryCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]discoveryCacheEntry{}
+}
+
+// Discover はキャッシュを使ってissuerのDiscoveryドキュメントを返す
+func (c *discoveryCache) Discover(ctx context.Context, issuer string) (*providerMetadata, error) {
+	return c.discover(ctx, httpConfig{}, issuer)
+}
+
+func (c *discoveryCache) discover(ctx context.Context, cfg httpConfig, issuer string) (*providerMetadata, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[issuer]
+	age := c.now().Sub(entry.fetchedAt)
+	switch {
+	case ok && age < c.ttl:
+		c.mu.Unlock()
+		cfg.log().DebugContext(ctx, "discovery cache hit", slog.String("issuer", issuer))
+		cfg.recorder().CacheAccessed(DiscoveryCacheName, true)
+
+		return entry.metadata, nil
+	case ok && age < c.ttl+c.staleTtl:
+		// 古いドキュメントを返しつつ、裏で再取得する。同じissuerの再取得は同時に1つまでにする
+		if !c.refreshing[issuer] {
+			c.refreshing[issuer] = true
+			c.wg.Add(1)
+			go c.refresh(cfg, issuer)
+		}
+		c.mu.Unlock()
+		cfg.log().DebugContext(ctx, "discovery cache stale, refreshing in background", slog.String("issuer", issuer))
+		cfg.recorder().CacheAccessed(DiscoveryCacheName, true)
+
+		return entry.metadata, nil
+	}
+	c.mu.Unlock()
+	cfg.log().DebugContext(ctx, "discovery cache miss", slog.String("issuer", issuer))
+	cfg.recorder().CacheAccessed(DiscoveryCacheName, false)
+
+	metadata, err := discoverProvider(ctx, cfg, issuer)
+	if err != nil {
+		return nil, err
+	}
+	c.set(issuer, metadata)
+
+	return metadata, nil
+}
+
+// refresh は裏でDiscoveryドキュメントを再取得する。失敗した場合はstaleTtlの間は古いドキュメントを使い続ける
+func (c *discoveryCache) refresh(cfg httpConfig, issuer string) {
+	defer c.wg.Done()
+
+	// 呼び出し元のリクエストが終わっても再取得を続けられるように、独立したcontextを使う
+	metadata, err := discoverProvider(context.Background(), cfg, issuer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.refreshing, issuer)
+	if err != nil {
+		cfg.log().Warn("failed to refresh discovery document", slog.String("issuer", issuer), slog.Any("error", err))
+
+		return
+	}
+	c.entries[issuer] = discoveryCacheEntry{metadata: metadata, fetchedAt: c.now()}
+}
+
+func (c *discoveryCache) set(issuer string, metadata *providerMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[issuer] = discoveryCacheEntry{metadata: metadata, fetchedAt: c.now()}
+}
@@ -0,0 +1,145 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testIssuer = "https://idp.example.com"
+
+const testDiscoveryBody = `{
+  "issuer": "https://idp.example.com",
+  "authorization_endpoint": "https://idp.example.com/authorize",
+  "token_endpoint": "https://idp.example.com/token",
+  "userinfo_endpoint": "https://idp.example.com/userinfo",
+  "jwks_uri": "https://idp.example.com/jwks",
+  "response_types_supported": ["code"],
+  "id_token_signing_alg_values_supported": ["RS256", "ES256", "HS256", "none"]
+}`
+
+func TestDiscoverProvider(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		issuer        string
+		status        int
+		body          string
+	}{
+		{"valid", true, testIssuer, http.StatusOK, testDiscoveryBody},
+		{"issuer with trailing slash", false, testIssuer + "/", http.StatusOK, testDiscoveryBody},
+		{"issuer mismatch", false, testIssuer, http.StatusOK, `{"issuer": "https://evil.example.com"}`},
+		{"missing jwks_uri", false, testIssuer, http.StatusOK, `{
+  "issuer": "https://idp.example.com",
+  "authorization_endpoint": "https://idp.example.com/authorize",
+  "token_endpoint": "https://idp.example.com/token"
+}`},
+		{"not found", false, testIssuer, http.StatusNotFound, ""},
+		{"invalid json", false, testIssuer, http.StatusOK, "<html></html>"},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		defaultDiscoveryCache.Purge()
+		httpmock.Reset()
+		httpmock.RegisterResponder(
+			http.MethodGet,
+			testIssuer+"/.well-known/openid-configuration",
+			httpmock.NewStringResponder(pattern.status, pattern.body),
+		)
+
+		metadata, err := DiscoverProvider(context.Background(), pattern.issuer)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "https://idp.example.com/jwks", metadata.JwksUri, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestProviderMetadata_NewOidcClient(t *testing.T) {
+	defaultDiscoveryCache.Purge()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		testIssuer+"/.well-known/openid-configuration",
+		httpmock.NewStringResponder(http.StatusOK, testDiscoveryBody),
+	)
+
+	metadata, err := DiscoverProvider(context.Background(), testIssuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metadata.NewOidcClient(Google, "client-id", "client-secret")
+
+	assert.Equal(t, testIssuer, client.Issuer)
+	assert.Equal(t, "https://idp.example.com/authorize", client.authEndpoint)
+	assert.Equal(t, "https://idp.example.com/token", client.tokenEndpoint)
+	assert.Equal(t, "https://idp.example.com/jwks", client.JwksEndpoint)
+	// HS256とnoneはドキュメントに記載されていても許可しない
+	assert.Equal(t, []string{"RS256", "ES256"}, client.AllowedAlgs)
+	assert.False(t, client.AllowHS256)
+}
+
+func TestDiscoveryCache_Discover(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		testIssuer+"/.well-known/openid-configuration",
+		httpmock.NewStringResponder(http.StatusOK, testDiscoveryBody),
+	)
+
+	now := time.Now()
+	cache := NewDiscoveryCache(time.Hour, time.Hour)
+	var mu sync.Mutex
+	cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	_, err := cache.Discover(context.Background(), testIssuer)
+	assert.Nil(t, err)
+	_, err = cache.Discover(context.Background(), testIssuer)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	// 有効期限切れ後はキャッシュを返しつつ裏で再取得する
+	advance(90 * time.Minute)
+	metadata, err := cache.Discover(context.Background(), testIssuer)
+	assert.Nil(t, err)
+	assert.Equal(t, testIssuer, metadata.Issuer)
+	cache.wg.Wait()
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// 再取得した結果は新しいものとして扱われる
+	_, _ = cache.Discover(context.Background(), testIssuer)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// staleTtlも過ぎた場合は同期的に取得する
+	advance(3 * time.Hour)
+	httpmock.Reset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		testIssuer+"/.well-known/openid-configuration",
+		httpmock.NewStringResponder(http.StatusInternalServerError, ""),
+	)
+	_, err = cache.Discover(context.Background(), testIssuer)
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// dpopHeader はDPoP proofを送るヘッダ
+	dpopHeader = "DPoP"
+	// dpopNonceHeader はサーバーがproofに含めるnonceを返すヘッダ
+	dpopNonceHeader = "DPoP-Nonce"
+	// dpopJwtType はDPoP proofのヘッダのtyp
+	dpopJwtType = "dpop+jwt"
+	// dpopNonceError はサーバーがnonceを含めたproofを要求する場合のエラーコード
+	dpopNonceError = "use_dpop_nonce"
+	// dpopTokenType はDPoPに紐付けられたアクセストークンのtoken_type
+	dpopTokenType = "DPoP"
+)
+
+// Confirmation はトークンが紐付けられた鍵を表すcnfクレーム
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7800
+type Confirmation struct {
+	// Jkt はDPoPの公開鍵のJWK Thumbprint
+	Jkt string `json:"jkt"`
+}
+
+// dpopSigner はDPoP proofに署名する秘密鍵
+//
+// サーバーから返されたnonceはオリジンごとに保持し、以降のproofに含める
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc9449
+type dpopSigner struct {
+	alg        string
+	key        crypto.Signer
+	jwk        jwk
+	thumbprint string
+	mu         sync.Mutex
+	nonces     map[string]string
+	now        func() time.Time
+}
+
+// NewDpopSigner はkeyでDPoP proofに署名するdpopSignerを返す
+//
+// algはRS256, PS256, ES256, EdDSAなどで、keyの種類と一致している必要がある。
+// トークンは公開鍵に紐付けられるので、トークンを使い続ける間は同じ鍵を使う
+func NewDpopSigner(alg string, key crypto.Signer) (*dpopSigner, error) {
+	publicJwk, err := newPublicJwk(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	// DPoP proofには共通鍵による署名は使えないので、公開鍵のアルゴリズムのみを受け付ける
+	if _, ok := algKeyTypes[alg]; !ok {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedAlg, alg)
+	}
+	if !publicJwk.isSigningKeyFor(alg) {
+		return nil, fmt.Errorf("%w: %s", errKeyAlgMismatch, alg)
+	}
+	thumbprint, err := publicJwk.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dpopSigner{
+		alg:        alg,
+		key:        key,
+		jwk:        publicJwk,
+		thumbprint: thumbprint,
+		nonces:     map[string]string{},
+		now:        time.Now,
+	}, nil
+}
+
+// Thumbprint は公開鍵のJWK Thumbprintを返す。トークンのcnf.jktと比較する値
+func (s *dpopSigner) Thumbprint() string {
+	return s.thumbprint
+}
+
+// Proof はmethodでtargetUrlにリクエストするためのDPoP proofを返す
+//
+// accessTokenが空でない場合は、リソースへのリクエスト用にアクセストークンのハッシュ(ath)を含める。
+// targetUrlのオリジンからnonceを返されている場合はそのnonceを含める
+func (s *dpopSigner) Proof(method string, targetUrl string, accessToken string) (string, error) {
+	u, err := url.Parse(targetUrl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse DPoP target url: %w", err)
+	}
+
+	return s.proof(method, u, accessToken)
+}
+
+func (s *dpopSigner) proof(method string, target *url.URL, accessToken string) (string, error) {
+	jti, err := randomToken(nonceBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate jti: %w", err)
+	}
+
+	claims := map[string]interface{}{
+		"jti": jti,
+		"htm": method,
+		"htu": dpopHtu(target),
+		"iat": s.now().Unix(),
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+	if nonce := s.nonce(target); nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	header := map[string]interface{}{"alg": s.alg, "typ": dpopJwtType, "jwk": s.jwk.requiredMembers()}
+	proof, err := signJws(s.alg, header, s.key, claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign DPoP proof: %w", err)
+	}
+
+	return proof, nil
+}
+
+// Authorize はreqにDPoP proofを付ける
+//
+// accessTokenが空でない場合は、AuthorizationヘッダにDPoPスキームでアクセストークンを付ける
+func (s *dpopSigner) Authorize(req *http.Request, accessToken string) error {
+	proof, err := s.proof(req.Method, req.URL, accessToken)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(dpopHeader, proof)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "DPoP "+accessToken)
+	}
+
+	return nil
+}
+
+// CheckBinding はトークンのcnf.jktがこの鍵のJWK Thumbprintと一致するかを確認する
+//
+// IdPがトークンを別の鍵に紐付けていないか、イントロスペクションの結果などで確認するために使う
+func (s *dpopSigner) CheckBinding(cnf Confirmation) error {
+	if cnf.Jkt == "" {
+		return errDpopJktMissing
+	}
+	if subtle.ConstantTimeCompare([]byte(cnf.Jkt), []byte(s.thumbprint)) != 1 {
+		return fmt.Errorf("%w: %s", errDpopJktMismatch, cnf.Jkt)
+	}
+
+	return nil
+}
+
+// checkTokenResponse はトークンレスポンスがDPoPに紐付けられたトークンかを確認する
+//
+// token_typeがBearerの場合はIdPがDPoPに対応しておらず、盗まれたトークンを誰でも使えてしまうので受け入れない
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc9449#section-5
+func (s *dpopSigner) checkTokenResponse(tokenResp tokenResponse) error {
+	if !strings.EqualFold(tokenResp.TokenType, dpopTokenType) {
+		return fmt.Errorf("%w: %s", errDpopTokenType, tokenResp.TokenType)
+	}
+
+	return s.checkAccessToken(tokenResp.AccessToken)
+}
+
+// checkAccessToken はJWT形式のアクセストークンにcnfが含まれている場合、この鍵に紐付けられているかを確認する
+//
+// アクセストークンはクライアントにとって不透明な値なので、JWTとして読めない場合やcnfがない場合は確認しない
+func (s *dpopSigner) checkAccessToken(accessToken string) error {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+	claims := struct {
+		Cnf *Confirmation `json:"cnf"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Cnf == nil {
+		return nil
+	}
+
+	return s.CheckBinding(*claims.Cnf)
+}
+
+// send はDPoP proofを付けてリクエストを送る
+//
+// サーバーは使用済みのjtiのproofを拒否するので、リトライを含めて送るたびにproofを作り直す。
+// サーバーにnonceを要求された場合は、返されたnonceを含めたproofで1度だけ送り直す
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc9449#section-8
+func (s *dpopSigner) send(cfg httpConfig, req *http.Request, accessToken string) (*http.Response, []byte, error) {
+	cfg.beforeAttempt = func(req *http.Request) error {
+		return s.Authorize(req, accessToken)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, body, err := cfg.send(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.rememberNonce(req.URL, resp.Header.Get(dpopNonceHeader))
+		if attempt >= 2 || resp.Header.Get(dpopNonceHeader) == "" || !dpopNonceRequired(resp, body) {
+			return resp, body, nil
+		}
+
+		// ボディは送信時に読み込まれているので、送り直す前に作り直す
+		if req.GetBody != nil {
+			newBody, err := req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = newBody
+		}
+	}
+}
+
+// nonce はtargetのオリジンから返されたnonceを返す
+func (s *dpopSigner) nonce(target *url.URL) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nonces[dpopOrigin(target)]
+}
+
+// rememberNonce はtargetのオリジンから返されたnonceを保持する。nonceが空の場合は何もしない
+//
+// nonceは成功したレスポンスで更新されることもあるので、レスポンスを受け取るたびに呼ぶ
+func (s *dpopSigner) rememberNonce(target *url.URL, nonce string) {
+	if nonce == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nonces[dpopOrigin(target)] = nonce
+}
+
+// dpopNonceRequired はレスポンスがnonceを含めたproofを要求するエラーかを返す
+//
+// 認可サーバーは400のOAuthエラーで、リソースサーバーは401のWWW-Authenticateヘッダで要求する
+func dpopNonceRequired(resp *http.Response, body []byte) bool {
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		oauthErr := OAuthError{}
+
+		return json.Unmarshal(body, &oauthErr) == nil && oauthErr.Code == dpopNonceError
+	case http.StatusUnauthorized:
+		return strings.Contains(resp.Header.Get("WWW-Authenticate"), `error="`+dpopNonceError+`"`)
+	default:
+		return false
+	}
+}
+
+// dpopHtu はproofのhtuとして使う、クエリとフラグメントを除いたURLを返す
+func dpopHtu(target *url.URL) string {
+	u := *target
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String()
+}
+
+func dpopOrigin(target *url.URL) string {
+	return target.Scheme + "://" + target.Host
+}
@@ -0,0 +1,318 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// decodeDpopProofForTest はproofの署名を検証し、ヘッダとpayloadを返す
+func decodeDpopProofForTest(t *testing.T, signer *dpopSigner, proof string) (map[string]interface{}, map[string]interface{}) {
+	parts := strings.Split(proof, ".")
+	if len(parts) != 3 {
+		t.Fatalf("invalid proof: %s", proof)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySignature(signer.alg, signer.key.Public(), parts[0]+"."+parts[1], signature); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := make([]map[string]interface{}, 2)
+	for i := range decoded {
+		raw, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(raw, &decoded[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return decoded[0], decoded[1]
+}
+
+func dpopSignerForTest(t *testing.T) *dpopSigner {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewDpopSigner("ES256", ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+func TestNewDpopSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		alg           string
+		key           interface{}
+	}{
+		{"RS256", true, "RS256", rsaKey},
+		{"PS256", true, "PS256", rsaKey},
+		{"ES256", true, "ES256", ecKey},
+		{"EdDSA", true, "EdDSA", edKey},
+		{"RSA key with ES256", false, "ES256", rsaKey},
+		{"P-256 key with ES384", false, "ES384", ecKey},
+		{"HS256", false, "HS256", rsaKey},
+	}
+
+	for _, pattern := range patterns {
+		var err error
+		switch key := pattern.key.(type) {
+		case *rsa.PrivateKey:
+			_, err = NewDpopSigner(pattern.alg, key)
+		case *ecdsa.PrivateKey:
+			_, err = NewDpopSigner(pattern.alg, key)
+		case ed25519.PrivateKey:
+			_, err = NewDpopSigner(pattern.alg, key)
+		}
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestDpopSigner_Proof(t *testing.T) {
+	signer := dpopSignerForTest(t)
+	target, _ := url.Parse("https://api.example.com/resource")
+	signer.rememberNonce(target, "server-nonce")
+
+	proof, err := signer.Proof(http.MethodGet, "https://api.example.com/resource?q=1#fragment", "DummyAccessToken")
+	assert.Nil(t, err)
+
+	header, claims := decodeDpopProofForTest(t, signer, proof)
+	assert.Equal(t, "dpop+jwt", header["typ"])
+	assert.Equal(t, "ES256", header["alg"])
+	rawJwk, _ := json.Marshal(header["jwk"])
+	headerJwk := jwk{}
+	assert.Nil(t, json.Unmarshal(rawJwk, &headerJwk))
+	thumbprint, err := headerJwk.thumbprint()
+	assert.Nil(t, err)
+	assert.Equal(t, signer.Thumbprint(), thumbprint)
+
+	hash := sha256.Sum256([]byte("DummyAccessToken"))
+	assert.Equal(t, "GET", claims["htm"])
+	assert.Equal(t, "https://api.example.com/resource", claims["htu"])
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(hash[:]), claims["ath"])
+	assert.Equal(t, "server-nonce", claims["nonce"])
+	assert.NotEmpty(t, claims["jti"])
+	assert.NotEmpty(t, claims["iat"])
+
+	// アクセストークンがない場合はathを含めず、nonceはオリジンごとに保持する
+	proof, err = signer.Proof(http.MethodPost, "https://idp.example.com/token", "")
+	assert.Nil(t, err)
+	_, claims = decodeDpopProofForTest(t, signer, proof)
+	assert.NotContains(t, claims, "ath")
+	assert.NotContains(t, claims, "nonce")
+}
+
+func TestDpopSigner_CheckBinding(t *testing.T) {
+	signer := dpopSignerForTest(t)
+	another := dpopSignerForTest(t)
+
+	patterns := []struct {
+		desc        string
+		cnf         Confirmation
+		expectedErr error
+	}{
+		{"bound to this key", Confirmation{Jkt: signer.Thumbprint()}, nil},
+		{"bound to another key", Confirmation{Jkt: another.Thumbprint()}, errDpopJktMismatch},
+		{"jkt missing", Confirmation{}, errDpopJktMissing},
+	}
+
+	for _, pattern := range patterns {
+		err := signer.CheckBinding(pattern.cnf)
+
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_PostToken_Dpop(t *testing.T) {
+	signer := dpopSignerForTest(t)
+	another := dpopSignerForTest(t)
+	accessTokenForTest := func(jkt string) string {
+		payload, _ := json.Marshal(map[string]interface{}{"sub": "1234567890", "cnf": map[string]string{"jkt": jkt}})
+
+		return "eyJhbGciOiJFUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+	}
+
+	patterns := []struct {
+		desc          string
+		tokenType     string
+		accessToken   string
+		expectedErr   error
+		expectedCalls int
+	}{
+		{"opaque access token", "DPoP", "DummyAccessToken", nil, 2},
+		{"bound to this key", "DPoP", accessTokenForTest(signer.Thumbprint()), nil, 2},
+		{"bound to another key", "DPoP", accessTokenForTest(another.Thumbprint()), errDpopJktMismatch, 2},
+		{"downgraded to bearer", "Bearer", "DummyAccessToken", errDpopTokenType, 2},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		pattern := pattern
+		client := NewGoogleOidcClient()
+		client.Dpop = signer
+		signer.nonces = map[string]string{}
+
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+			header, claims := decodeDpopProofForTest(t, signer, req.Header.Get("DPoP"))
+			assert.Equal(t, "dpop+jwt", header["typ"], pattern.desc)
+			assert.Equal(t, "POST", claims["htm"], pattern.desc)
+			assert.Equal(t, client.tokenEndpoint, claims["htu"], pattern.desc)
+			assert.NotContains(t, claims, "ath", pattern.desc)
+
+			// nonceを含まないproofにはnonceを要求する
+			if claims["nonce"] != "server-nonce" {
+				resp := httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "use_dpop_nonce"}`)
+				resp.Header.Set("DPoP-Nonce", "server-nonce")
+
+				return resp, nil
+			}
+			if err := req.ParseForm(); err != nil {
+				return nil, err
+			}
+			assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"), pattern.desc)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"access_token": "`+pattern.accessToken+`", "token_type": "`+pattern.tokenType+`"}`), nil
+		})
+
+		tokenResp, err := client.postToken(context.Background(), url.Values{"grant_type": {"client_credentials"}})
+
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "DPoP", tokenResp.TokenType, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		}
+		assert.Equal(t, pattern.expectedCalls, httpmock.GetTotalCallCount(), pattern.desc)
+	}
+}
+
+func TestOidcClient_PostToken_DpopRetry(t *testing.T) {
+	signer := dpopSignerForTest(t)
+	client := NewGoogleOidcClient()
+	client.Dpop = signer
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// リトライで送り直す場合もproofを作り直し、同じjtiを使わない
+	jtis := map[string]bool{}
+	responder := httpmock.ResponderFromMultipleResponses([]*http.Response{
+		httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+		httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken", "token_type": "DPoP"}`),
+	})
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		_, claims := decodeDpopProofForTest(t, signer, req.Header.Get("DPoP"))
+		jti, _ := claims["jti"].(string)
+		assert.False(t, jtis[jti])
+		jtis[jti] = true
+
+		return responder(req)
+	})
+
+	_, err := client.postToken(context.Background(), url.Values{"grant_type": {"client_credentials"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	assert.Len(t, jtis, 2)
+}
+
+func TestOidcClient_UserInfo_Dpop(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := dpopSignerForTest(t)
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	client.Dpop = signer
+
+	idToken, err := NewIdToken(encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		validGooglePayloadForTest(),
+		rsaSignerForTest(rsaKey),
+	), Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	hash := sha256.Sum256([]byte("DummyAccessToken"))
+	httpmock.RegisterResponder(http.MethodGet, client.UserInfoEndpoint, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "DPoP DummyAccessToken", req.Header.Get("Authorization"))
+		_, claims := decodeDpopProofForTest(t, signer, req.Header.Get("DPoP"))
+		assert.Equal(t, "GET", claims["htm"])
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(hash[:]), claims["ath"])
+
+		// リソースサーバーはWWW-Authenticateヘッダでnonceを要求する
+		if claims["nonce"] != "resource-nonce" {
+			resp := httpmock.NewStringResponse(http.StatusUnauthorized, "")
+			resp.Header.Set("WWW-Authenticate", `DPoP error="use_dpop_nonce", error_description="Resource server requires nonce in DPoP proof"`)
+			resp.Header.Set("DPoP-Nonce", "resource-nonce")
+
+			return resp, nil
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"sub": "1234567890", "email": "user@example.com"}`)
+		resp.Header.Set("Content-Type", "application/json")
+
+		return resp, nil
+	})
+
+	info, err := client.UserInfo(context.Background(), "DummyAccessToken", idToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "user@example.com", info.StandardClaims().Email)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// 返されたnonceは以降のリクエストでも使う
+	_, err = client.UserInfo(context.Background(), "DummyAccessToken", idToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
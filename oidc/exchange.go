@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Token はトークンエンドポイントから得られたトークン
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Scope        string
+	// Expiry はアクセストークンの有効期限。expires_inが含まれていない場合はゼロ値
+	Expiry time.Time
+	// IdToken は検証済みのid_token
+	IdToken *idToken
+	// IssuedTokenType はトークンエクスチェンジで発行されたトークンの種類
+	IssuedTokenType string
+}
+
+// Exchange は認可コードをトークンエンドポイントに渡してトークンを取得し、id_tokenを検証して返す
+//
+// リダイレクトURIにはRedirectUrlを使う。DisablePkceがfalseの場合はWithCodeVerifierでcode_verifierを渡す必要があり、
+// 渡さない場合はトークンエンドポイントにリクエストせずにエラーを返す
+func (c oidcClient) Exchange(ctx context.Context, code string, opts ...AuthCodeOption) (*Token, error) {
+	tokenResp, err := c.postAuthorizationCode(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.verifyTokenResponse(ctx, tokenResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// postAuthorizationCode はPKCEのcode_verifierが渡されているかを確認してから、認可コードをトークンエンドポイントに渡す
+func (c oidcClient) postAuthorizationCode(ctx context.Context, code string, opts ...AuthCodeOption) (tokenResponse, error) {
+	if err := c.checkCodeVerifier(opts); err != nil {
+		return tokenResponse{}, err
+	}
+
+	return c.PostTokenEndpoint(ctx, code, c.RedirectUrl, "authorization_code", opts...)
+}
+
+// verifyTokenResponse はトークンレスポンスのid_tokenを検証してTokenを作る
+func (c oidcClient) verifyTokenResponse(ctx context.Context, tokenResp tokenResponse) (*Token, error) {
+	if tokenResp.IdToken == "" {
+		return nil, errIdTokenMissing
+	}
+
+	idToken, err := NewIdToken(tokenResp.IdToken, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse id_token: %w", err)
+	}
+	if err := c.Verifier().Verify(ctx, idToken); err != nil {
+		return nil, err
+	}
+	if err := idToken.VerifyAccessToken(tokenResp.AccessToken); err != nil {
+		return nil, err
+	}
+
+	token := newToken(tokenResp)
+	token.IdToken = idToken
+
+	return token, nil
+}
+
+// tokenFromResponse はid_tokenが任意のグラントのトークンレスポンスからTokenを作る。id_tokenが含まれる場合は検証する
+func (c oidcClient) tokenFromResponse(ctx context.Context, tokenResp tokenResponse) (*Token, error) {
+	if tokenResp.IdToken == "" {
+		return newToken(tokenResp), nil
+	}
+
+	return c.verifyTokenResponse(ctx, tokenResp)
+}
+
+// newToken はトークンレスポンスからid_token以外をセットしたTokenを作る
+func newToken(tokenResp tokenResponse) *Token {
+	token := &Token{
+		AccessToken:     tokenResp.AccessToken,
+		TokenType:       tokenResp.TokenType,
+		RefreshToken:    tokenResp.RefreshToken,
+		Scope:           tokenResp.Scope,
+		IssuedTokenType: tokenResp.IssuedTokenType,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+
+	return token
+}
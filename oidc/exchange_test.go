@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_Exchange(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	validIdToken := encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		validGooglePayloadForTest(),
+		rsaSignerForTest(rsaKey),
+	)
+	expiredPayload := validGooglePayloadForTest()
+	expiredPayload["exp"] = time.Now().Add(-time.Hour).Unix()
+	expiredIdToken := encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		expiredPayload,
+		rsaSignerForTest(rsaKey),
+	)
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		status        int
+		body          string
+	}{
+		{
+			"valid",
+			true,
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken", "refresh_token": "DummyRefreshToken", "expires_in": 3600, "token_type": "Bearer", "id_token": "` + validIdToken + `"}`,
+		},
+		{
+			"expired id_token",
+			false,
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken", "id_token": "` + expiredIdToken + `"}`,
+		},
+		{
+			"id_token missing",
+			false,
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken"}`,
+		},
+		{
+			"invalid_grant",
+			false,
+			http.StatusBadRequest,
+			`{"error": "invalid_grant", "error_description": "Bad Request"}`,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(pattern.status, pattern.body))
+
+		token, err := client.Exchange(context.Background(), "DummyCode", WithCodeVerifier("DummyCodeVerifier"))
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "DummyAccessToken", token.AccessToken, pattern.desc)
+			assert.Equal(t, "DummyRefreshToken", token.RefreshToken, pattern.desc)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute, pattern.desc)
+			assert.Equal(t, "1234567890", token.IdToken.StandardClaims().Sub, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package oidc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+const (
+	// facebookGraphApiVersion は使用するGraph APIのバージョン
+	facebookGraphApiVersion = "v19.0"
+	facebookGraphUrl        = "https://graph.facebook.com/" + facebookGraphApiVersion
+)
+
+// facebookProvider はFacebookログインのプロバイダ
+//
+// 通常のFacebookログインはOIDCに対応していないため、アクセストークンでGraph APIからユーザーの情報を取得する。
+// iOSのLimited Loginで発行されるOIDCのトークンはVerifyLimitedLoginTokenで検証する
+type facebookProvider struct {
+	*oidcClient
+	// GraphUrl はGraph APIのベースURL
+	GraphUrl string
+}
+
+// facebookMe はGraph APIの/meのレスポンス
+type facebookMe struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Picture struct {
+		Data struct {
+			Url string `json:"url"`
+		} `json:"data"`
+	} `json:"picture"`
+}
+
+// NewFacebookProvider はFacebookログインのプロバイダを返す
+//
+// refs: https://developers.facebook.com/docs/facebook-login/guides/advanced/manual-flow
+func NewFacebookProvider(opts ...ClientOption) *facebookProvider {
+	client := newOidcClient(
+		Facebook,
+		"https://www.facebook.com",
+		os.Getenv("FACEBOOK_APP_ID"),
+		clientSecret(os.Getenv("FACEBOOK_APP_SECRET")),
+		"https://www.facebook.com/"+facebookGraphApiVersion+"/dialog/oauth",
+		facebookGraphUrl+"/oauth/access_token",
+		"https://limited.facebook.com/.well-known/oauth/openid/jwks/",
+		[]string{"RS256"},
+	)
+	client.Scopes = []string{"email", "public_profile"}
+	client.Apply(opts...)
+
+	return &facebookProvider{oidcClient: client, GraphUrl: facebookGraphUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、Graph APIからユーザーの情報を取得する
+//
+// トークンレスポンスにid_tokenが含まれる場合はid_tokenを検証し、nonceを確認する。
+// Graph APIはメールアドレスが確認済みかどうかを返さないので、Graph APIから取得した場合のEmailVerifiedはfalseになる
+func (p facebookProvider) Login(ctx context.Context, code string, nonce string, opts ...AuthCodeOption) (*User, error) {
+	tokenResp, err := p.postAuthorizationCode(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	token, err := p.tokenFromResponse(ctx, tokenResp)
+	if err != nil {
+		return nil, err
+	}
+	if token.IdToken != nil {
+		if err := token.IdToken.VerifyNonce(nonce); err != nil {
+			return nil, err
+		}
+
+		return newUserFromClaims(Facebook, token.IdToken.StandardClaims(), token), nil
+	}
+
+	me, err := p.me(ctx, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{
+		IdProvider: Facebook,
+		Sub:        me.Id,
+		Email:      me.Email,
+		Name:       me.Name,
+		Picture:    me.Picture.Data.Url,
+		Token:      token,
+	}, nil
+}
+
+// me はGraph APIの/meからユーザーの情報を取得する
+//
+// アクセストークンが盗まれても別のアプリから使われないように、appsecret_proofを付ける
+//
+// refs: https://developers.facebook.com/docs/graph-api/securing-requests#appsecret_proof
+func (p facebookProvider) me(ctx context.Context, accessToken string) (facebookMe, error) {
+	values := url.Values{}
+	values.Set("fields", "id,name,email,picture")
+	values.Set("appsecret_proof", p.appSecretProof(accessToken))
+
+	me := facebookMe{}
+	if err := p.getJson(ctx, p.GraphUrl+"/me?"+values.Encode(), accessToken, &me); err != nil {
+		return facebookMe{}, fmt.Errorf("failed to GET Facebook Graph API: %w", err)
+	}
+
+	return me, nil
+}
+
+// appSecretProof はアプリシークレットを鍵としたアクセストークンのHMAC-SHA256を返す
+func (p facebookProvider) appSecretProof(accessToken string) string {
+	mac := hmac.New(sha256.New, []byte(p.clientSecret))
+	mac.Write([]byte(accessToken))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyLimitedLoginToken はiOSのLimited Loginで発行されたOIDCのトークンを検証し、ユーザーの情報を返す
+//
+// このトークンではGraph APIを使えないため、署名とクレームを検証し、nonceを確認する
+//
+// refs: https://developers.facebook.com/docs/facebook-login/limited-login/token/validating
+func (p facebookProvider) VerifyLimitedLoginToken(ctx context.Context, rawIdToken string, nonce string) (*User, error) {
+	idToken, err := p.verifyFrontChannelIdToken(ctx, rawIdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return newUserFromClaims(Facebook, idToken.StandardClaims(), &Token{IdToken: idToken}), nil
+}
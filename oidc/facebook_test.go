@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var _ Provider = facebookProvider{}
+
+func TestFacebookProvider_Login(t *testing.T) {
+	provider := NewFacebookProvider()
+	provider.clientSecret = "DummyAppSecret"
+	provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, provider.tokenEndpoint, httpmock.NewStringResponder(
+		http.StatusOK,
+		`{"access_token": "DummyAccessToken", "token_type": "bearer", "expires_in": 5183944}`,
+	))
+	httpmock.RegisterResponder(http.MethodGet, facebookGraphUrl+"/me", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"))
+		assert.Equal(t, provider.appSecretProof("DummyAccessToken"), req.URL.Query().Get("appsecret_proof"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "id": "10158000000000000",
+  "name": "Taro Facebook",
+  "email": "user@example.com",
+  "picture": {"data": {"url": "https://platform-lookaside.fbsbx.com/picture"}}
+}`), nil
+	})
+
+	user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+	assert.Nil(t, err)
+	// Graph APIはメールアドレスの確認状態を返さないので、確認済みとして扱わない
+	assert.Equal(t, &User{
+		IdProvider: Facebook,
+		Sub:        "10158000000000000",
+		Email:      "user@example.com",
+		Name:       "Taro Facebook",
+		Picture:    "https://platform-lookaside.fbsbx.com/picture",
+		Token:      user.Token,
+	}, user)
+	assert.Equal(t, "DummyAccessToken", user.Token.AccessToken)
+}
+
+func TestFacebookProvider_AppSecretProof(t *testing.T) {
+	provider := NewFacebookProvider()
+	provider.clientSecret = "secret"
+
+	// echo -n "token" | openssl dgst -sha256 -hmac "secret"
+	assert.Equal(t, "e941110e3d2bfe82621f0e3e1434730d7305d106c5f68c87165d0b27a4611a4a", provider.appSecretProof("token"))
+}
+
+func TestFacebookProvider_VerifyLimitedLoginToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewFacebookProvider()
+	provider.ClientId = "DummyAppId"
+	provider.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	rawIdToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, map[string]interface{}{
+		"iss":     "https://www.facebook.com",
+		"aud":     "DummyAppId",
+		"sub":     "10158000000000000",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+		"iat":     time.Now().Unix(),
+		"nonce":   "DummyNonce",
+		"name":    "Taro Facebook",
+		"email":   "user@example.com",
+		"picture": "https://platform-lookaside.fbsbx.com/picture",
+	}, rsaSignerForTest(rsaKey))
+
+	user, err := provider.VerifyLimitedLoginToken(context.Background(), rawIdToken, "DummyNonce")
+	assert.Nil(t, err)
+	assert.Equal(t, "10158000000000000", user.Sub)
+	assert.Equal(t, "Taro Facebook", user.Name)
+
+	_, err = provider.VerifyLimitedLoginToken(context.Background(), rawIdToken, "AnotherNonce")
+	assert.ErrorIs(t, err, ErrNonceMismatch)
+}
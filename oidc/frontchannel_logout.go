@@ -0,0 +1,50 @@
+package oidc
+
+import (
+	"net/http"
+)
+
+// frontchannelLogoutHtml はIdPのiframeで読み込まれた際に返す最小限のHTML
+const frontchannelLogoutHtml = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Logged out</title></head><body></body></html>`
+
+// FrontchannelLogoutHandler はIdPのiframeから読み込まれるフロントチャネルログアウトのhttp.Handlerを返す
+//
+// クエリのissとsidのどちらかがある場合は両方を必須とし、issがIdPのものと一致するかを確認する。
+// 検証した後にclearを呼ぶので、clearではsidがローカルのセッションのものと一致する場合にセッションを破棄する。
+// sidがない場合は空文字で呼ばれる
+//
+// iframeで読み込まれる必要があるため、X-Frame-Optionsなどで埋め込みを禁止しないようにする
+//
+// refs: https://openid.net/specs/openid-connect-frontchannel-1_0.html#RPLogout
+func (c oidcClient) FrontchannelLogoutHandler(clear func(w http.ResponseWriter, r *http.Request, sid string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// IdPのログアウトのたびに必ず読み込まれるようにキャッシュさせない
+		w.Header().Set("Cache-Control", "no-cache, no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		query := r.URL.Query()
+		iss, sid := query.Get("iss"), query.Get("sid")
+		if (iss == "") != (sid == "") {
+			http.Error(w, errLogoutIssSidMissing.Error(), http.StatusBadRequest)
+
+			return
+		}
+		if iss != "" {
+			if err := c.Verifier().claims.validateIss(iss); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		if err := clear(w, r, sid); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(frontchannelLogoutHtml))
+	})
+}
@@ -0,0 +1,46 @@
+package oidc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOidcClient_FrontchannelLogoutHandler(t *testing.T) {
+	patterns := []struct {
+		desc           string
+		query          string
+		clearErr       error
+		expectedStatus int
+		expectedSid    string
+		expectedCalled bool
+	}{
+		{"valid", "?iss=https%3A%2F%2Faccounts.google.com&sid=DummySid", nil, http.StatusOK, "DummySid", true},
+		{"without iss and sid", "", nil, http.StatusOK, "", true},
+		{"iss mismatch", "?iss=https%3A%2F%2Fexample.com&sid=DummySid", nil, http.StatusBadRequest, "", false},
+		{"sid missing", "?iss=https%3A%2F%2Faccounts.google.com", nil, http.StatusBadRequest, "", false},
+		{"iss missing", "?sid=DummySid", nil, http.StatusBadRequest, "", false},
+		{"clear failed", "?iss=https%3A%2F%2Faccounts.google.com&sid=DummySid", errors.New("failed"), http.StatusInternalServerError, "DummySid", true},
+	}
+
+	client := NewGoogleOidcClient()
+	for _, pattern := range patterns {
+		called := false
+		h := client.FrontchannelLogoutHandler(func(w http.ResponseWriter, r *http.Request, sid string) error {
+			called = true
+			assert.Equal(t, pattern.expectedSid, sid, pattern.desc)
+
+			return pattern.clearErr
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/frontchannel_logout"+pattern.query, nil))
+
+		assert.Equal(t, pattern.expectedStatus, w.Code, pattern.desc)
+		assert.Equal(t, pattern.expectedCalled, called, pattern.desc)
+		assert.Equal(t, "no-cache, no-store", w.Header().Get("Cache-Control"), pattern.desc)
+		assert.Empty(t, w.Header().Get("X-Frame-Options"), pattern.desc)
+	}
+}
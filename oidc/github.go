@@ -0,0 +1,152 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// githubApiUrl はGitHubのREST APIのベースURL
+const githubApiUrl = "https://api.github.com"
+
+// githubProvider はGitHubのOAuthアプリのプロバイダ
+//
+// GitHubはOIDCに対応していないため、アクセストークンでREST APIからユーザーの情報を取得する
+type githubProvider struct {
+	*oidcClient
+	// ApiUrl はREST APIのベースURL。GitHub Enterprise Serverの場合は https://HOSTNAME/api/v3 にする
+	ApiUrl string
+}
+
+// githubTokenResponse はGitHubのトークンエンドポイントのレスポンス
+//
+// GitHubはエラーの場合もステータスコード200でerrorを返す
+type githubTokenResponse struct {
+	tokenResponse
+	OAuthError
+}
+
+// githubUser はGET /userのレスポンス
+type githubUser struct {
+	Id        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+// githubEmail はGET /user/emailsのレスポンスの要素
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// NewGitHubProvider はGitHubのプロバイダを返す
+//
+// メールアドレスを取得するためにuser:emailスコープを要求する
+//
+// refs: https://docs.github.com/en/apps/oauth-apps/building-oauth-apps/authorizing-oauth-apps
+func NewGitHubProvider(opts ...ClientOption) *githubProvider {
+	client := newOidcClient(
+		GitHub,
+		"",
+		os.Getenv("GITHUB_CLIENT_ID"),
+		clientSecret(os.Getenv("GITHUB_CLIENT_SECRET")),
+		"https://github.com/login/oauth/authorize",
+		"https://github.com/login/oauth/access_token",
+		"",
+		nil,
+	)
+	client.Scopes = []string{"read:user", "user:email"}
+	client.Apply(opts...)
+
+	return &githubProvider{oidcClient: client, ApiUrl: githubApiUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、REST APIからユーザーの情報を取得する
+//
+// nonceは使われない。メールアドレスは確認済みのプライマリアドレスのみをセットする
+func (p githubProvider) Login(ctx context.Context, code string, _ string, opts ...AuthCodeOption) (*User, error) {
+	token, err := p.exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	user := githubUser{}
+	if err := p.getJson(ctx, p.ApiUrl+"/user", token.AccessToken, &user); err != nil {
+		return nil, fmt.Errorf("failed to GET GitHub user: %w", err)
+	}
+	email, err := p.primaryEmail(ctx, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// nameは未設定の場合があるのでloginで補う
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	return &User{
+		IdProvider:    GitHub,
+		Sub:           strconv.FormatInt(user.Id, 10),
+		Email:         email,
+		EmailVerified: email != "",
+		Name:          name,
+		Username:      user.Login,
+		Picture:       user.AvatarUrl,
+		Token:         token,
+	}, nil
+}
+
+// exchange は認可コードをアクセストークンに交換する
+func (p githubProvider) exchange(ctx context.Context, code string, opts ...AuthCodeOption) (*Token, error) {
+	if err := p.checkCodeVerifier(opts); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Add("code", code)
+	values.Add("redirect_uri", p.RedirectUrl)
+	values.Add("grant_type", "authorization_code")
+	for _, opt := range opts {
+		opt(values)
+	}
+
+	tokenResp := githubTokenResponse{}
+	if err := p.postFormWithClientAuth(ctx, p.tokenEndpoint, values, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to POST token endpoint: %w", err)
+	}
+	if tokenResp.Code != "" {
+		oauthErr := tokenResp.OAuthError
+		oauthErr.StatusCode = http.StatusOK
+
+		return nil, fmt.Errorf("failed to POST token endpoint: %w", &oauthErr)
+	}
+
+	return newToken(tokenResp.tokenResponse), nil
+}
+
+// primaryEmail はGET /user/emailsから確認済みのプライマリアドレスを返す。ない場合は空文字を返す
+func (p githubProvider) primaryEmail(ctx context.Context, accessToken string) (string, error) {
+	emails := []githubEmail{}
+	if err := p.getJson(ctx, p.ApiUrl+"/user/emails", accessToken, &emails); err != nil {
+		if errors.Is(err, errUnexpectedStatus) {
+			// user:emailスコープが許可されていない場合はメールアドレスなしとして扱う
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to GET GitHub user emails: %w", err)
+	}
+
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			return email.Email, nil
+		}
+	}
+
+	return "", nil
+}
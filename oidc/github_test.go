@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var _ Provider = githubProvider{}
+
+func TestGitHubProvider_Login(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		name          string
+		emailsStatus  int
+		emails        string
+		expectedName  string
+		expectedEmail string
+	}{
+		{
+			"verified primary email",
+			"Taro GitHub",
+			http.StatusOK,
+			`[{"email": "other@example.com", "primary": false, "verified": true}, {"email": "user@example.com", "primary": true, "verified": true}]`,
+			"Taro GitHub",
+			"user@example.com",
+		},
+		{
+			"unverified primary email",
+			"Taro GitHub",
+			http.StatusOK,
+			`[{"email": "user@example.com", "primary": true, "verified": false}]`,
+			"Taro GitHub",
+			"",
+		},
+		{
+			"user:email not granted and name not set",
+			"",
+			http.StatusNotFound,
+			`{"message": "Not Found"}`,
+			"octocat",
+			"",
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		provider := NewGitHubProvider()
+		provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, provider.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "application/json", req.Header.Get("Accept"), pattern.desc)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken", "token_type": "bearer", "scope": "read:user,user:email"}`), nil
+		})
+		httpmock.RegisterResponder(http.MethodGet, githubApiUrl+"/user", httpmock.NewStringResponder(
+			http.StatusOK,
+			`{"id": 583231, "login": "octocat", "name": "`+pattern.name+`", "avatar_url": "https://avatars.githubusercontent.com/u/583231"}`,
+		))
+		httpmock.RegisterResponder(http.MethodGet, githubApiUrl+"/user/emails", httpmock.NewStringResponder(pattern.emailsStatus, pattern.emails))
+
+		user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, &User{
+			IdProvider:    GitHub,
+			Sub:           "583231",
+			Email:         pattern.expectedEmail,
+			EmailVerified: pattern.expectedEmail != "",
+			Name:          pattern.expectedName,
+			Username:      "octocat",
+			Picture:       "https://avatars.githubusercontent.com/u/583231",
+			Token:         user.Token,
+		}, user, pattern.desc)
+	}
+}
+
+func TestGitHubProvider_Login_OAuthError(t *testing.T) {
+	provider := NewGitHubProvider()
+	provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, provider.tokenEndpoint, httpmock.NewStringResponder(
+		http.StatusOK,
+		`{"error": "bad_verification_code", "error_description": "The code passed is incorrect or expired."}`,
+	))
+
+	_, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+	tokenErr := &OAuthError{}
+	if assert.ErrorAs(t, err, &tokenErr) {
+		assert.Equal(t, "bad_verification_code", tokenErr.Code)
+		assert.Equal(t, http.StatusOK, tokenErr.StatusCode)
+	}
+	assert.NotErrorIs(t, err, errUnexpectedStatus)
+}
@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+// GitLabUrl はgitlab.comのURL
+const GitLabUrl = "https://gitlab.com"
+
+// NewGitLabOidcClient はGitLabのクライアントを返す
+//
+// baseUrlにはGitLabUrlもしくはセルフマネージドのインスタンスのURLを渡す
+//
+// refs: https://docs.gitlab.com/ee/integration/openid_connect_provider.html
+func NewGitLabOidcClient(baseUrl string, opts ...ClientOption) *oidcClient {
+	issuer := strings.TrimSuffix(baseUrl, "/")
+	client := newOidcClient(
+		GitLab,
+		issuer,
+		os.Getenv("GITLAB_CLIENT_ID"),
+		clientSecret(os.Getenv("GITLAB_CLIENT_SECRET")),
+		issuer+"/oauth/authorize",
+		issuer+"/oauth/token",
+		issuer+"/oauth/discovery/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = issuer + "/oauth/userinfo"
+	client.IntrospectionEndpoint = issuer + "/oauth/introspect"
+	client.RevocationEndpoint = issuer + "/oauth/revoke"
+	client.Apply(opts...)
+
+	return client
+}
+
+// claimsDecoder はid_tokenやUserInfoのようにクレームをunmarshalできるもの
+type claimsDecoder interface {
+	Claims(v interface{}) error
+}
+
+// GitLabGroups はGitLabのid_tokenとUserInfoに含まれるグループのパス
+//
+// id_tokenには直接所属するグループのみが含まれ、UserInfoには親グループを含めたすべてのグループとロールごとのグループが含まれる
+type GitLabGroups struct {
+	// Direct はid_tokenのgroups_direct
+	Direct []string `json:"groups_direct"`
+	// All はUserInfoのgroups
+	All        []string `json:"groups"`
+	Owner      []string `json:"https://gitlab.org/claims/groups/owner"`
+	Maintainer []string `json:"https://gitlab.org/claims/groups/maintainer"`
+	Developer  []string `json:"https://gitlab.org/claims/groups/developer"`
+}
+
+// ParseGitLabGroups はid_tokenもしくはUserInfoからグループのクレームを取り出す
+func ParseGitLabGroups(claims claimsDecoder) (GitLabGroups, error) {
+	groups := GitLabGroups{}
+	if err := claims.Claims(&groups); err != nil {
+		return GitLabGroups{}, err
+	}
+
+	return groups, nil
+}
+
+// IsMember はgroupかそのサブグループに所属しているかどうかを返す
+func (groups GitLabGroups) IsMember(group string) bool {
+	for _, paths := range [][]string{groups.Direct, groups.All} {
+		for _, path := range paths {
+			if path == group || strings.HasPrefix(path, group+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewGitLabOidcClient(t *testing.T) {
+	patterns := []struct {
+		desc            string
+		baseUrl         string
+		expectedAuthUrl string
+		expectedJwksUrl string
+	}{
+		{"gitlab.com", GitLabUrl, "https://gitlab.com/oauth/authorize", "https://gitlab.com/oauth/discovery/keys"},
+		{"self-managed", "https://gitlab.example.com/", "https://gitlab.example.com/oauth/authorize", "https://gitlab.example.com/oauth/discovery/keys"},
+	}
+
+	for _, pattern := range patterns {
+		client := NewGitLabOidcClient(pattern.baseUrl)
+
+		assert.Equal(t, pattern.expectedAuthUrl, client.authEndpoint, pattern.desc)
+		assert.Equal(t, pattern.expectedJwksUrl, client.JwksEndpoint, pattern.desc)
+	}
+}
+
+func TestParseGitLabGroups(t *testing.T) {
+	payload := validGooglePayloadForTest()
+	payload["groups_direct"] = []string{"example/backend"}
+	token, err := NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256"}, payload, hmacSignerForTest("unused")), GitLab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &userInfo{rawClaims: []byte(`{
+  "sub": "1234567890",
+  "groups": ["example", "example/backend"],
+  "https://gitlab.org/claims/groups/owner": ["example/backend"]
+}`)}
+
+	direct, err := ParseGitLabGroups(token)
+	assert.Nil(t, err)
+	assert.Equal(t, GitLabGroups{Direct: []string{"example/backend"}}, direct)
+
+	all, err := ParseGitLabGroups(info)
+	assert.Nil(t, err)
+	assert.Equal(t, GitLabGroups{All: []string{"example", "example/backend"}, Owner: []string{"example/backend"}}, all)
+
+	patterns := []struct {
+		desc     string
+		groups   GitLabGroups
+		group    string
+		expected bool
+	}{
+		{"direct group", direct, "example/backend", true},
+		{"parent of direct group", direct, "example", true},
+		{"prefix but not parent", direct, "example/back", false},
+		{"userinfo group", all, "example", true},
+		{"not member", all, "another", false},
+	}
+
+	for _, pattern := range patterns {
+		assert.Equal(t, pattern.expected, pattern.groups.IsMember(pattern.group), pattern.desc)
+	}
+}
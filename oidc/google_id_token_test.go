@@ -53,11 +53,13 @@ func TestGoogleIdTokenPayload_IsValid(t *testing.T) {
 	}
 
 	for _, pattern := range patterns {
-		payload := googleIdTokenPayload{
+		payload := googleIdTokenPayload{IdTokenClaims{
 			Iss: pattern.iss,
-			Aud: pattern.aud,
+			Sub: "1234567890",
+			Aud: Audience{pattern.aud},
 			Exp: pattern.exp,
-		}
+			Iat: time.Now().Unix(),
+		}}
 
 		err := payload.validate(pattern.clientId)
 		actual := err == nil
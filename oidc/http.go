@@ -0,0 +1,270 @@
+package oidc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxResponseBytes はIdPからのレスポンスボディとして読み込む最大のサイズ
+	defaultMaxResponseBytes = 1 << 20
+	defaultConnectTimeout   = 5 * time.Second
+	defaultRequestTimeout   = 10 * time.Second
+	keepAliveInterval       = 30 * time.Second
+	// maxRedirects はリダイレクトを追う最大の回数。http.Clientの既定と同じ
+	maxRedirects = 10
+)
+
+// Timeouts はIdPへのリクエストのタイムアウト。0の項目はデフォルト値を使う
+type Timeouts struct {
+	// Connect はTCP接続の確立までのタイムアウト。HttpClientを指定した場合はそのTransportの設定が優先される
+	Connect time.Duration
+	// Jwks はJWKsエンドポイントへのリクエスト全体のタイムアウト
+	Jwks time.Duration
+	// Token はトークンエンドポイントへのリクエスト全体のタイムアウト
+	Token time.Duration
+	// UserInfo はUserInfoエンドポイントへのリクエスト全体のタイムアウト
+	UserInfo time.Duration
+	// Discovery はDiscoveryドキュメントの取得のリクエスト全体のタイムアウト
+	Discovery time.Duration
+}
+
+func (t Timeouts) connect() time.Duration {
+	return durationOrDefault(t.Connect, defaultConnectTimeout)
+}
+
+func (t Timeouts) jwks() time.Duration {
+	return durationOrDefault(t.Jwks, defaultRequestTimeout)
+}
+
+func (t Timeouts) token() time.Duration {
+	return durationOrDefault(t.Token, defaultRequestTimeout)
+}
+
+func (t Timeouts) userInfo() time.Duration {
+	return durationOrDefault(t.UserInfo, defaultRequestTimeout)
+}
+
+func (t Timeouts) discovery() time.Duration {
+	return durationOrDefault(t.Discovery, defaultRequestTimeout)
+}
+
+func durationOrDefault(d time.Duration, defaultValue time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultValue
+	}
+
+	return d
+}
+
+// httpConfig はIdPへのHTTPリクエストの設定
+type httpConfig struct {
+	// client はリクエストに使うクライアント。nilの場合はデフォルトのクライアントを使う
+	client   *http.Client
+	timeouts Timeouts
+	retry    RetryPolicy
+	// transport はclientがnilの場合に使うTransportの設定
+	transport TransportOptions
+	// maxResponseBytes はレスポンスボディとして読み込む最大のサイズ。0以下の場合はデフォルト値を使う
+	maxResponseBytes int64
+	logger           *slog.Logger
+	metrics          MetricsRecorder
+	tracer           Tracer
+	// debug はリクエストとレスポンスの内容をloggerに出力するかどうか
+	debug     bool
+	urlPolicy UrlPolicy
+	// beforeAttempt はリトライを含めて各リクエストを送る直前に呼ばれる。DPoP proofのように毎回作り直す必要があるヘッダを付ける
+	beforeAttempt func(req *http.Request) error
+}
+
+func (cfg httpConfig) log() *slog.Logger {
+	return loggerOrDiscard(cfg.logger)
+}
+
+func (cfg httpConfig) recorder() MetricsRecorder {
+	return metricsOrNop(cfg.metrics)
+}
+
+func (cfg httpConfig) httpClient() (*http.Client, error) {
+	if cfg.client != nil {
+		// 指定されたクライアントのTransportには接続時の確認を差し込めないので、確認せずに送らないようにする
+		if cfg.urlPolicy.BlockPrivateAddresses {
+			return nil, errUnenforceableUrlPolicy
+		}
+
+		return cfg.client, nil
+	}
+
+	transport, err := defaultTransport(transportConfig{
+		connectTimeout:        cfg.timeouts.connect(),
+		blockPrivateAddresses: cfg.urlPolicy.BlockPrivateAddresses,
+		TransportOptions:      cfg.transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport, CheckRedirect: cfg.checkRedirect}, nil
+}
+
+// checkRedirect はリダイレクト先のURLにもUrlPolicyを適用する
+//
+// 許可したURLから内部のアドレスやhttpのURLにリダイレクトされても、リクエストしないようにする
+func (cfg httpConfig) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := cfg.urlPolicy.check(req.Context(), req.URL.String()); err != nil {
+		return fmt.Errorf("failed to check redirect url: %w", err)
+	}
+
+	return nil
+}
+
+// TransportOptions はIdPへの通信経路の設定。HttpClientを指定した場合は使われない
+type TransportOptions struct {
+	// ProxyUrl は経由するプロキシのURL。空の場合は環境変数(HTTPS_PROXYなど)の設定に従う
+	//
+	// プロキシを経由する場合、UrlPolicyのBlockPrivateAddressesが接続時に確認するのはプロキシのアドレスになる
+	ProxyUrl string
+	// RootCAs はIdPのサーバー証明書の検証に使うルート証明書。nilの場合はシステムのルート証明書を使う
+	//
+	// 社内CAで署名されたKeycloakや、TLSを終端するプロキシを経由する場合に設定する
+	RootCAs *x509.CertPool
+	// MinTLSVersion は許可するTLSの最小バージョン(tls.VersionTLS12など)。0の場合はTLS1.2
+	MinTLSVersion uint16
+}
+
+// transportConfig はデフォルトのクライアントのTransportの設定
+type transportConfig struct {
+	connectTimeout        time.Duration
+	blockPrivateAddresses bool
+	TransportOptions
+}
+
+// defaultTransports はコネクションを使い回すために設定ごとに作ったTransportを保持する
+var defaultTransports sync.Map
+
+// defaultTransport はhttp.DefaultTransportを元に、設定を反映したTransportを返す
+//
+// http.DefaultTransportが差し替えられている場合(テストでのモックなど)はそれをそのまま使う。
+// その場合は接続時のアドレスを確認できないので、blockPrivateAddressesであればエラーを返す
+func defaultTransport(cfg transportConfig) (http.RoundTripper, error) {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		if cfg.blockPrivateAddresses {
+			return nil, errUnenforceableUrlPolicy
+		}
+
+		return http.DefaultTransport, nil
+	}
+
+	if transport, ok := defaultTransports.Load(cfg); ok {
+		if roundTripper, ok := transport.(http.RoundTripper); ok {
+			return roundTripper, nil
+		}
+	}
+
+	transport := base.Clone()
+	dialer := &net.Dialer{Timeout: cfg.connectTimeout, KeepAlive: keepAliveInterval}
+	if cfg.blockPrivateAddresses {
+		dialer.Control = blockPrivateAddress
+	}
+	transport.DialContext = dialer.DialContext
+	if cfg.ProxyUrl != "" {
+		proxyUrl, err := url.Parse(cfg.ProxyUrl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	minTLSVersion := cfg.MinTLSVersion
+	if minTLSVersion == 0 {
+		minTLSVersion = tls.VersionTLS12
+	}
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    cfg.RootCAs,
+		MinVersion: minTLSVersion,
+	}
+
+	actual, _ := defaultTransports.LoadOrStore(cfg, transport)
+	if roundTripper, ok := actual.(http.RoundTripper); ok {
+		return roundTripper, nil
+	}
+
+	return transport, nil
+}
+
+// send はリクエストを送り、レスポンスとボディを返す。ボディは読み込んだ後に閉じる
+//
+// ネットワークエラーやリトライ対象のステータスコードの場合はリトライポリシーに従って送り直す
+func (cfg httpConfig) send(req *http.Request) (*http.Response, []byte, error) {
+	policy := cfg.retry.withDefaults()
+	for attempt := 1; ; attempt++ {
+		if cfg.beforeAttempt != nil {
+			if err := cfg.beforeAttempt(req); err != nil {
+				return nil, nil, err
+			}
+		}
+		resp, body, err := cfg.sendOnce(req)
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(resp, err) || req.Context().Err() != nil {
+			return resp, body, err
+		}
+
+		if err := sleepWithContext(req.Context(), policy.backoff(attempt, resp)); err != nil {
+			return nil, nil, fmt.Errorf("failed to wait for retry: %w", err)
+		}
+		// ボディは送信時に読み込まれているので、送り直す前に作り直す
+		if req.GetBody != nil {
+			newBody, err := req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = newBody
+		}
+	}
+}
+
+func (cfg httpConfig) sendOnce(req *http.Request) (*http.Response, []byte, error) {
+	httpClient, err := cfg.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		cfg.dump(req, nil, nil, err)
+
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	// ボディは読み終えているので、閉じる際のエラーは結果に影響しない。プロセスを落とさないようにログに出すだけにする
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			cfg.log().WarnContext(req.Context(), "failed to close response body", slog.Any("error", err))
+		}
+	}(resp.Body)
+
+	// 不正なエンドポイントから巨大なレスポンスを返されてもメモリを使い切らないように、読み込むサイズを制限する
+	maxBytes := cfg.maxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxResponseBytes
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	cfg.dump(req, resp, body, nil)
+	if int64(len(body)) > maxBytes {
+		return nil, nil, fmt.Errorf("%w: exceeds %d bytes from %s", errResponseTooLarge, maxBytes, req.URL.Redacted())
+	}
+
+	return resp, body, nil
+}
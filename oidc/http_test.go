@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_HttpClient(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// httpmock.Activateせずに、クライアントに差し込んだTransportだけでリクエストが処理されることを確認する
+	transport := httpmock.NewMockTransport()
+	client := NewGoogleOidcClient()
+	client.HttpClient = &http.Client{Transport: transport}
+	client.JwksCache = nil
+
+	transport.RegisterResponder(http.MethodPost, client.tokenEndpoint,
+		httpmock.NewStringResponder(http.StatusOK, `{"id_token": "DummyIdToken"}`))
+	transport.RegisterResponder(http.MethodGet, client.JwksEndpoint,
+		httpmock.NewBytesResponder(http.StatusOK, rsaJwksForTest(t, &rsaKey.PublicKey, "key-1")))
+
+	tokenResp, err := client.PostTokenEndpoint(context.Background(), "", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyIdToken", tokenResp.IdToken)
+
+	keySet := newRemoteKeySet(client.JwksEndpoint, nil, nil, client.httpConfig())
+	_, err = keySet.Key(context.Background(), "key-1", "RS256")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, transport.GetTotalCallCount())
+}
+
+func TestTimeouts_Default(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		timeouts Timeouts
+		expected Timeouts
+	}{
+		{
+			"defaults",
+			Timeouts{},
+			Timeouts{
+				Connect:   5 * time.Second,
+				Jwks:      10 * time.Second,
+				Token:     10 * time.Second,
+				UserInfo:  10 * time.Second,
+				Discovery: 10 * time.Second,
+			},
+		},
+		{
+			"configured",
+			Timeouts{Connect: time.Second, Jwks: 2 * time.Second, Token: 3 * time.Second, UserInfo: 4 * time.Second, Discovery: time.Minute},
+			Timeouts{Connect: time.Second, Jwks: 2 * time.Second, Token: 3 * time.Second, UserInfo: 4 * time.Second, Discovery: time.Minute},
+		},
+	}
+
+	for _, pattern := range patterns {
+		actual := Timeouts{
+			Connect:   pattern.timeouts.connect(),
+			Jwks:      pattern.timeouts.jwks(),
+			Token:     pattern.timeouts.token(),
+			UserInfo:  pattern.timeouts.userInfo(),
+			Discovery: pattern.timeouts.discovery(),
+		}
+		assert.Equal(t, pattern.expected, actual, pattern.desc)
+	}
+}
+
+func TestOidcClient_Timeouts(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.Timeouts = Timeouts{Token: 10 * time.Millisecond}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	// タイムアウトするまでレスポンスを返さない
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+
+		return nil, req.Context().Err()
+	})
+
+	_, err := client.PostTokenEndpoint(context.Background(), "", "", "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHttpConfig_MaxResponseBytes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, httpmock.NewStringResponder(http.StatusOK, testJwksBody))
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		maxBytes      int64
+	}{
+		{"default limit", true, 0},
+		{"exact size", true, int64(len(testJwksBody))},
+		{"too large", false, int64(len(testJwksBody)) - 1},
+	}
+
+	for _, pattern := range patterns {
+		cfg := httpConfig{maxResponseBytes: pattern.maxBytes}
+		_, _, err := fetchJwks(context.Background(), cfg, testJwksUrl)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errResponseTooLarge, pattern.desc)
+		}
+	}
+}
+
+func TestHttpConfig_TransportOptions(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testJwksBody))
+	}))
+	defer tlsServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsServer.Certificate())
+
+	tls12Server := httptest.NewUnstartedServer(tlsServer.Config.Handler)
+	tls12Server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	tls12Server.StartTLS()
+	defer tls12Server.Close()
+	roots.AddCert(tls12Server.Certificate())
+
+	// 絶対URLでリクエストを受け付けるHTTPプロキシとして振る舞う
+	var proxiedUrl string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedUrl = r.URL.String()
+		_, _ = w.Write([]byte(testJwksBody))
+	}))
+	defer proxy.Close()
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		options       TransportOptions
+		url           string
+	}{
+		{"custom root CA", true, TransportOptions{RootCAs: roots}, tlsServer.URL},
+		{"unknown CA", false, TransportOptions{}, tlsServer.URL},
+		{"TLS1.2 allowed by default", true, TransportOptions{RootCAs: roots}, tls12Server.URL},
+		{"TLS1.2 below min version", false, TransportOptions{RootCAs: roots, MinTLSVersion: tls.VersionTLS13}, tls12Server.URL},
+		{"proxy", true, TransportOptions{ProxyUrl: proxy.URL}, "http://jwks.example.com/certs"},
+		{"invalid proxy url", false, TransportOptions{ProxyUrl: "://invalid"}, "http://jwks.example.com/certs"},
+	}
+
+	for _, pattern := range patterns {
+		// プロキシのテストではhttpのURLを使う
+		cfg := httpConfig{transport: pattern.options, retry: RetryPolicy{MaxAttempts: 1}, urlPolicy: UrlPolicy{AllowHttp: true}}
+		_, _, err := fetchJwks(context.Background(), cfg, pattern.url)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+	assert.Equal(t, "http://jwks.example.com/certs", proxiedUrl)
+}
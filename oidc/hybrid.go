@@ -0,0 +1,35 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExchangeHybrid はハイブリッドフロー(response_type=code id_token)の認可レスポンスを検証し、認可コードをトークンに交換する
+//
+// 認可エンドポイントから返されたid_tokenの署名、nonce、c_hashを検証してから認可コードを交換し、
+// トークンエンドポイントから返されたid_tokenと同じユーザーのものかをiss, subで確認する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#HybridIDToken2
+func (c oidcClient) ExchangeHybrid(ctx context.Context, resp *AuthResponse, nonce string, opts ...AuthCodeOption) (*Token, error) {
+	frontIdToken, err := c.verifyFrontChannelIdToken(ctx, resp.IdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if err := frontIdToken.VerifyCode(resp.Code); err != nil {
+		return nil, err
+	}
+
+	token, err := c.Exchange(ctx, resp.Code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	front := frontIdToken.StandardClaims()
+	back := token.IdToken.StandardClaims()
+	if front.Iss != back.Iss || front.Sub != back.Sub {
+		return nil, fmt.Errorf("%w: %s != %s", errSubMismatch, front.Sub, back.Sub)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestOidcClient_ExchangeHybrid(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	cHash, err := tokenHash("RS256", "DummyCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idTokenForTest := func(modify func(payload map[string]interface{})) string {
+		payload := validGooglePayloadForTest()
+		modify(payload)
+
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	}
+	frontIdToken := func(modify func(payload map[string]interface{})) string {
+		return idTokenForTest(func(p map[string]interface{}) {
+			p["nonce"] = "DummyNonce"
+			p["c_hash"] = cHash
+			modify(p)
+		})
+	}
+
+	patterns := []struct {
+		desc         string
+		resp         *AuthResponse
+		backIdToken  string
+		expected     error
+		expectedCall int
+	}{
+		{
+			"valid",
+			&AuthResponse{Code: "DummyCode", IdToken: frontIdToken(func(map[string]interface{}) {})},
+			idTokenForTest(func(map[string]interface{}) {}),
+			nil,
+			1,
+		},
+		{
+			"front-channel id_token missing",
+			&AuthResponse{Code: "DummyCode"},
+			idTokenForTest(func(map[string]interface{}) {}),
+			errAuthRespIdTokenMissing,
+			0,
+		},
+		{
+			"nonce mismatch",
+			&AuthResponse{Code: "DummyCode", IdToken: frontIdToken(func(p map[string]interface{}) { p["nonce"] = "AnotherNonce" })},
+			idTokenForTest(func(map[string]interface{}) {}),
+			ErrNonceMismatch,
+			0,
+		},
+		{
+			"c_hash missing",
+			&AuthResponse{Code: "DummyCode", IdToken: frontIdToken(func(p map[string]interface{}) { delete(p, "c_hash") })},
+			idTokenForTest(func(map[string]interface{}) {}),
+			errCHashMissing,
+			0,
+		},
+		{
+			"code replaced",
+			&AuthResponse{Code: "AnotherCode", IdToken: frontIdToken(func(map[string]interface{}) {})},
+			idTokenForTest(func(map[string]interface{}) {}),
+			errTokenHashMismatch,
+			0,
+		},
+		{
+			"sub mismatch",
+			&AuthResponse{Code: "DummyCode", IdToken: frontIdToken(func(map[string]interface{}) {})},
+			idTokenForTest(func(p map[string]interface{}) { p["sub"] = "0987654321" }),
+			errSubMismatch,
+			1,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken", "id_token": "`+pattern.backIdToken+`"}`,
+		))
+
+		token, err := client.ExchangeHybrid(context.Background(), pattern.resp, "DummyNonce", WithCodeVerifier("DummyCodeVerifier"))
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "DummyAccessToken", token.AccessToken, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+		assert.Equal(t, pattern.expectedCall, httpmock.GetTotalCallCount(), pattern.desc)
+	}
+}
@@ -1,7 +1,28 @@
 package oidc
 
+//go:generate stringer -type=IdProvider
 type IdProvider int
 
 const (
 	Google IdProvider = iota + 1
+	Apple
+	Line
+	YahooJapan
+	Microsoft
+	Facebook
+	X
+	GitHub
+	Slack
+	Discord
+	Cognito
+	Auth0
+	Okta
+	Keycloak
+	Salesforce
+	// GenericOidc はプリセットのないOIDCに準拠したIdP
+	GenericOidc
+	Twitch
+	GitLab
+	PayPal
+	Spotify
 )
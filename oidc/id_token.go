@@ -1,6 +1,7 @@
 package oidc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,11 +10,94 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
+// errors.Isで失敗の原因を判別できるエラー
+//
+// 例えばErrTokenExpiredの場合は再ログインさせるなど、呼び出し側で原因ごとに処理を分けるために使う
 var (
-	errIssMismatch    = errors.New("id_token issuer invalid")
-	errAudMismatch    = errors.New("id_token audience mismatch")
-	errIdTokenExpired = errors.New("id_token expired")
-	errJwkNotFound    = errors.New("key not found on JWKs endpoint")
+	ErrMalformedToken   = errors.New("malformed jwt")
+	ErrInvalidIssuer    = errors.New("id_token issuer invalid")
+	ErrInvalidAudience  = errors.New("id_token audience mismatch")
+	ErrTokenExpired     = errors.New("id_token expired")
+	ErrTokenNotYetValid = errors.New("id_token not yet valid")
+	ErrAuthTooOld       = errors.New("authentication is too old")
+	ErrNonceMismatch    = errors.New("nonce mismatch")
+	ErrStateMismatch    = errors.New("state mismatch")
+	ErrAlgNotAllowed    = errors.New("id_token signing algorithm is not allowed")
+	ErrJwkNotFound      = errors.New("key not found on JWKs endpoint")
+	ErrSignatureInvalid = errors.New("invalid signature")
+	ErrTokenReplayed    = errors.New("id_token has already been used")
+)
+
+var (
+	errIatMissing              = errors.New("id_token iat missing")
+	errIatInFuture             = errors.New("id_token issued in the future")
+	errEmailNotFound           = errors.New("email claim not found in id_token")
+	errNonceMissing            = errors.New("expected nonce is empty")
+	errStateMissing            = errors.New("state not found")
+	errTokenHashMismatch       = errors.New("token hash mismatch")
+	errCHashMissing            = errors.New("c_hash claim missing")
+	errAzpMissing              = errors.New("azp claim missing")
+	errAzpMismatch             = errors.New("azp mismatch")
+	errAuthTimeMissing         = errors.New("auth_time claim missing")
+	errAuthnPolicy             = errors.New("authentication policy not satisfied")
+	errIdTokenMissing          = errors.New("id_token not found in token response")
+	errAuthRespIdTokenMissing  = errors.New("id_token not found in authorization response")
+	errAtHashMissing           = errors.New("at_hash claim missing")
+	errSubMismatch             = errors.New("sub of front-channel and back-channel id_token mismatch")
+	errUserInfoEndpointMissing = errors.New("userinfo endpoint is not configured")
+	errUserInfoSubMismatch     = errors.New("sub of userinfo response does not match id_token")
+	errIntrospectionMissing    = errors.New("introspection endpoint is not configured")
+	errRevocationMissing       = errors.New("revocation endpoint is not configured")
+	errEndSessionMissing       = errors.New("end session endpoint is not configured")
+	errLogoutTokenMissing      = errors.New("logout_token not found in request")
+	errLogoutEventMissing      = errors.New("backchannel-logout event not found in logout token")
+	errLogoutNonceFound        = errors.New("logout token must not contain nonce")
+	errLogoutSubSidMissing     = errors.New("logout token must contain sub or sid")
+	errLogoutIssSidMissing     = errors.New("front-channel logout requires both iss and sid")
+	errCheckSessionMissing     = errors.New("check_session_iframe is not configured")
+	errInvalidAppleKey         = errors.New("invalid private key of Sign in with Apple")
+	errCodeVerifierMissing     = errors.New("code_verifier is required")
+	errSlackTeamNotAllowed     = errors.New("slack workspace is not allowed")
+	errDiscordGuildNotJoined   = errors.New("user is not a member of the required discord guild")
+	errInvalidTokenUse         = errors.New("token_use is not id")
+	errInvalidIdentityUrl      = errors.New("invalid salesforce identity url")
+	errProviderNotFound        = errors.New("provider is not registered")
+	errProviderDuplicated      = errors.New("provider is already registered")
+	errClientConfigMissing     = errors.New("client configuration is missing")
+	errRefreshTokenMissing     = errors.New("refresh token is empty")
+	errDeviceEndpointMissing   = errors.New("device authorization endpoint is not configured")
+	errSubjectTokenMissing     = errors.New("subject_token and subject_token_type are required")
+	errCibaEndpointMissing     = errors.New("backchannel authentication endpoint is not configured")
+	errParEndpointMissing      = errors.New("pushed authorization request endpoint is not configured")
+	errNoRequestObjectSigner   = errors.New("request object signer is not configured")
+	errRequestUriMissing       = errors.New("request_uri not found in pushed authorization response")
+	errLoginHintMissing        = errors.New("one of login_hint, login_hint_token and id_token_hint is required")
+	errInvalidExponent         = errors.New("invalid RSA exponent in JWK")
+	errInvalidEcPoint          = errors.New("EC public key is not on the curve")
+	errUnsupportedKeyType      = errors.New("unsupported JWK key type")
+	errUnsupportedCurve        = errors.New("unsupported JWK curve")
+	errUnsupportedAlg          = errors.New("unsupported signing algorithm")
+	errKeyAlgMismatch          = errors.New("JWK does not match signing algorithm")
+	errX5cRequired             = errors.New("x5c certificate chain is required in JWK")
+	errX5cKeyMismatch          = errors.New("JWK key parameters do not match x5c certificate")
+	errResponseTooLarge        = errors.New("response body too large")
+	errDiscoveryIssuerMismatch = errors.New("issuer in discovery document does not match")
+	errDiscoveryMissingField   = errors.New("required field missing in discovery document")
+	errUnexpectedStatus        = errors.New("unexpected response status")
+	errAlgNone                 = errors.New("unsigned id_token (alg=none) is not allowed")
+	errHmacNotAllowed          = errors.New("HMAC signed id_token is not allowed")
+	errEmptyHmacSecret         = errors.New("client secret is required to verify HMAC signature")
+	errSubMissing              = errors.New("id_token sub missing")
+	errInsecureUrl             = errors.New("url must use https")
+	errPrivateAddress          = errors.New("url resolves to a private address")
+	errUnenforceableUrlPolicy  = errors.New("BlockPrivateAddresses requires the default transport")
+	errJtiMissing              = errors.New("id_token jti missing")
+	errReplayCacheFull         = errors.New("replay cache is full")
+	errDpopJktMissing          = errors.New("cnf.jkt claim missing")
+	errDpopJktMismatch         = errors.New("token is bound to another DPoP key")
+	errDpopTokenType           = errors.New("token_type is not DPoP")
+	errPreviousIdTokenMissing  = errors.New("original id_token is required to verify refreshed id_token")
+	errRefreshedTokenMismatch  = errors.New("refreshed id_token does not match the original id_token")
 )
 
 type idToken struct {
@@ -27,15 +111,18 @@ type idToken struct {
 }
 
 type idTokenPayload interface {
-	validateIss() error
-	validateAud(clientId string) error
-	validateExp() error
-	validate(clientId string) error
+	// standardClaims は検証に使うOIDC Coreで定義されたクレームを返す
+	standardClaims() IdTokenClaims
 	GetSub() string
 	// GetEmail はGoogleでのみ動作する
 	GetEmail() (string, error)
 }
 
+// validatingIdTokenPayload はOIDC Coreの検証に加えてIdP固有のクレームの検証を持つid_tokenのpayload
+type validatingIdTokenPayload interface {
+	validate() error
+}
+
 type header struct {
 	Alg string `json:"alg"`
 	Kid string `json:"kid"`
@@ -47,7 +134,7 @@ func NewIdToken(rawToken string, provider IdProvider) (*idToken, error) {
 	const jwtSegNum = 3
 	segments := strings.Split(rawToken, ".")
 	if len(segments) != jwtSegNum {
-		return nil, errors.New("invalid jwt")
+		return nil, fmt.Errorf("%w: expected %d segments", ErrMalformedToken, jwtSegNum)
 	}
 	idToken := &idToken{
 		IdProvider:   provider,
@@ -59,11 +146,11 @@ func NewIdToken(rawToken string, provider IdProvider) (*idToken, error) {
 
 	byteHeader, err := jwt.DecodeSegment(idToken.rawHeader)
 	if err != nil {
-		return nil, errors.New("base64 decode header error")
+		return nil, fmt.Errorf("%w: failed to decode header: %v", ErrMalformedToken, err)
 	}
 	header := &header{}
 	if err := json.Unmarshal(byteHeader, header); err != nil {
-		return nil, errors.New("json decode header error")
+		return nil, fmt.Errorf("%w: failed to unmarshal header: %v", ErrMalformedToken, err)
 	}
 	idToken.header = *header
 
@@ -75,34 +162,70 @@ func NewIdToken(rawToken string, provider IdProvider) (*idToken, error) {
 	return idToken, nil
 }
 
-// setPayload は生のpayloadを構造体に焼き直してセットする
-func (token *idToken) setPayload() error {
-	if token.IdProvider == Google {
-		bytePayload, err := jwt.DecodeSegment(token.RawPayload)
-		if err != nil {
-			return fmt.Errorf("failed to decode payload JWT segment: %w", err)
-		}
-		payload := &googleIdTokenPayload{}
-		if err := json.Unmarshal(bytePayload, payload); err != nil {
-			return fmt.Errorf("failed to unmarshal id_token payload: %w", err)
-		}
-		token.Payload = payload
-
-		return nil
+// Claims はid_tokenのpayloadをvにunmarshalする
+//
+// IdP固有のクレームなど、IdTokenClaimsに含まれないクレームを取り出したい場合に使う
+func (token *idToken) Claims(v interface{}) error {
+	bytePayload, err := jwt.DecodeSegment(token.RawPayload)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode payload: %v", ErrMalformedToken, err)
+	}
+	if err := json.Unmarshal(bytePayload, v); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal payload: %v", ErrMalformedToken, err)
 	}
 
-	return errIssMismatch
+	return nil
+}
+
+// StandardClaims はid_tokenのpayloadのうちOIDC Coreで定義されているクレームを返す
+func (token *idToken) StandardClaims() IdTokenClaims {
+	return token.Payload.standardClaims()
 }
 
-// Validate はJWTの署名とpayloadの中身を検証する
+// Raw は検証前の生のid_tokenを返す
+//
+// セッションに保存したトークンをNewIdTokenで復元する場合や、id_token_hintに使う場合に使う
+func (token *idToken) Raw() string {
+	return token.rawToken
+}
+
+// Validate はjwksUrlの公開鍵でJWTの署名を検証し、clientId向けのGoogleのid_tokenとしてpayloadの中身を検証する
+//
+// Deprecated: oidcClient.Verifier().Verifyを使う。Verifyは鍵の取得にcontextを渡せ、Google以外のIdPにも対応している
 func (token idToken) Validate(jwksUrl string, clientId string) error {
-	if err := token.validateSignature(jwksUrl); err != nil {
-		return err
-	}
+	client := newOidcClient(token.IdProvider, "", clientId, "", "", "", jwksUrl, []string{"RS256"})
+
+	return client.Verifier().Verify(context.Background(), &token)
+}
 
-	if err := token.Payload.validate(clientId); err != nil {
-		return fmt.Errorf("failed to validate id_token payload: %w", err)
+// setPayload は生のpayloadを構造体に焼き直してセットする
+//
+// IdP固有の構造体がない場合はOIDC Coreで定義されたクレームとして扱う
+func (token *idToken) setPayload() error {
+	var payload idTokenPayload
+	switch token.IdProvider {
+	case Google:
+		payload = &googleIdTokenPayload{}
+	case Apple:
+		payload = &appleIdTokenPayload{}
+	case Microsoft:
+		payload = &microsoftIdTokenPayload{}
+	case Slack:
+		payload = &slackIdTokenPayload{}
+	case Cognito:
+		payload = &cognitoIdTokenPayload{}
+	default:
+		payload = &IdTokenClaims{}
+	}
+	if err := token.Claims(payload); err != nil {
+		return err
 	}
+	token.Payload = payload
 
 	return nil
 }
+
+// signingInput は署名対象となるheaderとpayloadを"."で繋いだ文字列を返す
+func (token idToken) signingInput() string {
+	return fmt.Sprintf("%s.%s", token.rawHeader, token.RawPayload)
+}
@@ -1,10 +1,16 @@
 package oidc
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/base64"
+	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewIdToken(t *testing.T) {
@@ -33,6 +39,20 @@ func TestNewIdToken(t *testing.T) {
 			"{}",
 			"{}",
 		},
+		{
+			"invalid payload",
+			false,
+			`{"alg": "RS256"}`,
+			"invalid payload",
+			"{}",
+		},
+		{
+			"invalid claim type",
+			false,
+			`{"alg": "RS256"}`,
+			`{"exp": "tomorrow"}`,
+			"{}",
+		},
 	}
 
 	for _, pattern := range patterns {
@@ -47,7 +67,90 @@ func TestNewIdToken(t *testing.T) {
 		if pattern.isExpectValid {
 			assert.Nil(t, err)
 		} else {
-			assert.Error(t, err)
+			assert.ErrorIs(t, err, ErrMalformedToken)
+		}
+	}
+
+	_, err := NewIdToken("header.payload", Google)
+	assert.ErrorIs(t, err, ErrMalformedToken)
+}
+
+func TestIdToken_Claims(t *testing.T) {
+	rawToken := encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "typ": "JWT"},
+		map[string]interface{}{
+			"iss":            "https://example.com",
+			"sub":            "1234567890",
+			"aud":            []string{"client-1", "client-2"},
+			"email":          "user@example.com",
+			"email_verified": true,
+			"name":           "Taro Yamada",
+			"picture":        "https://example.com/taro.png",
+			"locale":         "ja-JP",
+			"hd":             "example.com",
+		},
+		func(string) []byte { return []byte("signature") },
+	)
+	token, err := NewIdToken(rawToken, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standard := token.StandardClaims()
+	assert.Equal(t, "1234567890", standard.Sub)
+	assert.Equal(t, Audience{"client-1", "client-2"}, standard.Aud)
+	assert.True(t, standard.EmailVerified)
+	assert.Equal(t, "Taro Yamada", standard.Name)
+	assert.Equal(t, "https://example.com/taro.png", standard.Picture)
+	assert.Equal(t, "ja-JP", standard.Locale)
+
+	// IdP固有のクレームを独自の構造体で取り出せる
+	var custom struct {
+		Sub          string `json:"sub"`
+		HostedDomain string `json:"hd"`
+	}
+	assert.Nil(t, token.Claims(&custom))
+	assert.Equal(t, "1234567890", custom.Sub)
+	assert.Equal(t, "example.com", custom.HostedDomain)
+}
+
+func TestIdToken_Validate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const jwksUrl = "https://example.com/validate/jwks"
+	expiredPayload := validGooglePayloadForTest()
+	expiredPayload["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	patterns := []struct {
+		desc        string
+		payload     map[string]interface{}
+		clientId    string
+		expectedErr error
+	}{
+		{"valid", validGooglePayloadForTest(), os.Getenv("GOOGLE_CLIENT_ID"), nil},
+		{"expired", expiredPayload, os.Getenv("GOOGLE_CLIENT_ID"), ErrTokenExpired},
+		{"another client", validGooglePayloadForTest(), "another-client", ErrInvalidAudience},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, jwksUrl, httpmock.NewBytesResponder(http.StatusOK, rsaJwksForTest(t, &rsaKey.PublicKey, "key-1")))
+
+	for _, pattern := range patterns {
+		rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, pattern.payload, rsaSignerForTest(rsaKey))
+		token, err := NewIdToken(rawToken, Google)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = token.Validate(jwksUrl, pattern.clientId)
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
 		}
 	}
 }
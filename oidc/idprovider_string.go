@@ -0,0 +1,43 @@
+// Code generated by "stringer -type=IdProvider"; DO NOT EDIT.
+
+package oidc
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Google-1]
+	_ = x[Apple-2]
+	_ = x[Line-3]
+	_ = x[YahooJapan-4]
+	_ = x[Microsoft-5]
+	_ = x[Facebook-6]
+	_ = x[X-7]
+	_ = x[GitHub-8]
+	_ = x[Slack-9]
+	_ = x[Discord-10]
+	_ = x[Cognito-11]
+	_ = x[Auth0-12]
+	_ = x[Okta-13]
+	_ = x[Keycloak-14]
+	_ = x[Salesforce-15]
+	_ = x[GenericOidc-16]
+	_ = x[Twitch-17]
+	_ = x[GitLab-18]
+	_ = x[PayPal-19]
+	_ = x[Spotify-20]
+}
+
+const _IdProvider_name = "GoogleAppleLineYahooJapanMicrosoftFacebookXGitHubSlackDiscordCognitoAuth0OktaKeycloakSalesforceGenericOidcTwitchGitLabPayPalSpotify"
+
+var _IdProvider_index = [...]uint8{0, 6, 11, 15, 25, 34, 42, 43, 49, 54, 61, 68, 73, 77, 85, 95, 106, 112, 118, 124, 131}
+
+func (i IdProvider) String() string {
+	i -= 1
+	if i < 0 || i >= IdProvider(len(_IdProvider_index)-1) {
+		return "IdProvider(" + strconv.FormatInt(int64(i+1), 10) + ")"
+	}
+	return _IdProvider_name[_IdProvider_index[i]:_IdProvider_index[i+1]]
+}
@@ -0,0 +1,51 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+)
+
+// VerifyImplicitResponse はインプリシットフローで認可エンドポイントから直接返されたid_tokenを検証する
+//
+// コード交換を行わないレガシーなSPAやGoogle One Tapなどで使う。
+// id_tokenがリダイレクトで直接返されるためリプレイ攻撃を防ぐ手段がnonceしかなく、nonceの検証は必須となる。
+// response_typeが"id_token token"でアクセストークンも返される場合はat_hashも必須となる
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#ImplicitIDTValidation
+func (c oidcClient) VerifyImplicitResponse(ctx context.Context, resp *AuthResponse, nonce string) (*idToken, error) {
+	idToken, err := c.verifyFrontChannelIdToken(ctx, resp.IdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.AccessToken != "" {
+		if idToken.Payload.standardClaims().AtHash == "" {
+			return nil, errAtHashMissing
+		}
+		if err := idToken.VerifyAccessToken(resp.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+
+	return idToken, nil
+}
+
+// verifyFrontChannelIdToken は認可エンドポイントから返されたid_tokenの署名とクレームを検証し、nonceを確認する
+func (c oidcClient) verifyFrontChannelIdToken(ctx context.Context, rawIdToken string, nonce string) (*idToken, error) {
+	if rawIdToken == "" {
+		return nil, errAuthRespIdTokenMissing
+	}
+
+	idToken, err := NewIdToken(rawIdToken, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse id_token: %w", err)
+	}
+	if err := c.Verifier().Verify(ctx, idToken); err != nil {
+		return nil, err
+	}
+	if err := idToken.VerifyNonce(nonce); err != nil {
+		return nil, err
+	}
+
+	return idToken, nil
+}
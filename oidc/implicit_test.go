@@ -0,0 +1,98 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_VerifyImplicitResponse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	atHash, err := tokenHash("RS256", "DummyAccessToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idTokenForTest := func(modify func(payload map[string]interface{})) string {
+		payload := validGooglePayloadForTest()
+		payload["nonce"] = "DummyNonce"
+		modify(payload)
+
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	}
+
+	patterns := []struct {
+		desc     string
+		resp     *AuthResponse
+		nonce    string
+		expected error
+	}{
+		{
+			"valid",
+			&AuthResponse{IdToken: idTokenForTest(func(map[string]interface{}) {})},
+			"DummyNonce",
+			nil,
+		},
+		{
+			"valid with access token",
+			&AuthResponse{IdToken: idTokenForTest(func(p map[string]interface{}) { p["at_hash"] = atHash }), AccessToken: "DummyAccessToken"},
+			"DummyNonce",
+			nil,
+		},
+		{
+			"id_token missing",
+			&AuthResponse{},
+			"DummyNonce",
+			errAuthRespIdTokenMissing,
+		},
+		{
+			"expired",
+			&AuthResponse{IdToken: idTokenForTest(func(p map[string]interface{}) { p["exp"] = time.Now().Add(-time.Hour).Unix() })},
+			"DummyNonce",
+			ErrTokenExpired,
+		},
+		{
+			"nonce not saved",
+			&AuthResponse{IdToken: idTokenForTest(func(map[string]interface{}) {})},
+			"",
+			errNonceMissing,
+		},
+		{
+			"nonce claim missing",
+			&AuthResponse{IdToken: idTokenForTest(func(p map[string]interface{}) { delete(p, "nonce") })},
+			"DummyNonce",
+			ErrNonceMismatch,
+		},
+		{
+			"at_hash missing",
+			&AuthResponse{IdToken: idTokenForTest(func(map[string]interface{}) {}), AccessToken: "DummyAccessToken"},
+			"DummyNonce",
+			errAtHashMissing,
+		},
+		{
+			"at_hash mismatch",
+			&AuthResponse{IdToken: idTokenForTest(func(p map[string]interface{}) { p["at_hash"] = atHash }), AccessToken: "AnotherAccessToken"},
+			"DummyNonce",
+			errTokenHashMismatch,
+		},
+	}
+
+	for _, pattern := range patterns {
+		token, err := client.VerifyImplicitResponse(context.Background(), pattern.resp, pattern.nonce)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "1234567890", token.Payload.GetSub(), pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
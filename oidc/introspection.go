@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Introspection はトークンイントロスペクションエンドポイントのレスポンス
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7662#section-2.2
+type Introspection struct {
+	// Active はトークンが有効かどうか。falseの場合はそれ以外の項目は返されない
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope"`
+	ClientId  string   `json:"client_id"`
+	Username  string   `json:"username"`
+	TokenType string   `json:"token_type"`
+	Exp       int64    `json:"exp"`
+	Iat       int64    `json:"iat"`
+	Nbf       int64    `json:"nbf"`
+	Sub       string   `json:"sub"`
+	Aud       Audience `json:"aud"`
+	Iss       string   `json:"iss"`
+	Jti       string   `json:"jti"`
+	// Cnf はトークンが紐付けられた鍵。DPoPで発行されたトークンの場合に含まれる
+	Cnf Confirmation `json:"cnf"`
+}
+
+// Introspect はトークンイントロスペクションエンドポイントに問い合わせ、不透明なアクセストークンが有効かどうかを確認する
+//
+// リソースサーバーがIdPの発行したアクセストークンを検証するために使う。tokenTypeHintは"access_token"などで、空の場合は送らない。
+// IntrospectionCacheが設定されている場合は、トークンのハッシュをキーにして結果をキャッシュする
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7662
+func (c oidcClient) Introspect(ctx context.Context, token string, tokenTypeHint string) (*Introspection, error) {
+	if c.IntrospectionEndpoint == "" {
+		return nil, errIntrospectionMissing
+	}
+
+	if introspection, ok := c.IntrospectionCache.get(token); ok {
+		return introspection, nil
+	}
+
+	values := url.Values{}
+	values.Set("token", token)
+	if tokenTypeHint != "" {
+		values.Set("token_type_hint", tokenTypeHint)
+	}
+	introspection := &Introspection{}
+	if err := c.postFormWithClientAuth(ctx, c.IntrospectionEndpoint, values, introspection); err != nil {
+		return nil, fmt.Errorf("failed to POST introspection endpoint: %w", err)
+	}
+	c.IntrospectionCache.set(token, introspection)
+
+	return introspection, nil
+}
+
+// expiresAt はexpクレームの時刻を返す。expが含まれない場合はゼロ値
+func (i Introspection) expiresAt() time.Time {
+	if i.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(i.Exp, 0)
+}
@@ -0,0 +1,98 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+type introspectionCacheEntry struct {
+	introspection *Introspection
+	expiresAt     time.Time
+}
+
+// introspectionCache はトークンイントロスペクションの結果を保持する
+//
+// アクセストークンそのものをメモリに残さないように、キーにはトークンのSHA-256ハッシュを使う
+type introspectionCache struct {
+	mu      sync.RWMutex
+	entries map[[sha256.Size]byte]introspectionCacheEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewIntrospectionCache はイントロスペクションの結果のキャッシュを返す
+//
+// ttlはキャッシュする期間の最大値で、有効なトークンの場合もexpを過ぎてキャッシュすることはない。
+// キャッシュしている間はIdP側でトークンを失効させても有効と判定されるため、短めの期間にする
+func NewIntrospectionCache(ttl time.Duration) *introspectionCache {
+	return &introspectionCache{
+		entries: map[[sha256.Size]byte]introspectionCacheEntry{},
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// Purge はキャッシュをすべて破棄する
+func (c *introspectionCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[[sha256.Size]byte]introspectionCacheEntry{}
+}
+
+// get はキャッシュが有効であれば結果を返す。cがnilの場合は常にfalseを返す
+func (c *introspectionCache) get(token string) (*Introspection, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[sha256.Sum256([]byte(token))]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.introspection, true
+}
+
+// set は結果をキャッシュする。cがnilの場合は何もしない
+func (c *introspectionCache) set(token string, introspection *Introspection) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// 期限切れのエントリが溜まり続けないように、書き込みのたびに取り除く
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	expiresAt := now.Add(c.ttl)
+	if exp := introspection.expiresAt(); introspection.Active && !exp.IsZero() && exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+	c.entries[sha256.Sum256([]byte(token))] = introspectionCacheEntry{introspection: introspection, expiresAt: expiresAt}
+}
+
+// delete はtokenの結果をキャッシュから取り除く。cがnilの場合は何もしない
+func (c *introspectionCache) delete(token string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, sha256.Sum256([]byte(token)))
+}
@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testIntrospectionUrl = "https://example.com/introspect"
+
+func TestOidcClient_Introspect(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.IntrospectionEndpoint = testIntrospectionUrl
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		status        int
+		body          string
+		expected      *Introspection
+	}{
+		{
+			"active",
+			true,
+			http.StatusOK,
+			`{"active": true, "scope": "read write", "client_id": "client-1", "sub": "1234567890", "aud": "https://api.example.com", "exp": 1419356238}`,
+			&Introspection{Active: true, Scope: "read write", ClientId: "client-1", Sub: "1234567890", Aud: Audience{"https://api.example.com"}, Exp: 1419356238},
+		},
+		{
+			"inactive",
+			true,
+			http.StatusOK,
+			`{"active": false}`,
+			&Introspection{},
+		},
+		{
+			"invalid client",
+			false,
+			http.StatusUnauthorized,
+			`{"error": "invalid_client"}`,
+			nil,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		pattern := pattern
+		httpmock.RegisterResponder(http.MethodPost, testIntrospectionUrl, func(req *http.Request) (*http.Response, error) {
+			assert.Nil(t, req.ParseForm(), pattern.desc)
+			assert.Equal(t, "DummyAccessToken", req.PostForm.Get("token"), pattern.desc)
+			assert.Equal(t, "access_token", req.PostForm.Get("token_type_hint"), pattern.desc)
+			assert.Equal(t, client.ClientId, req.PostForm.Get("client_id"), pattern.desc)
+
+			return httpmock.NewStringResponse(pattern.status, pattern.body), nil
+		})
+
+		actual, err := client.Introspect(context.Background(), "DummyAccessToken", "access_token")
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, actual, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errUnexpectedStatus, pattern.desc)
+		}
+	}
+
+	client.IntrospectionEndpoint = ""
+	_, err := client.Introspect(context.Background(), "DummyAccessToken", "")
+	assert.ErrorIs(t, err, errIntrospectionMissing)
+}
+
+func TestOidcClient_Introspect_Cache(t *testing.T) {
+	now := time.Now()
+	cache := NewIntrospectionCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	client := NewGoogleOidcClient()
+	client.IntrospectionEndpoint = testIntrospectionUrl
+	client.IntrospectionCache = cache
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, testIntrospectionUrl, func(req *http.Request) (*http.Response, error) {
+		if req.FormValue("token") == "ShortLivedToken" {
+			return httpmock.NewJsonResponse(http.StatusOK, Introspection{Active: true, Exp: now.Add(10 * time.Second).Unix()})
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"active": true}`), nil
+	})
+
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	// トークンごとにキャッシュされる
+	_, _ = client.Introspect(context.Background(), "ShortLivedToken", "")
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// expを過ぎた場合はttl内でも問い合わせ直す
+	now = now.Add(30 * time.Second)
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+	_, _ = client.Introspect(context.Background(), "ShortLivedToken", "")
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+	now = now.Add(30 * time.Second)
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+	assert.Equal(t, 4, httpmock.GetTotalCallCount())
+}
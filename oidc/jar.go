@@ -0,0 +1,110 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestObjectTtl はリクエストオブジェクトの有効期間
+const requestObjectTtl = 5 * time.Minute
+
+// RequestObjectSigner はリクエストオブジェクトに署名する秘密鍵
+type RequestObjectSigner struct {
+	// Alg は署名アルゴリズム。RS256, PS256, ES256, EdDSAなど
+	Alg string
+	// Kid はIdPに登録したJWKsで公開鍵を識別するためのkid
+	Kid string
+	Key crypto.Signer
+}
+
+// RequestObjectEncrypter はリクエストオブジェクトを暗号化するIdPの公開鍵
+//
+// RSA-OAEP-256とA256GCMで暗号化する
+type RequestObjectEncrypter struct {
+	Kid string
+	Key *rsa.PublicKey
+}
+
+// RequestObject は認可リクエストのパラメータを署名したJWT(リクエストオブジェクト)にして返す
+//
+// 暗号化の鍵としてRequestObjectEncrypterが設定されている場合は、署名したJWTを更に暗号化する
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc9101
+func (c oidcClient) RequestObject(
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts ...AuthCodeOption,
+) (string, error) {
+	if c.RequestObjectSigner == nil {
+		return "", errNoRequestObjectSigner
+	}
+
+	jti, err := randomToken(nonceBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate jti: %w", err)
+	}
+	now := time.Now()
+	claims := map[string]interface{}{}
+	for key, values := range c.authRequestExtras(nonce, opts) {
+		claims[key] = values[0]
+	}
+	claims["response_type"] = respType
+	claims["client_id"] = c.ClientId
+	claims["scope"] = strings.Join(scopes, " ")
+	claims["redirect_uri"] = redirectUrl
+	claims["state"] = state
+	claims["iss"] = c.ClientId
+	claims["aud"] = c.Issuer
+	claims["jti"] = jti
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = now.Add(requestObjectTtl).Unix()
+
+	signer := c.RequestObjectSigner
+	requestObject, err := signJwt(signer.Alg, signer.Kid, signer.Key, claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request object: %w", err)
+	}
+	if c.RequestObjectEncrypter == nil {
+		return requestObject, nil
+	}
+
+	encrypted, err := encryptJwe(requestObject, c.RequestObjectEncrypter.Key, c.RequestObjectEncrypter.Kid)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt request object: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+// AuthUrlWithRequestObject はリクエストオブジェクトをrequestパラメータに入れた認可エンドポイントのURLを返す
+//
+// OIDCではリクエストオブジェクトを使う場合でもresponse_typeとscopeをクエリに含める必要がある
+func (c oidcClient) AuthUrlWithRequestObject(
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts ...AuthCodeOption,
+) (string, error) {
+	requestObject, err := c.RequestObject(respType, scopes, redirectUrl, state, nonce, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Set("client_id", c.ClientId)
+	values.Set("response_type", respType)
+	values.Set("scope", strings.Join(scopes, " "))
+	values.Set("request", requestObject)
+
+	return c.authEndpoint + "?" + values.Encode(), nil
+}
@@ -0,0 +1,62 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestOidcClient_RequestObject(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptionKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewGoogleOidcClient()
+	client.ClientId = "client-1"
+	client.RequestObjectSigner = &RequestObjectSigner{Alg: "PS256", Kid: "key-1", Key: signingKey}
+
+	authUrl, err := client.AuthUrlWithRequestObject("code", []string{"openid"}, "http://localhost:8000/callback", "12345678", "n-0S6_WzA2Mj")
+	assert.Nil(t, err)
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "client-1", parsed.Query().Get("client_id"))
+	assert.Equal(t, "openid", parsed.Query().Get("scope"))
+
+	token, err := NewIdToken(parsed.Query().Get("request"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, token.validateSignature(&signingKey.PublicKey))
+	claims := map[string]interface{}{}
+	assert.Nil(t, token.Claims(&claims))
+	assert.Equal(t, "client-1", claims["iss"])
+	assert.Equal(t, "https://accounts.google.com", claims["aud"])
+	assert.Equal(t, "http://localhost:8000/callback", claims["redirect_uri"])
+	assert.Equal(t, "12345678", claims["state"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.NotEmpty(t, claims["jti"])
+
+	// 暗号化した場合は復号すると署名済みのリクエストオブジェクトになる
+	client.RequestObjectEncrypter = &RequestObjectEncrypter{Kid: "enc-key-1", Key: &encryptionKey.PublicKey}
+	encrypted, err := client.RequestObject("code", []string{"openid"}, "", "12345678", "")
+	assert.Nil(t, err)
+	_, plaintext := decryptJweForTest(t, encrypted, encryptionKey)
+	token, err = NewIdToken(plaintext, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, token.validateSignature(&signingKey.PublicKey))
+
+	client.RequestObjectSigner = nil
+	_, err = client.RequestObject("code", []string{"openid"}, "", "12345678", "")
+	assert.ErrorIs(t, err, errNoRequestObjectSigner)
+}
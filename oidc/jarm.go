@@ -0,0 +1,60 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+)
+
+// jarmClaims はJARMのレスポンスのJWTのpayload
+//
+// refs: https://openid.net/specs/oauth-v2-jarm.html#section-2.1
+type jarmClaims struct {
+	Iss              string   `json:"iss"`
+	Aud              Audience `json:"aud"`
+	Exp              int64    `json:"exp"`
+	Code             string   `json:"code"`
+	State            string   `json:"state"`
+	IdToken          string   `json:"id_token"`
+	Error            string   `json:"error"`
+	ErrorDescription string   `json:"error_description"`
+	ErrorUri         string   `json:"error_uri"`
+}
+
+// ParseJarmResponse はresponse_mode=jwtで返されたresponseパラメータのJWTを検証し、認可レスポンスを取り出す
+//
+// 署名はid_tokenと同じ鍵で検証し、iss, aud, expを確認する。
+// レスポンスがエラーの場合は*AuthErrorを返す
+func (c oidcClient) ParseJarmResponse(ctx context.Context, rawResponse string) (*AuthResponse, error) {
+	token, err := NewIdToken(rawResponse, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authorization response JWT: %w", err)
+	}
+
+	v := c.Verifier()
+	if err := v.checkAlg(token.header.Alg); err != nil {
+		return nil, err
+	}
+	if err := v.verifySignature(ctx, token); err != nil {
+		return nil, err
+	}
+
+	claims := jarmClaims{}
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+	if err := v.tenantClaims(token).validateIss(claims.Iss); err != nil {
+		return nil, err
+	}
+	if !claims.Aud.contains(c.ClientId) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAudience, []string(claims.Aud))
+	}
+	if err := v.claims.validateExp(claims.Exp); err != nil {
+		return nil, err
+	}
+
+	if claims.Error != "" {
+		return nil, &AuthError{Code: claims.Error, Description: claims.ErrorDescription, Uri: claims.ErrorUri, State: claims.State}
+	}
+
+	return &AuthResponse{Code: claims.Code, State: claims.State, IdToken: claims.IdToken}, nil
+}
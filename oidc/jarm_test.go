@@ -0,0 +1,79 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_ParseJarmResponse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anotherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	jarmPayload := func(modify func(payload map[string]interface{})) map[string]interface{} {
+		payload := map[string]interface{}{
+			"iss":   "https://accounts.google.com",
+			"aud":   os.Getenv("GOOGLE_CLIENT_ID"),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"code":  "DummyCode",
+			"state": "12345678",
+		}
+		modify(payload)
+
+		return payload
+	}
+
+	patterns := []struct {
+		desc     string
+		payload  map[string]interface{}
+		key      *rsa.PrivateKey
+		expected error
+	}{
+		{"valid", jarmPayload(func(map[string]interface{}) {}), rsaKey, nil},
+		{"signed by another key", jarmPayload(func(map[string]interface{}) {}), anotherKey, ErrSignatureInvalid},
+		{"iss mismatch", jarmPayload(func(p map[string]interface{}) { p["iss"] = "https://example.com" }), rsaKey, ErrInvalidIssuer},
+		{"aud mismatch", jarmPayload(func(p map[string]interface{}) { p["aud"] = "another-client" }), rsaKey, ErrInvalidAudience},
+		{"expired", jarmPayload(func(p map[string]interface{}) { p["exp"] = time.Now().Add(-time.Hour).Unix() }), rsaKey, ErrTokenExpired},
+	}
+
+	for _, pattern := range patterns {
+		rawResponse := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, pattern.payload, rsaSignerForTest(pattern.key))
+		resp, err := client.ParseJarmResponse(context.Background(), rawResponse)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, &AuthResponse{Code: "DummyCode", State: "12345678"}, resp, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+
+	// エラーのレスポンスは署名を検証した上でAuthErrorとして返す
+	rawResponse := encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		jarmPayload(func(p map[string]interface{}) {
+			delete(p, "code")
+			p["error"] = "access_denied"
+		}),
+		rsaSignerForTest(rsaKey),
+	)
+	_, err = client.ParseJarmResponse(context.Background(), rawResponse)
+	var authErr *AuthError
+	assert.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "access_denied", authErr.Code)
+	assert.Equal(t, "12345678", authErr.State)
+}
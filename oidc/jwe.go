@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	jweAlgRsaOaep256 = "RSA-OAEP-256"
+	jweEncA256Gcm    = "A256GCM"
+	// a256GcmKeyBytes はA256GCMのコンテンツ暗号化鍵のバイト数
+	a256GcmKeyBytes = 32
+)
+
+// encryptJwe はplaintextをRSA-OAEP-256とA256GCMでJWE(Compact Serialization)に暗号化する
+//
+// ネストしたJWTとして署名済みのJWTを暗号化するためにctyはJWTとする
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7516#appendix-A.1
+func encryptJwe(plaintext string, pubKey *rsa.PublicKey, kid string) (string, error) {
+	header := map[string]string{"alg": jweAlgRsaOaep256, "enc": jweEncA256Gcm, "cty": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	byteHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWE header: %w", err)
+	}
+	b64Header := base64.RawURLEncoding.EncodeToString(byteHeader)
+
+	cek := make([]byte, a256GcmKeyBytes)
+	if _, err := rand.Read(cek); err != nil {
+		return "", fmt.Errorf("failed to generate content encryption key: %w", err)
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pubKey, cek, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt content encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate initialization vector: %w", err)
+	}
+	// Sealの結果は暗号文の後ろに認証タグが付いたもの
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(b64Header))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		b64Header,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// decryptJweForTest はencryptJweで暗号化したJWEを秘密鍵で復号する
+func decryptJweForTest(t *testing.T, jwe string, key *rsa.PrivateKey) (map[string]string, string) {
+	parts := strings.Split(jwe, ".")
+	if len(parts) != 5 {
+		t.Fatalf("unexpected JWE segments: %d", len(parts))
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded[i] = b
+	}
+
+	header := map[string]string{}
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		t.Fatal(err)
+	}
+	cek, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return header, string(plaintext)
+}
+
+func TestEncryptJwe(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwe, err := encryptJwe("header.payload.signature", &rsaKey.PublicKey, "enc-key-1")
+	assert.Nil(t, err)
+
+	header, plaintext := decryptJweForTest(t, jwe, rsaKey)
+	assert.Equal(t, map[string]string{"alg": "RSA-OAEP-256", "enc": "A256GCM", "cty": "JWT", "kid": "enc-key-1"}, header)
+	assert.Equal(t, "header.payload.signature", plaintext)
+}
@@ -3,13 +3,18 @@ package oidc
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"log/slog"
+	"math"
 	"math/big"
 	"net/http"
 	"net/url"
@@ -27,94 +32,314 @@ type jwk struct {
 	Use string `json:"use"`
 	N   string `json:"n"`
 	Alg string `json:"alg"`
+	// kty=EC, OKPの場合に使うパラメータ。OKPではyは使わない
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// X5c は公開鍵を含む証明書チェーン。先頭が公開鍵の証明書で、以降はそれを署名した中間証明書
+	X5c []string `json:"x5c"`
 }
 
-func (token idToken) validateSignature(jwksUrl string) error {
-	key, err := token.getJwk(jwksUrl)
+// newPublicJwk は公開鍵をJWKにする。kid, use, algは設定しない
+func newPublicJwk(pubKey crypto.PublicKey) (jwk, error) {
+	switch pub := pubKey.(type) {
+	case *rsa.PublicKey:
+		return jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		// x, yは曲線のサイズに合わせた固定長にする必要がある
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return jwk{
+			Kty: "EC",
+			Crv: pub.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return jwk{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)}, nil
+	default:
+		return jwk{}, fmt.Errorf("%w: %T", errUnsupportedKeyType, pubKey)
+	}
+}
+
+// requiredMembers はJWKの鍵の種類ごとの必須パラメータのみを返す
+func (key jwk) requiredMembers() map[string]string {
+	switch key.Kty {
+	case "RSA":
+		return map[string]string{"kty": key.Kty, "n": key.N, "e": key.E}
+	case "EC":
+		return map[string]string{"kty": key.Kty, "crv": key.Crv, "x": key.X, "y": key.Y}
+	default:
+		return map[string]string{"kty": key.Kty, "crv": key.Crv, "x": key.X}
+	}
+}
+
+// thumbprint はJWKのSHA-256のJWK Thumbprintを返す
+//
+// 必須パラメータのみを辞書順に並べた空白のないJSONのハッシュをとる。json.Marshalはmapのキーを辞書順に出力する
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7638
+func (key jwk) thumbprint() (string, error) {
+	raw, err := json.Marshal(key.requiredMembers())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWK: %w", err)
+	}
+	hash := sha256.Sum256(raw)
+
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+// publicKeyFor はJWKがalgでの検証に使える鍵かを確認した上で公開鍵を組み立てる
+//
+// n/eなどの鍵のパラメータがなくx5cのみが公開されている場合は証明書から公開鍵を取り出す。
+// rootsが指定されている場合はx5cの証明書チェーンを必須とし、rootsで検証できた証明書の公開鍵のみを受け入れる
+func (key jwk) publicKeyFor(alg string, roots *x509.CertPool) (crypto.PublicKey, error) {
+	if err := key.checkAlg(alg); err != nil {
+		return nil, err
+	}
+
+	if roots == nil && key.hasKeyParams() {
+		return key.publicKey()
+	}
+
+	certKey, err := key.x5cPublicKey(roots)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !key.hasKeyParams() {
+		return certKey, nil
 	}
 
+	// 鍵のパラメータと証明書の両方がある場合は同じ鍵であることを確認する
+	pubKey, err := key.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	if !equalPublicKey(pubKey, certKey) {
+		return nil, errX5cKeyMismatch
+	}
+
+	return pubKey, nil
+}
+
+// hasKeyParams はJWKにn/eやx/yなどの公開鍵のパラメータが含まれているかを返す
+func (key jwk) hasKeyParams() bool {
+	return key.N != "" || key.X != ""
+}
+
+// publicKey はktyに応じてJWKから公開鍵を組み立てる
+func (key jwk) publicKey() (crypto.PublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		return key.rsaPublicKey()
+	case "EC":
+		return key.ecdsaPublicKey()
+	case "OKP":
+		return key.ed25519PublicKey()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKeyType, key.Kty)
+	}
+}
+
+// checkAlg はJWKがヘッダのalgでの検証に使える鍵かを確認する
+//
+// JWKのalgは任意項目なので、指定されている場合のみ一致を確認する。
+// ESアルゴリズムの場合はcrvも対応する曲線である必要がある
+func (key jwk) checkAlg(alg string) error {
+	if key.Alg != "" && key.Alg != alg {
+		return fmt.Errorf("%w: jwk alg %s, header alg %s", errKeyAlgMismatch, key.Alg, alg)
+	}
+
+	if crv, ok := ecdsaAlgCurves[alg]; ok && key.Crv != crv {
+		return fmt.Errorf("%w: jwk crv %s, header alg %s", errKeyAlgMismatch, key.Crv, alg)
+	}
+
+	return nil
+}
+
+// rsaPublicKey はJWKのmodulus(n)とexponent(e)からRSA公開鍵を組み立てる
+func (key jwk) rsaPublicKey() (*rsa.PublicKey, error) {
 	byteN, err := base64.RawURLEncoding.DecodeString(key.N)
 	if err != nil {
-		return fmt.Errorf("failed to decode base64 modulus: %w", err)
+		return nil, fmt.Errorf("failed to decode base64 modulus: %w", err)
+	}
+
+	// eはビッグエンディアンの符号なし整数。一般的には"AQAB"(65537)
+	byteE, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 exponent: %w", err)
+	}
+	e := new(big.Int).SetBytes(byteE)
+	// 1以下や偶数の指数はRSAとして成り立たず、intに収まらないものはrsa.PublicKeyで扱えない
+	if !e.IsInt64() || e.Int64() > math.MaxInt32 || e.Int64() <= 1 || e.Bit(0) == 0 {
+		return nil, errInvalidExponent
 	}
 
-	const standardExponent = 65537
-	pubKey := &rsa.PublicKey{
+	return &rsa.PublicKey{
 		N: new(big.Int).SetBytes(byteN),
-		E: standardExponent, // TODO: key.E -> "AQAB"から導きたい
+		E: int(e.Int64()),
+	}, nil
+}
+
+// ecdsaPublicKey はJWKの曲線(crv)と座標(x, y)からECDSA公開鍵を組み立てる
+func (key jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch key.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedCurve, key.Crv)
 	}
 
-	headerAndPayload := fmt.Sprintf("%s.%s", token.rawHeader, token.RawPayload)
-	sha := sha256.New()
-	sha.Write([]byte(headerAndPayload))
+	byteX, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 x coordinate: %w", err)
+	}
+	byteY, err := base64.RawURLEncoding.DecodeString(key.Y)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 y coordinate: %w", err)
+	}
+
+	pubKey := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(byteX),
+		Y:     new(big.Int).SetBytes(byteY),
+	}
+	// 曲線上にない点を渡されると不正な鍵で検証することになるため弾く
+	if !curve.IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, errInvalidEcPoint
+	}
+
+	return pubKey, nil
+}
 
-	decSignature, err := base64.RawURLEncoding.DecodeString(token.rawSignature)
+// ed25519PublicKey はkty=OKPのJWKからEd25519公開鍵を取り出す
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc8037#section-2
+func (key jwk) ed25519PublicKey() (ed25519.PublicKey, error) {
+	if key.Crv != "Ed25519" {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedCurve, key.Crv)
+	}
+
+	byteX, err := base64.RawURLEncoding.DecodeString(key.X)
 	if err != nil {
-		return fmt.Errorf("failed to base64 decode id_token signature: %w", err)
+		return nil, fmt.Errorf("failed to decode base64 x coordinate: %w", err)
+	}
+	if len(byteX) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: unexpected Ed25519 public key length %d", errInvalidEcPoint, len(byteX))
 	}
 
-	if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, sha.Sum(nil), decSignature); err != nil {
-		return fmt.Errorf("failed to verify id_token signature: %w", err)
+	return ed25519.PublicKey(byteX), nil
+}
+
+// getJwk はJWKsからkidに一致しalgで使える鍵を探す。cacheがnilの場合は毎回JWKsエンドポイントから取得する
+func getJwk(ctx context.Context, cfg httpConfig, jwksUrl string, kid string, alg string, cache *jwksCache) (jwk, error) {
+	keys, err := cache.getOrFetch(ctx, cfg, jwksUrl)
+	if err != nil {
+		return jwk{}, err
 	}
 
-	return nil
+	foundKey, err := keys.find(kid, alg)
+	if err == nil {
+		return foundKey, nil
+	}
+	if !errors.Is(err, ErrJwkNotFound) {
+		return jwk{}, err
+	}
+
+	// 鍵のローテーションでキャッシュに新しいkidが含まれていない可能性があるので一度だけ取得し直す
+	cfg.log().InfoContext(ctx, "kid not found in cached JWKs", slog.String("url", jwksUrl), slog.String("kid", kid))
+	refetchedKeys, refetched, refetchErr := cache.refetch(ctx, cfg, jwksUrl)
+	if refetchErr != nil {
+		return jwk{}, refetchErr
+	}
+	if !refetched {
+		return jwk{}, err
+	}
+
+	return refetchedKeys.find(kid, alg)
+}
+
+// fetchJwks はJWKsエンドポイントから公開鍵の一覧を取得する。キャッシュ期間の算出に使うためにレスポンスヘッダも返す
+func fetchJwks(ctx context.Context, cfg httpConfig, jwksUrl string) (keys jwks, header http.Header, err error) {
+	ctx, endSpan := startSpan(ctx, cfg.tracer, "oidc.jwks", SpanAttribute{"url.full", jwksUrl})
+	start := time.Now()
+	defer func() {
+		cfg.recorder().JwksFetched(time.Since(start), err)
+		endSpan(err)
+	}()
+
+	return doFetchJwks(ctx, cfg, jwksUrl)
 }
 
-func (token idToken) getJwk(jwksUrl string) (jwk, error) {
+func doFetchJwks(ctx context.Context, cfg httpConfig, jwksUrl string) (jwks, http.Header, error) {
 	parsedUrl, err := url.Parse(jwksUrl)
 	if err != nil {
-		return jwk{}, fmt.Errorf("failed to parse jwks url: %w", err)
+		return jwks{}, nil, fmt.Errorf("failed to parse jwks url: %w", err)
+	}
+	if err := cfg.urlPolicy.check(ctx, jwksUrl); err != nil {
+		return jwks{}, nil, fmt.Errorf("failed to check jwks url: %w", err)
 	}
 
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), httpTimeoutSec*time.Second)
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, cfg.timeouts.jwks())
 	defer cancel()
 	reqWithCtx, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodGet, parsedUrl.String(), nil)
 	if err != nil {
-		return jwk{}, fmt.Errorf("failed to create request of GET JWKs endpoint: %w", err)
+		return jwks{}, nil, fmt.Errorf("failed to create request of GET JWKs endpoint: %w", err)
 	}
 
-	httpClient := &http.Client{}
-	resp, err := httpClient.Do(reqWithCtx)
+	resp, byteArray, err := cfg.send(reqWithCtx)
 	if err != nil {
-		return jwk{}, fmt.Errorf("failed to GET JWKs endpoint: %w", err)
-	}
+		cfg.log().WarnContext(ctx, "failed to fetch JWKs", slog.String("url", jwksUrl), slog.Any("error", err))
 
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			panic(err)
-		}
-	}(resp.Body)
-	byteArray, _ := ioutil.ReadAll(resp.Body)
+		return jwks{}, nil, fmt.Errorf("failed to GET JWKs endpoint: %w", err)
+	}
 
 	keys := &jwks{}
 	if err := json.Unmarshal(byteArray, keys); err != nil {
-		return jwk{}, fmt.Errorf("failed to unmarshal JWKs response: %w", err)
-	}
+		cfg.log().WarnContext(ctx, "failed to unmarshal JWKs", slog.String("url", jwksUrl), slog.Int("status", resp.StatusCode))
 
-	foundKey, err := keys.find(token.header.Kid)
-	if err != nil {
-		return jwk{}, err
+		return jwks{}, nil, fmt.Errorf("failed to unmarshal JWKs response: %w", err)
 	}
+	cfg.log().DebugContext(ctx, "fetched JWKs", slog.String("url", jwksUrl), slog.Int("keys", len(keys.Keys)))
 
-	return foundKey, nil
+	return *keys, resp.Header, nil
 }
 
-func (keys jwks) find(kid string) (jwk, error) {
-	var foundKey jwk
+// find はkidが一致し、algでの署名検証に使える鍵を探す
+//
+// 同じkidでRSAとECの鍵や、署名用と暗号化用の鍵が公開されていることがあるため、
+// kidに加えてuse, alg, ktyも確認して誤った鍵を選ばないようにする
+func (keys jwks) find(kid string, alg string) (jwk, error) {
 	for _, key := range keys.Keys {
-		if key.Kid == kid {
-			foundKey = key
-
-			break
+		if key.Kid == kid && key.isSigningKeyFor(alg) {
+			return key, nil
 		}
 	}
 
-	if foundKey != (jwk{}) {
-		return foundKey, nil
-	} else {
-		return jwk{}, errJwkNotFound
+	return jwk{}, ErrJwkNotFound
+}
+
+// isSigningKeyFor はalgの署名検証に使える鍵かを返す。use, algはJWKでは任意項目なので、指定されている場合のみ確認する
+func (key jwk) isSigningKeyFor(alg string) bool {
+	if key.Use != "" && key.Use != "sig" {
+		return false
 	}
+
+	if key.Alg != "" && key.Alg != alg {
+		return false
+	}
+
+	if kty, ok := algKeyTypes[alg]; ok && key.Kty != kty {
+		return false
+	}
+
+	return key.checkAlg(alg) == nil
 }
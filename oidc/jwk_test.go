@@ -0,0 +1,225 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestJwk_RsaPublicKey(t *testing.T) {
+	n := base64.RawURLEncoding.EncodeToString([]byte{0xc1, 0x23, 0x45, 0x67})
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		e             string
+		expected      int
+	}{
+		{
+			"standard exponent",
+			true,
+			"AQAB",
+			65537,
+		},
+		{
+			"non-standard exponent",
+			true,
+			base64.RawURLEncoding.EncodeToString([]byte{0x03}),
+			3,
+		},
+		{
+			"even exponent",
+			false,
+			base64.RawURLEncoding.EncodeToString([]byte{0x04}),
+			0,
+		},
+		{
+			"empty exponent",
+			false,
+			"",
+			0,
+		},
+		{
+			"too large exponent",
+			false,
+			base64.RawURLEncoding.EncodeToString([]byte{0x01, 0x00, 0x00, 0x00, 0x01}),
+			0,
+		},
+		{
+			"not base64url",
+			false,
+			"A+/=",
+			0,
+		},
+	}
+
+	for _, pattern := range patterns {
+		key := jwk{Kty: "RSA", N: n, E: pattern.e}
+		pubKey, err := key.rsaPublicKey()
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, pubKey.E, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestJwk_PublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes())
+	y := base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes())
+	edPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edX := base64.RawURLEncoding.EncodeToString(edPubKey)
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		key           jwk
+	}{
+		{"valid EC key", true, jwk{Kty: "EC", Crv: "P-256", X: x, Y: y}},
+		{"point not on curve", false, jwk{Kty: "EC", Crv: "P-256", X: x, Y: x}},
+		{"unsupported curve", false, jwk{Kty: "EC", Crv: "secp256k1", X: x, Y: y}},
+		{"valid OKP key", true, jwk{Kty: "OKP", Crv: "Ed25519", X: edX}},
+		{"unsupported OKP curve", false, jwk{Kty: "OKP", Crv: "X25519", X: edX}},
+		{"invalid Ed25519 key length", false, jwk{Kty: "OKP", Crv: "Ed25519", X: x[:10]}},
+		{"unsupported kty", false, jwk{Kty: "oct"}},
+	}
+
+	for _, pattern := range patterns {
+		_, err := pattern.key.publicKey()
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestJwk_CheckAlg(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		key           jwk
+		alg           string
+	}{
+		{"alg not specified in jwk", true, jwk{Kty: "RSA"}, "RS256"},
+		{"alg matches", true, jwk{Kty: "RSA", Alg: "RS256"}, "RS256"},
+		{"alg mismatch", false, jwk{Kty: "RSA", Alg: "RS256"}, "ES256"},
+		{"crv matches", true, jwk{Kty: "EC", Crv: "P-384"}, "ES384"},
+		{"crv and alg match", true, jwk{Kty: "EC", Crv: "P-521", Alg: "ES512"}, "ES512"},
+		{"crv mismatch", false, jwk{Kty: "EC", Crv: "P-256"}, "ES512"},
+	}
+
+	for _, pattern := range patterns {
+		err := pattern.key.checkAlg(pattern.alg)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestGetJwk_KeyRotation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// 1回目の取得ではkey-1のみ、2回目以降はローテーション後のkey-2も含まれる
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, httpmock.ResponderFromMultipleResponses([]*http.Response{
+		httpmock.NewStringResponse(http.StatusOK, `{"keys": [{"kty": "RSA", "kid": "key-1"}]}`),
+		httpmock.NewStringResponse(http.StatusOK, `{"keys": [{"kty": "RSA", "kid": "key-1"}, {"kty": "RSA", "kid": "key-2"}]}`),
+	}))
+
+	now := time.Now()
+	cache := NewJwksCache(time.Hour)
+	cache.now = func() time.Time { return now }
+
+	_, err := getJwk(context.Background(), httpConfig{}, testJwksUrl, "key-1", "RS256", cache)
+	assert.Nil(t, err)
+
+	// 直前に取得したばかりなのでcooldown中は取得し直さない
+	_, err = getJwk(context.Background(), httpConfig{}, testJwksUrl, "key-2", "RS256", cache)
+	assert.ErrorIs(t, err, ErrJwkNotFound)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	now = now.Add(defaultJwksRefetchCooldown)
+	key, err := getJwk(context.Background(), httpConfig{}, testJwksUrl, "key-2", "RS256", cache)
+	assert.Nil(t, err)
+	assert.Equal(t, "key-2", key.Kid)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// 取得し直した結果がキャッシュされる
+	_, err = getJwk(context.Background(), httpConfig{}, testJwksUrl, "key-2", "RS256", cache)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestJwks_Find(t *testing.T) {
+	keys := jwks{Keys: []jwk{
+		{Kid: "shared", Kty: "RSA", Use: "enc"},
+		{Kid: "shared", Kty: "EC", Crv: "P-256", Use: "sig"},
+		{Kid: "shared", Kty: "RSA", Use: "sig", Alg: "PS256"},
+		{Kid: "shared", Kty: "RSA", Use: "sig", N: "rs256"},
+		{Kid: "no-use", Kty: "RSA"},
+	}}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		kid           string
+		alg           string
+		expected      jwk
+	}{
+		{"skip enc key and alg mismatch", true, "shared", "RS256", keys.Keys[3]},
+		{"EC key with same kid", true, "shared", "ES256", keys.Keys[1]},
+		{"alg specified in jwk", true, "shared", "PS256", keys.Keys[2]},
+		{"curve mismatch", false, "shared", "ES384", jwk{}},
+		{"use not specified", true, "no-use", "RS256", keys.Keys[4]},
+		{"kty mismatch", false, "no-use", "EdDSA", jwk{}},
+		{"kid not found", false, "unknown", "RS256", jwk{}},
+	}
+
+	for _, pattern := range patterns {
+		actual, err := keys.find(pattern.kid, pattern.alg)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, actual, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, ErrJwkNotFound, pattern.desc)
+		}
+	}
+}
+
+func TestJwk_Thumbprint(t *testing.T) {
+	// RFC 7638 3.1節の例
+	key := jwk{
+		Kty: "RSA",
+		Kid: "2011-04-29",
+		Alg: "RS256",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+
+	thumbprint, err := key.thumbprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
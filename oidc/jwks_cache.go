@@ -0,0 +1,197 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultJwksCacheFallbackTtl はJWKsのレスポンスにmax-ageがない場合にキャッシュする期間
+const defaultJwksCacheFallbackTtl = 1 * time.Hour
+
+// defaultJwksRefetchCooldown はkidが見つからない場合にJWKsを取得し直す間隔の最小値
+//
+// 存在しないkidを指定したトークンを大量に送られても、JWKsエンドポイントへのアクセスが増えすぎないようにする
+const defaultJwksRefetchCooldown = 1 * time.Minute
+
+// defaultJwksCache はクライアント間で共有するJWKsのキャッシュ
+var defaultJwksCache = NewJwksCache(defaultJwksCacheFallbackTtl)
+
+type jwksCacheEntry struct {
+	keys      jwks
+	expiresAt time.Time
+}
+
+// jwksCache はJWKsエンドポイントのURLごとに取得した公開鍵の一覧を保持する
+//
+// キャッシュ期間はレスポンスのCache-Controlヘッダのmax-ageに従う
+type jwksCache struct {
+	mu              sync.RWMutex
+	entries         map[string]jwksCacheEntry
+	lastAttemptedAt map[string]time.Time
+	fallbackTtl     time.Duration
+	refetchCooldown time.Duration
+	now             func() time.Time
+	flight          jwksFlightGroup
+}
+
+// NewJwksCache はJWKsのキャッシュを返す
+//
+// fallbackTtlはレスポンスにCache-Controlのmax-ageがない場合のキャッシュ期間
+func NewJwksCache(fallbackTtl time.Duration) *jwksCache {
+	return &jwksCache{
+		entries:         map[string]jwksCacheEntry{},
+		lastAttemptedAt: map[string]time.Time{},
+		fallbackTtl:     fallbackTtl,
+		refetchCooldown: defaultJwksRefetchCooldown,
+		now:             time.Now,
+	}
+}
+
+// Purge はキャッシュをすべて破棄する。テストなどでキャッシュを使わずに取得し直したい場合に使う
+func (c *jwksCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]jwksCacheEntry{}
+	c.lastAttemptedAt = map[string]time.Time{}
+}
+
+// getOrFetch はキャッシュが有効であればキャッシュから、そうでなければJWKsエンドポイントから公開鍵の一覧を取得する
+//
+// cがnilの場合はキャッシュを使わない
+func (c *jwksCache) getOrFetch(ctx context.Context, cfg httpConfig, jwksUrl string) (jwks, error) {
+	if c == nil {
+		keys, _, err := fetchJwks(ctx, cfg, jwksUrl)
+
+		return keys, err
+	}
+
+	// キャッシュはURLごとにクライアント間で共有するので、別のクライアントが取得した鍵を返す前にこのクライアントのUrlPolicyを確認する
+	if err := cfg.urlPolicy.check(ctx, jwksUrl); err != nil {
+		return jwks{}, fmt.Errorf("failed to check jwks url: %w", err)
+	}
+	if keys, ok := c.get(jwksUrl); ok {
+		cfg.log().DebugContext(ctx, "JWKs cache hit", slog.String("url", jwksUrl))
+		cfg.recorder().CacheAccessed(JwksCacheName, true)
+
+		return keys, nil
+	}
+	cfg.log().DebugContext(ctx, "JWKs cache miss", slog.String("url", jwksUrl))
+	cfg.recorder().CacheAccessed(JwksCacheName, false)
+
+	return c.fetch(ctx, cfg, jwksUrl)
+}
+
+// refetch はキャッシュの有効期限に関わらずJWKsエンドポイントから取得し直す
+//
+// 鍵のローテーション直後でキャッシュにkidが見つからない場合に使う。
+// 前回の取得からrefetchCooldownが経っていない場合は、前回の取得が失敗していても取得し直さず、falseを返す
+func (c *jwksCache) refetch(ctx context.Context, cfg httpConfig, jwksUrl string) (jwks, bool, error) {
+	if c == nil {
+		return jwks{}, false, nil
+	}
+
+	c.mu.RLock()
+	lastAttemptedAt, ok := c.lastAttemptedAt[jwksUrl]
+	c.mu.RUnlock()
+	if ok && c.now().Sub(lastAttemptedAt) < c.refetchCooldown {
+		return jwks{}, false, nil
+	}
+
+	keys, err := c.fetch(ctx, cfg, jwksUrl)
+	if err != nil {
+		return jwks{}, false, err
+	}
+
+	return keys, true, nil
+}
+
+// fetch はJWKsエンドポイントから取得してキャッシュする。同じURLへの同時の取得は1回のリクエストにまとめる
+//
+// まとめた取得は最初の呼び出し元のリクエストがキャンセルされると待っている他の呼び出しも失敗するので、
+// 呼び出し元のキャンセルは引き継がず、JWKsのタイムアウトで打ち切る
+func (c *jwksCache) fetch(ctx context.Context, cfg httpConfig, jwksUrl string) (jwks, error) {
+	return c.flight.do(jwksUrl, func() (jwks, error) {
+		// JWKsエンドポイントが失敗し続けている場合もrefetchCooldownの間は取得し直さないように、取得する前に記録する
+		c.mu.Lock()
+		c.lastAttemptedAt[jwksUrl] = c.now()
+		c.mu.Unlock()
+
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.timeouts.jwks())
+		defer cancel()
+		keys, header, err := fetchJwks(fetchCtx, cfg, jwksUrl)
+		if err != nil {
+			return jwks{}, err
+		}
+		c.set(jwksUrl, keys, c.ttl(header))
+
+		return keys, nil
+	})
+}
+
+func (c *jwksCache) get(jwksUrl string) (jwks, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[jwksUrl]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return jwks{}, false
+	}
+
+	return entry.keys, true
+}
+
+func (c *jwksCache) set(jwksUrl string, keys jwks, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(c.entries, jwksUrl)
+
+		return
+	}
+	c.entries[jwksUrl] = jwksCacheEntry{keys: keys, expiresAt: c.now().Add(ttl)}
+}
+
+// ttl はレスポンスヘッダからキャッシュ期間を求める
+//
+// no-store, no-cacheの場合はキャッシュしない。max-ageがあればAgeヘッダ分を差し引いた期間、
+// なければfallbackTtlをキャッシュ期間とする
+func (c *jwksCache) ttl(header http.Header) time.Duration {
+	maxAge, ok := parseMaxAge(header.Get("Cache-Control"))
+	if !ok {
+		return c.fallbackTtl
+	}
+
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+		maxAge -= time.Duration(age) * time.Second
+	}
+
+	return maxAge
+}
+
+// parseMaxAge はCache-Controlヘッダからキャッシュしてよい期間を取り出す。期間の指定がない場合はfalseを返す
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	maxAge, found := time.Duration(0), false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "max-age="):
+			sec, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil || sec < 0 {
+				continue
+			}
+			maxAge, found = time.Duration(sec)*time.Second, true
+		}
+	}
+
+	return maxAge, found
+}
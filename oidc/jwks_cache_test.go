@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testJwksUrl = "https://example.com/jwks"
+
+const testJwksBody = `{"keys": [{"kty": "RSA", "kid": "key-1", "n": "wSNFZw", "e": "AQAB"}]}`
+
+func registerJwksResponderForTest(cacheControl string) {
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, testJwksBody)
+		if cacheControl != "" {
+			resp.Header.Set("Cache-Control", cacheControl)
+		}
+
+		return resp, nil
+	})
+}
+
+func TestJwksCache_GetOrFetch(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		cacheControl  string
+		expectedCalls int
+	}{
+		{"max-age", "public, max-age=3600, must-revalidate", 1},
+		{"no max-age uses fallback", "public", 1},
+		{"no-store", "no-store", 2},
+		{"max-age=0", "max-age=0", 2},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		registerJwksResponderForTest(pattern.cacheControl)
+
+		cache := NewJwksCache(time.Hour)
+		for i := 0; i < 2; i++ {
+			keys, err := cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "key-1", keys.Keys[0].Kid, pattern.desc)
+		}
+
+		assert.Equal(t, pattern.expectedCalls, httpmock.GetTotalCallCount(), pattern.desc)
+	}
+}
+
+func TestJwksCache_FetchIgnoresCallerCancel(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, testJwksBody), nil
+	})
+
+	// 最初の呼び出し元がキャンセルしても、まとめた取得は他の呼び出しのために続ける
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache := NewJwksCache(time.Hour)
+	keys, err := cache.fetch(ctx, httpConfig{}, testJwksUrl)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "key-1", keys.Keys[0].Kid)
+	}
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestJwksCache_Expire(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerJwksResponderForTest("max-age=60")
+
+	now := time.Now()
+	cache := NewJwksCache(time.Hour)
+	cache.now = func() time.Time { return now }
+
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+	now = now.Add(59 * time.Second)
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	now = now.Add(time.Second)
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	cache.Purge()
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
+
+func TestJwksCache_Nil(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerJwksResponderForTest("max-age=3600")
+
+	var cache *jwksCache
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+	_, _ = cache.getOrFetch(context.Background(), httpConfig{}, testJwksUrl)
+
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestJwksCache_Ttl(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"max-age", http.Header{"Cache-Control": {"public, max-age=19868"}}, 19868 * time.Second},
+		{"max-age with age", http.Header{"Cache-Control": {"max-age=100"}, "Age": {"40"}}, 60 * time.Second},
+		{"no-cache", http.Header{"Cache-Control": {"no-cache"}}, 0},
+		{"invalid max-age", http.Header{"Cache-Control": {"max-age=abc"}}, 10 * time.Minute},
+		{"no header", http.Header{}, 10 * time.Minute},
+	}
+
+	cache := NewJwksCache(10 * time.Minute)
+	for _, pattern := range patterns {
+		assert.Equal(t, pattern.expected, cache.ttl(pattern.header), pattern.desc)
+	}
+}
+
+func TestJwksCache_GetOrFetch_UrlPolicy(t *testing.T) {
+	const httpJwksUrl = "http://idp.example.com/jwks"
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, httpJwksUrl, httpmock.NewStringResponder(http.StatusOK, testJwksBody))
+
+	// httpを許可したクライアントが取得した鍵を、httpを許可していないクライアントには返さない
+	cache := NewJwksCache(time.Hour)
+	_, err := cache.getOrFetch(context.Background(), httpConfig{urlPolicy: UrlPolicy{AllowHttp: true}}, httpJwksUrl)
+	assert.Nil(t, err)
+	_, err = cache.getOrFetch(context.Background(), httpConfig{}, httpJwksUrl)
+	assert.ErrorIs(t, err, errInsecureUrl)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestJwksCache_Refetch_CooldownAfterError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	now := time.Now()
+	cache := NewJwksCache(time.Hour)
+	cache.now = func() time.Time { return now }
+	cfg := httpConfig{retry: RetryPolicy{MaxAttempts: 1}}
+
+	_, refetched, err := cache.refetch(context.Background(), cfg, testJwksUrl)
+	assert.Error(t, err)
+	assert.False(t, refetched)
+
+	// 取得に失敗した場合もrefetchCooldownの間は取得し直さない
+	_, refetched, err = cache.refetch(context.Background(), cfg, testJwksUrl)
+	assert.Nil(t, err)
+	assert.False(t, refetched)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	now = now.Add(defaultJwksRefetchCooldown)
+	_, _, err = cache.refetch(context.Background(), cfg, testJwksUrl)
+	assert.Error(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// signJwt はclaimsをpayloadとしたJWTをkeyで署名して返す
+//
+// keyはcrypto.Signerなので、秘密鍵をHSMやKMSに置いたままでも署名できる
+func signJwt(alg string, kid string, key crypto.Signer, claims interface{}) (string, error) {
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	return signJws(alg, header, key, claims)
+}
+
+// signJws はheaderとclaimsからJWSを組み立ててalgで署名する。headerのalgはalgと同じ値にしておく
+func signJws(alg string, header map[string]interface{}, key crypto.Signer, claims interface{}) (string, error) {
+	byteHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT header: %w", err)
+	}
+	bytePayload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT payload: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(byteHeader) + "." + base64.RawURLEncoding.EncodeToString(bytePayload)
+	signature, err := sign(alg, key, signingInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// sign はalgに従ってsigningInputに署名する。verifySignatureで検証できる形式の署名を返す
+func sign(alg string, key crypto.Signer, signingInput string) ([]byte, error) {
+	switch alg {
+	case "RS256", "PS256", "PS384", "PS512":
+		if _, ok := key.Public().(*rsa.PublicKey); !ok {
+			return nil, errKeyAlgMismatch
+		}
+		hash := algHashes[alg]
+		var opts crypto.SignerOpts = hash
+		if alg != "RS256" {
+			opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+		}
+
+		return key.Sign(rand.Reader, digest(hash, signingInput), opts)
+	case "ES256", "ES384", "ES512":
+		return signEcdsa(alg, key, signingInput)
+	case "EdDSA":
+		return key.Sign(rand.Reader, []byte(signingInput), crypto.Hash(0))
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedAlg, alg)
+	}
+}
+
+// signEcdsa はECDSAで署名し、ASN.1形式の署名をJWS形式(RとSを固定長で連結したもの)に変換して返す
+func signEcdsa(alg string, key crypto.Signer, signingInput string) ([]byte, error) {
+	ecKey, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok || ecKey.Curve.Params().Name != ecdsaAlgCurves[alg] {
+		return nil, errKeyAlgMismatch
+	}
+
+	hash := algHashes[alg]
+	der, err := key.Sign(rand.Reader, digest(hash, signingInput), hash)
+	if err != nil {
+		return nil, err
+	}
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ECDSA signature: %w", err)
+	}
+
+	keySize := (ecKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*keySize)
+	rs.R.FillBytes(signature[:keySize])
+	rs.S.FillBytes(signature[keySize:])
+
+	return signature, nil
+}
@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSignJwt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		alg           string
+		key           crypto.Signer
+	}{
+		{"RS256", true, "RS256", rsaKey},
+		{"PS256", true, "PS256", rsaKey},
+		{"PS512", true, "PS512", rsaKey},
+		{"ES256", true, "ES256", ecKey},
+		{"ES512", true, "ES512", ec521Key},
+		{"EdDSA", true, "EdDSA", edKey},
+		{"EC key with RS256", false, "RS256", ecKey},
+		{"P-256 key with ES512", false, "ES512", ecKey},
+		{"unsupported alg", false, "HS256", rsaKey},
+	}
+
+	for _, pattern := range patterns {
+		rawToken, err := signJwt(pattern.alg, "key-1", pattern.key, map[string]string{"sub": "1234567890"})
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			token, err := NewIdToken(rawToken, 0)
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "key-1", token.header.Kid, pattern.desc)
+			assert.Equal(t, "1234567890", token.StandardClaims().Sub, pattern.desc)
+
+			// 署名はverifySignatureで検証できる
+			parts := strings.Split(rawToken, ".")
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			assert.Nil(t, err, pattern.desc)
+			assert.Nil(t, verifySignature(pattern.alg, pattern.key.Public(), parts[0]+"."+parts[1], signature), pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+)
+
+// KeyProvider はid_tokenの署名検証に使う公開鍵を提供する
+//
+// JWKsエンドポイント以外(Vault, DB, 固定の鍵など)から公開鍵を取得したい場合に実装する
+type KeyProvider interface {
+	// Key はヘッダのkidとalgに対応する公開鍵を返す
+	Key(ctx context.Context, kid string, alg string) (crypto.PublicKey, error)
+}
+
+// remoteKeySet はJWKsエンドポイントから公開鍵を取得する
+type remoteKeySet struct {
+	jwksUrl string
+	cache   *jwksCache
+	// x5cRoots はx5cの証明書チェーンを検証するルート証明書。nilの場合はチェーンを検証しない
+	x5cRoots   *x509.CertPool
+	httpConfig httpConfig
+}
+
+// newRemoteKeySet はjwksUrlから公開鍵を取得するKeyProviderを返す。cacheがnilの場合は毎回取得する
+func newRemoteKeySet(jwksUrl string, cache *jwksCache, x5cRoots *x509.CertPool, httpConfig httpConfig) *remoteKeySet {
+	return &remoteKeySet{jwksUrl: jwksUrl, cache: cache, x5cRoots: x5cRoots, httpConfig: httpConfig}
+}
+
+// Key はJWKsエンドポイントからkidに一致する公開鍵を取得する
+func (s remoteKeySet) Key(ctx context.Context, kid string, alg string) (crypto.PublicKey, error) {
+	key, err := getJwk(ctx, s.httpConfig, s.jwksUrl, kid, alg, s.cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.publicKeyFor(alg, s.x5cRoots)
+}
+
+// StaticKeys はkidごとに固定の公開鍵を提供する
+type StaticKeys map[string]crypto.PublicKey
+
+// Key はkidに一致する公開鍵を返す。鍵の種類とalgの整合性は署名の検証時に確認する
+func (keys StaticKeys) Key(_ context.Context, kid string, _ string) (crypto.PublicKey, error) {
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %s", ErrJwkNotFound, kid)
+	}
+
+	return key, nil
+}
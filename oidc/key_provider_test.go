@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestRemoteKeySet_Key(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		testJwksUrl,
+		httpmock.NewBytesResponder(http.StatusOK, rsaJwksForTest(t, &rsaKey.PublicKey, "key-1")),
+	)
+
+	set := newRemoteKeySet(testJwksUrl, nil, nil, httpConfig{})
+
+	pubKey, err := set.Key(context.Background(), "key-1", "RS256")
+	assert.Nil(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, pubKey)
+
+	// kidが一致してもalgで使えない鍵は選ばない
+	_, err = set.Key(context.Background(), "key-1", "ES256")
+	assert.ErrorIs(t, err, ErrJwkNotFound)
+
+	_, err = set.Key(context.Background(), "key-2", "RS256")
+	assert.ErrorIs(t, err, ErrJwkNotFound)
+}
+
+func TestStaticKeys_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := StaticKeys{"key-1": &rsaKey.PublicKey}
+
+	for _, kid := range []string{"key-1", "key-2"} {
+		rawToken := encodeTokenForTest(
+			t,
+			map[string]interface{}{"alg": "RS256", "kid": kid, "typ": "JWT"},
+			validGooglePayloadForTest(),
+			rsaSignerForTest(rsaKey),
+		)
+		token, err := NewIdToken(rawToken, Google)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := NewGoogleOidcClient()
+		client.KeyProvider = keys
+		err = client.Verifier().Verify(context.Background(), token)
+
+		if kid == "key-1" {
+			assert.Nil(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrJwkNotFound)
+		}
+	}
+}
+
+func TestRemoteKeySet_Key_Canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+
+		return nil, req.Context().Err()
+	})
+
+	// 呼び出し元のcontextのキャンセルがJWKsの取得まで伝わることを確認する
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := newRemoteKeySet(testJwksUrl, nil, nil, httpConfig{}).Key(ctx, "key-1", "RS256")
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,57 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// keySet は事前に読み込んだJWKsの公開鍵の一覧
+//
+// ネットワークにアクセスできない環境や、テストで決まった鍵を使って検証したい場合に使う
+type keySet struct {
+	keys jwks
+}
+
+// NewKeySet はJWKsのJSONから公開鍵の一覧を読み込む
+func NewKeySet(rawJwks []byte) (*keySet, error) {
+	return NewKeySetFromReader(bytes.NewReader(rawJwks))
+}
+
+// NewKeySetFromReader はrから読み込んだJWKsのJSONから公開鍵の一覧を読み込む
+func NewKeySetFromReader(r io.Reader) (*keySet, error) {
+	keys := &jwks{}
+	if err := json.NewDecoder(r).Decode(keys); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKs: %w", err)
+	}
+
+	return &keySet{keys: *keys}, nil
+}
+
+// NewKeySetFromFile はpathのファイルに保存されたJWKsのJSONから公開鍵の一覧を読み込む
+func NewKeySetFromFile(path string) (*keySet, error) {
+	rawJwks, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKs file: %w", err)
+	}
+
+	return NewKeySet(rawJwks)
+}
+
+func (s keySet) find(kid string, alg string) (jwk, error) {
+	return s.keys.find(kid, alg)
+}
+
+// Key はkidに一致する公開鍵を返す
+func (s keySet) Key(_ context.Context, kid string, alg string) (crypto.PublicKey, error) {
+	key, err := s.find(kid, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.publicKeyFor(alg, nil)
+}
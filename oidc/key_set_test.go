@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewKeySet(t *testing.T) {
+	file, err := ioutil.TempFile("", "jwks-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(testJwksBody); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fromBytes, err := NewKeySet([]byte(testJwksBody))
+	assert.Nil(t, err)
+	fromReader, err := NewKeySetFromReader(strings.NewReader(testJwksBody))
+	assert.Nil(t, err)
+	fromFile, err := NewKeySetFromFile(file.Name())
+	assert.Nil(t, err)
+
+	for _, set := range []*keySet{fromBytes, fromReader, fromFile} {
+		key, err := set.find("key-1", "RS256")
+		assert.Nil(t, err)
+		assert.Equal(t, "RSA", key.Kty)
+
+		_, err = set.find("unknown", "RS256")
+		assert.ErrorIs(t, err, ErrJwkNotFound)
+	}
+}
+
+func TestNewKeySet_Invalid(t *testing.T) {
+	_, err := NewKeySet([]byte("invalid json"))
+	assert.Error(t, err)
+
+	_, err = NewKeySetFromFile("not-exist.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+// NewKeycloakOidcClient はKeycloakのレルムのクライアントを返す
+//
+// baseUrlにはhttps://keycloak.example.comのようなKeycloakのURLを渡す。
+// Keycloak 17より前のWildFly版を使う場合はhttps://keycloak.example.com/authのように/authまで含める
+//
+// refs: https://www.keycloak.org/docs/latest/securing_apps/#endpoints
+func NewKeycloakOidcClient(baseUrl string, realm string, opts ...ClientOption) *oidcClient {
+	issuer := strings.TrimSuffix(baseUrl, "/") + "/realms/" + realm
+	base := issuer + "/protocol/openid-connect"
+	client := newOidcClient(
+		Keycloak,
+		issuer,
+		os.Getenv("KEYCLOAK_CLIENT_ID"),
+		clientSecret(os.Getenv("KEYCLOAK_CLIENT_SECRET")),
+		base+"/auth",
+		base+"/token",
+		base+"/certs",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = base + "/userinfo"
+	client.IntrospectionEndpoint = base + "/token/introspect"
+	client.RevocationEndpoint = base + "/revoke"
+	client.EndSessionEndpoint = base + "/logout"
+	client.DeviceAuthEndpoint = base + "/auth/device"
+	client.CheckSessionIframe = base + "/login-status-iframe.html"
+	client.Apply(opts...)
+
+	return client
+}
+
+// KeycloakRoles はKeycloakのトークンに含まれるロール
+//
+// refs: https://www.keycloak.org/docs/latest/server_admin/#con-client-scopes_server_administration_guide
+type KeycloakRoles struct {
+	// Realm はrealm_access.rolesのレルムロール
+	Realm []string
+	// Clients はresource_accessのクライアントIDごとのクライアントロール
+	Clients map[string][]string
+}
+
+// keycloakRoleClaims はrealm_accessとresource_accessをunmarshalするための構造体
+type keycloakRoleClaims struct {
+	RealmAccess struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	ResourceAccess map[string]struct {
+		Roles []string `json:"roles"`
+	} `json:"resource_access"`
+}
+
+// ParseKeycloakRoles はトークンのrealm_accessとresource_accessからロールを取り出す
+//
+// Keycloakは既定ではアクセストークンにのみロールを含めるので、id_tokenで使う場合はクライアントスコープのマッパーで
+// 「Add to ID token」を有効にしておく
+func ParseKeycloakRoles(token *idToken) (KeycloakRoles, error) {
+	claims := keycloakRoleClaims{}
+	if err := token.Claims(&claims); err != nil {
+		return KeycloakRoles{}, err
+	}
+
+	roles := KeycloakRoles{Realm: claims.RealmAccess.Roles, Clients: map[string][]string{}}
+	for clientId, access := range claims.ResourceAccess {
+		roles.Clients[clientId] = access.Roles
+	}
+
+	return roles, nil
+}
+
+// HasRealmRole はレルムロールroleを持つかどうかを返す
+func (roles KeycloakRoles) HasRealmRole(role string) bool {
+	return contains(roles.Realm, role)
+}
+
+// HasClientRole はクライアントclientIdのクライアントロールroleを持つかどうかを返す
+func (roles KeycloakRoles) HasClientRole(clientId string, role string) bool {
+	return contains(roles.Clients[clientId], role)
+}
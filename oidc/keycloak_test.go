@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewKeycloakOidcClient(t *testing.T) {
+	client := NewKeycloakOidcClient("https://keycloak.example.com/", "example")
+
+	assert.Equal(t, "https://keycloak.example.com/realms/example", client.Issuer)
+	assert.Equal(t, "https://keycloak.example.com/realms/example/protocol/openid-connect/auth", client.authEndpoint)
+	assert.Equal(t, "https://keycloak.example.com/realms/example/protocol/openid-connect/token", client.tokenEndpoint)
+	assert.Equal(t, "https://keycloak.example.com/realms/example/protocol/openid-connect/certs", client.JwksEndpoint)
+}
+
+func TestParseKeycloakRoles(t *testing.T) {
+	payload := validGooglePayloadForTest()
+	payload["realm_access"] = map[string]interface{}{"roles": []string{"offline_access", "admin"}}
+	payload["resource_access"] = map[string]interface{}{
+		"account": map[string]interface{}{"roles": []string{"manage-account", "view-profile"}},
+		"my-app":  map[string]interface{}{"roles": []string{"editor"}},
+	}
+	token, err := NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256"}, payload, hmacSignerForTest("unused")), Keycloak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := ParseKeycloakRoles(token)
+	assert.Nil(t, err)
+	assert.Equal(t, KeycloakRoles{
+		Realm: []string{"offline_access", "admin"},
+		Clients: map[string][]string{
+			"account": {"manage-account", "view-profile"},
+			"my-app":  {"editor"},
+		},
+	}, roles)
+
+	patterns := []struct {
+		desc     string
+		actual   bool
+		expected bool
+	}{
+		{"realm role", roles.HasRealmRole("admin"), true},
+		{"missing realm role", roles.HasRealmRole("editor"), false},
+		{"client role", roles.HasClientRole("my-app", "editor"), true},
+		{"role of another client", roles.HasClientRole("account", "editor"), false},
+		{"unknown client", roles.HasClientRole("unknown", "editor"), false},
+	}
+
+	for _, pattern := range patterns {
+		assert.Equal(t, pattern.expected, pattern.actual, pattern.desc)
+	}
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// lineVerifyEndpoint はLINEがid_tokenを検証してpayloadを返すエンドポイント
+//
+// refs: https://developers.line.biz/ja/reference/line-login/#verify-id-token
+const lineVerifyEndpoint = "https://api.line.me/oauth2/v2.1/verify"
+
+// LINEのid_tokenのamrクレームに入る認証方法
+//
+// refs: https://developers.line.biz/ja/docs/line-login/verify-id-token/#payload
+const (
+	// LineAmrPwd はメールアドレスとパスワードによるログイン
+	LineAmrPwd = "pwd"
+	// LineAmrSso はLINEのシングルサインオンによるログイン
+	LineAmrSso = "linesso"
+	// LineAmrAutoLogin はLINEアプリ内ブラウザでの自動ログイン
+	LineAmrAutoLogin = "lineautologin"
+	// LineAmrQr はQRコードによるログイン
+	LineAmrQr = "lineqr"
+	// LineAmrMfa は2要素認証によるログイン
+	LineAmrMfa = "mfa"
+)
+
+// LINEの認可リクエストのscope
+//
+// profileを指定するとid_tokenのname(表示名)とpicture(プロフィール画像のURL)が含まれ、
+// emailを指定するとemailが含まれる。emailはLINE Developersコンソールで申請が必要
+const (
+	LineScopeProfile = "profile"
+	LineScopeEmail   = "email"
+)
+
+// NewLineOidcClient はLINEログインのクライアントを返す
+//
+// LINEのid_tokenはウェブログインではチャネルシークレットを鍵としたHS256で署名されるため、HS256を受け入れる。
+// ネイティブアプリのログインなどJWKsの公開鍵で署名されたid_tokenも検証できる
+//
+// refs: https://developers.line.biz/ja/docs/line-login/integrate-line-login/
+func NewLineOidcClient(opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		Line,
+		"https://access.line.me",
+		os.Getenv("LINE_CHANNEL_ID"),
+		clientSecret(os.Getenv("LINE_CHANNEL_SECRET")),
+		"https://access.line.me/oauth2/v2.1/authorize",
+		"https://api.line.me/oauth2/v2.1/token",
+		"https://api.line.me/oauth2/v2.1/certs",
+		[]string{"ES256", "RS256"},
+	)
+	client.AllowHS256 = true
+	client.UserInfoEndpoint = "https://api.line.me/oauth2/v2.1/userinfo"
+	client.RevocationEndpoint = "https://api.line.me/oauth2/v2.1/revoke"
+	client.Apply(opts...)
+
+	return client
+}
+
+// VerifyLineIdToken はLINEの検証エンドポイントでid_tokenを検証し、payloadを返す
+//
+// クライアントサイドでLIFFなどから受け取ったid_tokenを、署名の鍵を持たずにサーバーで検証したい場合に使う。
+// nonceが空でない場合はid_tokenのnonceと一致するかもLINEが確認する
+func (c oidcClient) VerifyLineIdToken(ctx context.Context, rawIdToken string, nonce string) (*IdTokenClaims, error) {
+	values := url.Values{}
+	values.Set("id_token", rawIdToken)
+	values.Set("client_id", c.ClientId)
+	if nonce != "" {
+		values.Set("nonce", nonce)
+	}
+
+	claims := &IdTokenClaims{}
+	if err := c.postForm(ctx, lineVerifyEndpoint, values, claims); err != nil {
+		return nil, fmt.Errorf("failed to POST LINE verify endpoint: %w", err)
+	}
+
+	return claims, nil
+}
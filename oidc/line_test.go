@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewLineOidcClient_Verify(t *testing.T) {
+	client := NewLineOidcClient()
+	client.ClientId = "1234567890"
+	client.clientSecret = "DummyChannelSecret"
+	client.AuthnPolicy = AuthnPolicy{AmrMethods: []string{LineAmrPwd}}
+
+	payload := func(amr []string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":     "https://access.line.me",
+			"sub":     "U1234567890abcdef1234567890abcdef",
+			"aud":     "1234567890",
+			"exp":     time.Now().Add(time.Hour).Unix(),
+			"iat":     time.Now().Unix(),
+			"amr":     amr,
+			"name":    "Taro Line",
+			"picture": "https://profile.line-scdn.net/abcdefg",
+		}
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		payload       map[string]interface{}
+		secret        string
+	}{
+		{"HS256 signed by channel secret", true, payload([]string{LineAmrPwd}), "DummyChannelSecret"},
+		{"signed by another secret", false, payload([]string{LineAmrPwd}), "AnotherSecret"},
+		{"amr does not satisfy policy", false, payload([]string{LineAmrQr}), "DummyChannelSecret"},
+	}
+
+	for _, pattern := range patterns {
+		rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, pattern.payload, hmacSignerForTest(pattern.secret))
+		token, err := NewIdToken(rawToken, Line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Verifier().Verify(context.Background(), token)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "Taro Line", token.StandardClaims().Name, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_VerifyLineIdToken(t *testing.T) {
+	client := NewLineOidcClient()
+	client.ClientId = "1234567890"
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, lineVerifyEndpoint, func(req *http.Request) (*http.Response, error) {
+		if req.FormValue("nonce") != "DummyNonce" {
+			return httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "invalid_request", "error_description": "Invalid IdToken Nonce."}`), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"iss": "https://access.line.me", "sub": "U1234", "aud": "1234567890", "amr": ["linesso"], "nonce": "DummyNonce"}`), nil
+	})
+
+	claims, err := client.VerifyLineIdToken(context.Background(), "DummyIdToken", "DummyNonce")
+	assert.Nil(t, err)
+	assert.Equal(t, "U1234", claims.Sub)
+	assert.Equal(t, []string{LineAmrSso}, claims.Amr)
+
+	_, err = client.VerifyLineIdToken(context.Background(), "DummyIdToken", "AnotherNonce")
+	var tokenErr *OAuthError
+	assert.True(t, errors.As(err, &tokenErr))
+	assert.Equal(t, "invalid_request", tokenErr.Code)
+}
@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted はログでトークンやシークレットの値の代わりに出力する文字列
+const redacted = "[REDACTED]"
+
+// loggableParams はログにそのまま出力してよいリクエストパラメータ
+//
+// 新しいパラメータが増えても値が漏れないように、ここに含まれないパラメータの値はすべてredactedに置き換える
+var loggableParams = map[string]bool{
+	"grant_type":            true,
+	"redirect_uri":          true,
+	"scope":                 true,
+	"client_id":             true,
+	"response_type":         true,
+	"code_challenge_method": true,
+	"token_type_hint":       true,
+	"subject_token_type":    true,
+	"requested_token_type":  true,
+	"audience":              true,
+	"resource":              true,
+}
+
+// redactedValues はトークンやシークレットの値をマスクしてログに出力するurl.Values
+type redactedValues url.Values
+
+func (v redactedValues) LogValue() slog.Value {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		value := redacted
+		if loggableParams[key] {
+			value = strings.Join(v[key], " ")
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// loggerOrDiscard はloggerがnilの場合に何も出力しないLoggerを返す
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(discardHandler{})
+	}
+
+	return logger
+}
+
+// discardHandler は何も出力しないslog.Handler
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
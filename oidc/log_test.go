@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func loggerForTest() (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})), buf
+}
+
+func TestRedactedValues(t *testing.T) {
+	logger, buf := loggerForTest()
+	logger.Info("request", slog.Any("params", redactedValues(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"DummyCode"},
+		"client_secret": {"DummyClientSecret"},
+		"refresh_token": {"DummyRefreshToken"},
+		"unknown_param": {"DummyValue"},
+	})))
+
+	assert.Contains(t, buf.String(), `"grant_type":"authorization_code"`)
+	assert.Contains(t, buf.String(), `"code":"[REDACTED]"`)
+	for _, secret := range []string{"DummyCode", "DummyClientSecret", "DummyRefreshToken", "DummyValue"} {
+		assert.NotContains(t, buf.String(), secret)
+	}
+}
+
+func TestOidcClient_Logger_Token(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := newOidcClient(GenericOidc, "https://op.example.com", "DummyClientId", "DummyClientSecret", "", "https://op.example.com/token", "", nil)
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	logger, buf := loggerForTest()
+	client.Logger = logger
+
+	httpmock.RegisterResponder(http.MethodPost, "https://op.example.com/token", httpmock.NewStringResponder(http.StatusOK, `{"access_token": "DummyAccessToken"}`))
+	_, err := client.PostTokenEndpoint(context.Background(), "DummyCode", "https://rp.example.com/callback", "authorization_code")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "token request succeeded")
+
+	httpmock.RegisterResponder(http.MethodPost, "https://op.example.com/token", httpmock.NewStringResponder(http.StatusBadRequest, `{"error": "invalid_grant"}`))
+	_, err = client.PostTokenEndpoint(context.Background(), "DummyCode", "https://rp.example.com/callback", "authorization_code")
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "token request failed")
+	assert.Contains(t, buf.String(), "invalid_grant")
+
+	for _, secret := range []string{"DummyCode", "DummyClientSecret", "DummyAccessToken"} {
+		assert.NotContains(t, buf.String(), secret)
+	}
+}
+
+func TestOidcClient_Logger_Jwks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerJwksResponderForTest("max-age=3600")
+
+	logger, buf := loggerForTest()
+	cfg := httpConfig{logger: logger}
+	cache := NewJwksCache(time.Hour)
+	_, _ = cache.getOrFetch(context.Background(), cfg, testJwksUrl)
+	_, _ = cache.getOrFetch(context.Background(), cfg, testJwksUrl)
+
+	assert.Contains(t, buf.String(), "JWKs cache miss")
+	assert.Contains(t, buf.String(), "fetched JWKs")
+	assert.Contains(t, buf.String(), "JWKs cache hit")
+}
+
+func TestVerifier_Logger(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newVerifier(
+		Google,
+		newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+		"",
+		[]string{"RS256"},
+		false,
+		StaticKeys{"key-1": &rsaKey.PublicKey},
+	)
+	logger, buf := loggerForTest()
+	v.logger = logger
+
+	payload := validGooglePayloadForTest()
+	payload["exp"] = time.Now().Add(-time.Hour).Unix()
+	rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	_, err = v.VerifyRawToken(context.Background(), rawToken)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	assert.Contains(t, buf.String(), "id_token validation failed")
+	assert.Contains(t, buf.String(), `"kid":"key-1"`)
+	assert.NotContains(t, buf.String(), rawToken)
+}
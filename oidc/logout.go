@@ -0,0 +1,38 @@
+package oidc
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// EndSessionUrl はRP-Initiated LogoutでユーザーをIdPからもログアウトさせるためのURLを返す
+//
+// ローカルのセッションを破棄した後にこのURLにリダイレクトする。idTokenHintにはログイン時に受け取った生のid_tokenを渡す。
+// postLogoutRedirectUriはIdPに事前に登録したもので、空の場合はidTokenHint以外のパラメータを付けない
+//
+// refs: https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+func (c oidcClient) EndSessionUrl(idTokenHint string, postLogoutRedirectUri string, state string) (string, error) {
+	if c.EndSessionEndpoint == "" {
+		return "", errEndSessionMissing
+	}
+
+	endSessionUrl, err := url.Parse(c.EndSessionEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse end session endpoint: %w", err)
+	}
+
+	values := endSessionUrl.Query()
+	values.Set("client_id", c.ClientId)
+	if idTokenHint != "" {
+		values.Set("id_token_hint", idTokenHint)
+	}
+	if postLogoutRedirectUri != "" {
+		values.Set("post_logout_redirect_uri", postLogoutRedirectUri)
+		if state != "" {
+			values.Set("state", state)
+		}
+	}
+	endSessionUrl.RawQuery = values.Encode()
+
+	return endSessionUrl.String(), nil
+}
@@ -0,0 +1,56 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOidcClient_EndSessionUrl(t *testing.T) {
+	patterns := []struct {
+		desc                  string
+		endpoint              string
+		idTokenHint           string
+		postLogoutRedirectUri string
+		state                 string
+		expected              string
+	}{
+		{
+			"all parameters",
+			"https://example.com/logout",
+			"DummyIdToken",
+			"https://rp.example.com/logged-out",
+			"12345678",
+			"https://example.com/logout?client_id=client-1&id_token_hint=DummyIdToken&post_logout_redirect_uri=https%3A%2F%2Frp.example.com%2Flogged-out&state=12345678",
+		},
+		{
+			"state without redirect uri",
+			"https://example.com/logout",
+			"DummyIdToken",
+			"",
+			"12345678",
+			"https://example.com/logout?client_id=client-1&id_token_hint=DummyIdToken",
+		},
+		{
+			"endpoint with query",
+			"https://example.com/logout?tenant=abc",
+			"",
+			"",
+			"",
+			"https://example.com/logout?client_id=client-1&tenant=abc",
+		},
+	}
+
+	client := NewGoogleOidcClient()
+	client.ClientId = "client-1"
+	for _, pattern := range patterns {
+		client.EndSessionEndpoint = pattern.endpoint
+		actual, err := client.EndSessionUrl(pattern.idTokenHint, pattern.postLogoutRedirectUri, pattern.state)
+
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, pattern.expected, actual, pattern.desc)
+	}
+
+	client.EndSessionEndpoint = ""
+	_, err := client.EndSessionUrl("DummyIdToken", "", "")
+	assert.ErrorIs(t, err, errEndSessionMissing)
+}
@@ -0,0 +1,49 @@
+package oidc
+
+import "time"
+
+const (
+	// JwksCacheName と DiscoveryCacheName はMetricsRecorderのCacheAccessedに渡すキャッシュの名前
+	JwksCacheName      = "jwks"
+	DiscoveryCacheName = "discovery"
+)
+
+// MetricsRecorder はログインのフローの計測値を受け取る
+//
+// Prometheusなどの計測基盤に送る場合に実装し、oidcClientのMetricsとauthenticatorのMetricsに設定する。
+// 各メソッドはリクエストの処理中に呼ばれるので、ブロックしないようにする
+type MetricsRecorder interface {
+	// LoginStarted はユーザーをプロバイダの認可エンドポイントにリダイレクトしたときに呼ばれる
+	LoginStarted(provider string)
+	// LoginCompleted はコールバックでセッションを作成したときに呼ばれる
+	LoginCompleted(provider string)
+	// LoginFailed はコールバックでログインに失敗したときに呼ばれる
+	LoginFailed(provider string)
+	// TokenValidated はid_tokenの検証にかかった時間と結果を受け取る
+	TokenValidated(provider string, duration time.Duration, err error)
+	// JwksFetched はJWKsエンドポイントからの取得にかかった時間と結果を受け取る
+	JwksFetched(duration time.Duration, err error)
+	// CacheAccessed はJwksCacheNameかDiscoveryCacheNameのキャッシュを引いた結果を受け取る
+	CacheAccessed(cache string, hit bool)
+}
+
+// NopMetrics は何も記録しないMetricsRecorder
+var NopMetrics MetricsRecorder = nopMetrics{}
+
+type nopMetrics struct{}
+
+func (nopMetrics) LoginStarted(string)                         {}
+func (nopMetrics) LoginCompleted(string)                       {}
+func (nopMetrics) LoginFailed(string)                          {}
+func (nopMetrics) TokenValidated(string, time.Duration, error) {}
+func (nopMetrics) JwksFetched(time.Duration, error)            {}
+func (nopMetrics) CacheAccessed(string, bool)                  {}
+
+// metricsOrNop はmがnilの場合に何も記録しないMetricsRecorderを返す
+func metricsOrNop(m MetricsRecorder) MetricsRecorder {
+	if m == nil {
+		return NopMetrics
+	}
+
+	return m
+}
@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+)
+
+// tenantIdPlaceholder はマルチテナントのissuerのテナントIDの部分
+const tenantIdPlaceholder = "{tenantid}"
+
+// Microsoftのマルチテナント向けのテナント
+//
+// refs: https://learn.microsoft.com/ja-jp/entra/identity-platform/v2-protocols-oidc#find-your-apps-openid-configuration-document-uri
+const (
+	// MicrosoftTenantCommon は職場・学校アカウントと個人のMicrosoftアカウントの両方
+	MicrosoftTenantCommon = "common"
+	// MicrosoftTenantOrganizations は職場・学校アカウントのみ
+	MicrosoftTenantOrganizations = "organizations"
+	// MicrosoftTenantConsumers は個人のMicrosoftアカウントのみ
+	MicrosoftTenantConsumers = "consumers"
+)
+
+// tenantIdTokenPayload はテナントIDのクレームを持つマルチテナントのIdPのid_tokenのpayload
+type tenantIdTokenPayload interface {
+	tenantId() string
+}
+
+// microsoftIdTokenPayload はMicrosoft Entra IDのid_tokenのpayloadをunmarshalするための構造体
+//
+// refs: https://learn.microsoft.com/ja-jp/entra/identity-platform/id-token-claims-reference
+type microsoftIdTokenPayload struct {
+	IdTokenClaims
+	// Oid はテナント内でのユーザーのオブジェクトID。同じテナントのアプリ間で共通となる
+	Oid string `json:"oid"`
+	// Tid はユーザーが所属するテナントのID
+	Tid string `json:"tid"`
+	// PreferredUsername はユーザーのサインイン名。変更可能であり、ユーザーの識別子として使ってはならない
+	PreferredUsername string `json:"preferred_username"`
+}
+
+func (payload microsoftIdTokenPayload) tenantId() string {
+	return payload.Tid
+}
+
+// NewMicrosoftOidcClient はMicrosoft Entra ID(Azure AD)のv2.0エンドポイントのクライアントを返す
+//
+// tenantにはテナントIDもしくはMicrosoftTenantCommonなどを渡す。
+// テナントIDを指定しない場合はid_tokenのissにユーザーのテナントIDが入るため、tidクレームからissを組み立てて検証する
+//
+// Microsoftのemailクレームは所有が確認されていない場合があるので、ユーザーの識別にはsubかoidとtidを使う。
+// optsでclient_idなどの設定を変更できる
+func NewMicrosoftOidcClient(tenant string, opts ...ClientOption) *oidcClient {
+	issuerTenant := tenant
+	switch tenant {
+	case MicrosoftTenantCommon, MicrosoftTenantOrganizations, MicrosoftTenantConsumers:
+		issuerTenant = tenantIdPlaceholder
+	}
+
+	baseUrl := fmt.Sprintf("https://login.microsoftonline.com/%s", tenant)
+	client := newOidcClient(
+		Microsoft,
+		fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", issuerTenant),
+		os.Getenv("MICROSOFT_CLIENT_ID"),
+		clientSecret(os.Getenv("MICROSOFT_CLIENT_SECRET")),
+		baseUrl+"/oauth2/v2.0/authorize",
+		baseUrl+"/oauth2/v2.0/token",
+		baseUrl+"/discovery/v2.0/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = "https://graph.microsoft.com/oidc/userinfo"
+	client.EndSessionEndpoint = baseUrl + "/oauth2/v2.0/logout"
+	client.DeviceAuthEndpoint = baseUrl + "/oauth2/v2.0/devicecode"
+	client.Apply(opts...)
+
+	return client
+}
@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewMicrosoftOidcClient_Verify(t *testing.T) {
+	const (
+		tenantId        = "9122040d-6c67-4c5b-b112-36a304b66dad"
+		anotherTenantId = "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := func(iss string, tid string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                iss,
+			"sub":                "AAAAAAAAAAAAAAAAAAAAAIkzqFVrSaSaFHy782bbtaQ",
+			"aud":                "DummyClientId",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"iat":                time.Now().Unix(),
+			"oid":                "00000000-0000-0000-66f3-3332eca7ea81",
+			"tid":                tid,
+			"preferred_username": "abeli@microsoft.com",
+		}
+	}
+	issuerOf := func(tid string) string {
+		return "https://login.microsoftonline.com/" + tid + "/v2.0"
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		tenant        string
+		payload       map[string]interface{}
+	}{
+		{"common", true, MicrosoftTenantCommon, payload(issuerOf(tenantId), tenantId)},
+		{"organizations", true, MicrosoftTenantOrganizations, payload(issuerOf(anotherTenantId), anotherTenantId)},
+		{"common with tid mismatch", false, MicrosoftTenantCommon, payload(issuerOf(tenantId), anotherTenantId)},
+		{"common without tid", false, MicrosoftTenantCommon, payload(issuerOf(tenantId), "")},
+		{"placeholder in iss", false, MicrosoftTenantCommon, payload(issuerOf(tenantIdPlaceholder), "")},
+		{"single tenant", true, tenantId, payload(issuerOf(tenantId), tenantId)},
+		{"single tenant with another tenant", false, tenantId, payload(issuerOf(anotherTenantId), anotherTenantId)},
+	}
+
+	for _, pattern := range patterns {
+		client := NewMicrosoftOidcClient(pattern.tenant)
+		client.ClientId = "DummyClientId"
+		client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+
+		rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, pattern.payload, rsaSignerForTest(rsaKey))
+		token, err := NewIdToken(rawToken, Microsoft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Verifier().Verify(context.Background(), token)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			microsoftPayload, ok := token.Payload.(*microsoftIdTokenPayload)
+			assert.True(t, ok, pattern.desc)
+			assert.Equal(t, "00000000-0000-0000-66f3-3332eca7ea81", microsoftPayload.Oid, pattern.desc)
+			assert.Equal(t, "abeli@microsoft.com", microsoftPayload.PreferredUsername, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidIssuer, pattern.desc)
+		}
+	}
+}
+
+func TestNewMicrosoftOidcClient_Endpoints(t *testing.T) {
+	client := NewMicrosoftOidcClient(MicrosoftTenantCommon, WithClientId("DummyClientId"))
+
+	assert.Equal(t, "DummyClientId", client.ClientId)
+	assert.Equal(t, "https://login.microsoftonline.com/{tenantid}/v2.0", client.Issuer)
+	assert.Equal(t, "https://login.microsoftonline.com/common/oauth2/v2.0/authorize", client.authEndpoint)
+	assert.Equal(t, "https://login.microsoftonline.com/common/oauth2/v2.0/token", client.tokenEndpoint)
+	assert.Equal(t, "https://login.microsoftonline.com/common/discovery/v2.0/keys", client.JwksEndpoint)
+}
+
+func TestNewMicrosoftOidcClient_ValidateIss(t *testing.T) {
+	const tenantId = "9122040d-6c67-4c5b-b112-36a304b66dad"
+
+	// JARMやログアウトトークン、フロントチャネルログアウトのissも、マルチテナントのissuerで確認できる
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		tenant        string
+		iss           string
+	}{
+		{"common", true, MicrosoftTenantCommon, "https://login.microsoftonline.com/" + tenantId + "/v2.0"},
+		{"common with another host", false, MicrosoftTenantCommon, "https://login.example.com/" + tenantId + "/v2.0"},
+		{"common with empty tenant", false, MicrosoftTenantCommon, "https://login.microsoftonline.com//v2.0"},
+		{"common with path in tenant", false, MicrosoftTenantCommon, "https://login.microsoftonline.com/" + tenantId + "/x/v2.0"},
+		{"placeholder in iss", false, MicrosoftTenantCommon, "https://login.microsoftonline.com/{tenantid}/v2.0"},
+		{"single tenant", true, tenantId, "https://login.microsoftonline.com/" + tenantId + "/v2.0"},
+		{"single tenant with another tenant", false, tenantId, "https://login.microsoftonline.com/72f988bf-86f1-41af-91ab-2d7cd011db47/v2.0"},
+	}
+
+	for _, pattern := range patterns {
+		err := NewMicrosoftOidcClient(pattern.tenant).Verifier().claims.validateIss(pattern.iss)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidIssuer, pattern.desc)
+		}
+	}
+}
+
+func TestVerifier_TenantClaims(t *testing.T) {
+	v := NewMicrosoftOidcClient(MicrosoftTenantCommon).Verifier()
+	token := func(tid string) *idToken {
+		return &idToken{Payload: &microsoftIdTokenPayload{Tid: tid}}
+	}
+
+	// tidがある場合はissのテナントIDと一致させる
+	claims := v.tenantClaims(token("9122040d-6c67-4c5b-b112-36a304b66dad"))
+	assert.Nil(t, claims.validateIss("https://login.microsoftonline.com/9122040d-6c67-4c5b-b112-36a304b66dad/v2.0"))
+	assert.ErrorIs(t, claims.validateIss("https://login.microsoftonline.com/72f988bf-86f1-41af-91ab-2d7cd011db47/v2.0"), ErrInvalidIssuer)
+
+	// tidがない場合はプレースホルダのまま任意のテナントIDと一致させる
+	claims = v.tenantClaims(token(""))
+	assert.Nil(t, claims.validateIss("https://login.microsoftonline.com/72f988bf-86f1-41af-91ab-2d7cd011db47/v2.0"))
+}
@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+// nonceBytes はnonceとして生成する乱数のバイト数
+const nonceBytes = 32
+
+// RandomNonce はリプレイ攻撃の対策に使うためのnonceを返す
+//
+// 認可リクエストに含めたnonceはid_tokenのnonceクレームにそのまま入って返ってくるので、
+// セッションに保存しておき、VerifyNonceで一致するかを確認する
+func RandomNonce() (string, error) {
+	nonce, err := randomToken(nonceBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+// randomToken はnバイトの乱数をbase64urlエンコードした文字列を返す
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// VerifyNonce はid_tokenのnonceクレームが認可リクエストに含めたnonceと一致するかを確認する
+func (token *idToken) VerifyNonce(expected string) error {
+	if expected == "" {
+		return errNonceMissing
+	}
+
+	actual := token.Payload.standardClaims().Nonce
+	if subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) != 1 {
+		return ErrNonceMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRandomNonce(t *testing.T) {
+	nonce, err := RandomNonce()
+	assert.Nil(t, err)
+	assert.Len(t, nonce, 43)
+
+	another, err := RandomNonce()
+	assert.Nil(t, err)
+	assert.NotEqual(t, nonce, another)
+}
+
+func TestIdToken_VerifyNonce(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		nonce    string
+		expected string
+		err      error
+	}{
+		{"match", "n-0S6_WzA2Mj", "n-0S6_WzA2Mj", nil},
+		{"mismatch", "n-0S6_WzA2Mj", "another", ErrNonceMismatch},
+		{"nonce claim missing", "", "n-0S6_WzA2Mj", ErrNonceMismatch},
+		{"expected nonce empty", "", "", errNonceMissing},
+	}
+
+	for _, pattern := range patterns {
+		token := &idToken{Payload: &IdTokenClaims{Nonce: pattern.nonce}}
+		err := token.VerifyNonce(pattern.expected)
+
+		if pattern.err == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// OktaDefaultAuthServer はOktaのorgに最初から用意されているカスタム認可サーバーのID
+const OktaDefaultAuthServer = "default"
+
+// oktaIssuer はOktaのissuerを返す
+//
+// authServerIdが空の場合はorg認可サーバー、それ以外はカスタム認可サーバーのissuerになる
+//
+// refs: https://developer.okta.com/docs/concepts/auth-servers/
+func oktaIssuer(domain string, authServerId string) string {
+	orgUrl := "https://" + strings.TrimSuffix(domain, "/")
+	if authServerId == "" {
+		return orgUrl
+	}
+
+	return orgUrl + "/oauth2/" + authServerId
+}
+
+// oktaEndpointBase はエンドポイントのURLの共通部分を返す
+//
+// org認可サーバーのエンドポイントは/oauth2/v1配下にあり、issuerとパスが異なる
+func oktaEndpointBase(domain string, authServerId string) string {
+	if authServerId == "" {
+		return oktaIssuer(domain, "") + "/oauth2/v1"
+	}
+
+	return oktaIssuer(domain, authServerId) + "/v1"
+}
+
+// NewOktaOidcClient はOktaのクライアントを返す
+//
+// authServerIdを空にするとorg認可サーバー、OktaDefaultAuthServerなどを渡すとカスタム認可サーバーを使う。
+// org認可サーバーのアクセストークンはOktaのAPI以外では検証できないので、
+// 自前のAPIのためのアクセストークンが必要な場合はカスタム認可サーバーを使う
+func NewOktaOidcClient(domain string, authServerId string, opts ...ClientOption) *oidcClient {
+	base := oktaEndpointBase(domain, authServerId)
+	client := newOidcClient(
+		Okta,
+		oktaIssuer(domain, authServerId),
+		os.Getenv("OKTA_CLIENT_ID"),
+		clientSecret(os.Getenv("OKTA_CLIENT_SECRET")),
+		base+"/authorize",
+		base+"/token",
+		base+"/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = base + "/userinfo"
+	client.IntrospectionEndpoint = base + "/introspect"
+	client.RevocationEndpoint = base + "/revoke"
+	client.EndSessionEndpoint = base + "/logout"
+	client.DeviceAuthEndpoint = base + "/device/authorize"
+	client.Apply(opts...)
+
+	return client
+}
+
+// DiscoverOktaOidcClient はOktaの認可サーバーのDiscoveryドキュメントの内容で設定したクライアントを返す
+func DiscoverOktaOidcClient(ctx context.Context, domain string, authServerId string) (*oidcClient, error) {
+	metadata, err := DiscoverProvider(ctx, oktaIssuer(domain, authServerId))
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.NewOidcClient(Okta, os.Getenv("OKTA_CLIENT_ID"), os.Getenv("OKTA_CLIENT_SECRET")), nil
+}
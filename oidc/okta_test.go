@@ -0,0 +1,51 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewOktaOidcClient(t *testing.T) {
+	patterns := []struct {
+		desc             string
+		authServerId     string
+		expectedIssuer   string
+		expectedAuthUrl  string
+		expectedJwksUrl  string
+		expectedTokenUrl string
+	}{
+		{
+			"org authorization server",
+			"",
+			"https://example.okta.com",
+			"https://example.okta.com/oauth2/v1/authorize",
+			"https://example.okta.com/oauth2/v1/keys",
+			"https://example.okta.com/oauth2/v1/token",
+		},
+		{
+			"default custom authorization server",
+			OktaDefaultAuthServer,
+			"https://example.okta.com/oauth2/default",
+			"https://example.okta.com/oauth2/default/v1/authorize",
+			"https://example.okta.com/oauth2/default/v1/keys",
+			"https://example.okta.com/oauth2/default/v1/token",
+		},
+		{
+			"custom authorization server",
+			"aus1a2b3c4d5e6f7g8h9",
+			"https://example.okta.com/oauth2/aus1a2b3c4d5e6f7g8h9",
+			"https://example.okta.com/oauth2/aus1a2b3c4d5e6f7g8h9/v1/authorize",
+			"https://example.okta.com/oauth2/aus1a2b3c4d5e6f7g8h9/v1/keys",
+			"https://example.okta.com/oauth2/aus1a2b3c4d5e6f7g8h9/v1/token",
+		},
+	}
+
+	for _, pattern := range patterns {
+		client := NewOktaOidcClient("example.okta.com", pattern.authServerId)
+
+		assert.Equal(t, pattern.expectedIssuer, client.Issuer, pattern.desc)
+		assert.Equal(t, pattern.expectedAuthUrl, client.authEndpoint, pattern.desc)
+		assert.Equal(t, pattern.expectedJwksUrl, client.JwksEndpoint, pattern.desc)
+		assert.Equal(t, pattern.expectedTokenUrl, client.tokenEndpoint, pattern.desc)
+	}
+}
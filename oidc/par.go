@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parResponse はPARエンドポイントのレスポンス
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc9126#section-2.2
+type parResponse struct {
+	RequestUri string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// PushAuthRequest は認可リクエストのパラメータをPARエンドポイントに送り、request_uriだけを含む認可エンドポイントのURLを返す
+//
+// パラメータがブラウザを経由しないため改竄や漏洩を防げる。FAPIではPARが必須となる
+func (c oidcClient) PushAuthRequest(
+	ctx context.Context,
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts ...AuthCodeOption,
+) (string, error) {
+	if c.ParEndpoint == "" {
+		return "", errParEndpointMissing
+	}
+
+	values, err := c.parValues(respType, scopes, redirectUrl, state, nonce, opts)
+	if err != nil {
+		return "", err
+	}
+	resp := parResponse{}
+	if err := c.postFormWithClientAuth(ctx, c.ParEndpoint, values, &resp); err != nil {
+		return "", fmt.Errorf("failed to POST pushed authorization request endpoint: %w", err)
+	}
+	if resp.RequestUri == "" {
+		return "", errRequestUriMissing
+	}
+
+	return fmt.Sprintf(
+		"%s?client_id=%s&request_uri=%s",
+		c.authEndpoint,
+		url.QueryEscape(c.ClientId),
+		url.QueryEscape(resp.RequestUri),
+	), nil
+}
+
+// parValues はPARエンドポイントに送る認可リクエストのパラメータを返す
+//
+// RequestObjectSignerが設定されている場合はパラメータをリクエストオブジェクトにまとめる
+func (c oidcClient) parValues(
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts []AuthCodeOption,
+) (url.Values, error) {
+	if c.RequestObjectSigner != nil {
+		requestObject, err := c.RequestObject(respType, scopes, redirectUrl, state, nonce, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return url.Values{"request": {requestObject}}, nil
+	}
+
+	values := c.authRequestExtras(nonce, opts)
+	values.Set("response_type", respType)
+	values.Set("scope", strings.Join(scopes, " "))
+	values.Set("redirect_uri", redirectUrl)
+	values.Set("state", state)
+
+	return values, nil
+}
+
+// AuthorizationUrl は認可エンドポイントのURLを返す
+//
+// DiscoveryドキュメントなどでPARエンドポイントが設定されている場合はPARを使い、そうでない場合はAuthUrlと同じURLを返す
+func (c oidcClient) AuthorizationUrl(
+	ctx context.Context,
+	respType string,
+	scopes []string,
+	redirectUrl string,
+	state string,
+	nonce string,
+	opts ...AuthCodeOption,
+) (string, error) {
+	if c.ParEndpoint == "" {
+		return c.AuthUrl(respType, scopes, redirectUrl, state, nonce, opts...), nil
+	}
+
+	return c.PushAuthRequest(ctx, respType, scopes, redirectUrl, state, nonce, opts...)
+}
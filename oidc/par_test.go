@@ -0,0 +1,59 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+const testParEndpoint = "https://example.com/par"
+
+func TestOidcClient_AuthorizationUrl(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	httpmock.RegisterResponder(http.MethodPost, testParEndpoint, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = req.PostForm
+
+		return httpmock.NewStringResponse(
+			http.StatusCreated,
+			`{"request_uri": "urn:ietf:params:oauth:request_uri:6esc_11ACC5bwc014ltc14eY22c", "expires_in": 60}`,
+		), nil
+	})
+
+	client := NewGoogleOidcClient()
+	client.ParEndpoint = testParEndpoint
+	authUrl, err := client.AuthorizationUrl(
+		context.Background(),
+		"code",
+		[]string{"openid", "email"},
+		"http://localhost:8000/callback",
+		"12345678",
+		"n-0S6_WzA2Mj",
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"https://accounts.google.com/o/oauth2/v2/auth?client_id=&request_uri=urn%3Aietf%3Aparams%3Aoauth%3Arequest_uri%3A6esc_11ACC5bwc014ltc14eY22c",
+		authUrl,
+	)
+	assert.Equal(t, "openid email", form.Get("scope"))
+	assert.Equal(t, "http://localhost:8000/callback", form.Get("redirect_uri"))
+	assert.Equal(t, "12345678", form.Get("state"))
+	assert.Equal(t, "n-0S6_WzA2Mj", form.Get("nonce"))
+
+	// PARエンドポイントがない場合は通常の認可リクエストのURLを返す
+	client.ParEndpoint = ""
+	authUrl, err = client.AuthorizationUrl(context.Background(), "code", []string{"openid"}, "", "12345678", "")
+	assert.Nil(t, err)
+	assert.Equal(t, client.AuthUrl("code", []string{"openid"}, "", "12345678", ""), authUrl)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// PayPalの環境ごとのURL
+const (
+	paypalApiUrl        = "https://api-m.paypal.com"
+	paypalSandboxApiUrl = "https://api-m.sandbox.paypal.com"
+)
+
+// paypalProvider はLog in with PayPalのプロバイダ
+//
+// PayPalのid_tokenは検証に必要な情報が揃っていないため、アクセストークンでIdentity APIからユーザーの情報を取得する
+type paypalProvider struct {
+	*oidcClient
+	// ApiUrl はREST APIのベースURL
+	ApiUrl string
+}
+
+// paypalUserInfo はIdentity APIのuserinfoのレスポンス
+//
+// refs: https://developer.paypal.com/docs/api/identity/v1/#userinfo_get
+type paypalUserInfo struct {
+	// UserId はPayPalのユーザーの識別子
+	UserId string `json:"user_id"`
+	Name   string `json:"name"`
+	// PayerId は決済で使われるアカウントのID
+	PayerId string `json:"payer_id"`
+	Emails  []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+		// Confirmed はメールアドレスの所有が確認されているかどうか
+		Confirmed bool `json:"confirmed"`
+	} `json:"emails"`
+	// VerifiedAccount はPayPalのアカウントが確認済みかどうか
+	VerifiedAccount bool `json:"verified_account"`
+}
+
+// NewPayPalProvider はLog in with PayPalのプロバイダを返す
+//
+// sandboxがtrueの場合はSandbox環境のエンドポイントを使う。
+// Developer Dashboardでアプリの「Log in with PayPal」を有効にし、取得する属性を選んでおく必要がある
+//
+// refs: https://developer.paypal.com/docs/log-in-with-paypal/integrate/
+func NewPayPalProvider(sandbox bool, opts ...ClientOption) *paypalProvider {
+	authUrl := "https://www.paypal.com/signin/authorize"
+	apiUrl := paypalApiUrl
+	if sandbox {
+		authUrl = "https://www.sandbox.paypal.com/signin/authorize"
+		apiUrl = paypalSandboxApiUrl
+	}
+
+	client := newOidcClient(
+		PayPal,
+		"",
+		os.Getenv("PAYPAL_CLIENT_ID"),
+		clientSecret(os.Getenv("PAYPAL_CLIENT_SECRET")),
+		authUrl,
+		apiUrl+"/v1/oauth2/token",
+		"",
+		nil,
+	)
+	client.Scopes = []string{"openid", "email", "profile"}
+	client.ClientAuthMethod = ClientSecretBasic
+	client.Apply(opts...)
+
+	return &paypalProvider{oidcClient: client, ApiUrl: apiUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、Identity APIからユーザーの情報を取得する
+//
+// nonceは使われない。メールアドレスは確認済みのプライマリアドレスを優先してセットする
+func (p paypalProvider) Login(ctx context.Context, code string, _ string, opts ...AuthCodeOption) (*User, error) {
+	tokenResp, err := p.postAuthorizationCode(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	token := newToken(tokenResp)
+
+	info := paypalUserInfo{}
+	if err := p.getJson(ctx, p.ApiUrl+"/v1/identity/oauth2/userinfo?schema=paypalv1.1", token.AccessToken, &info); err != nil {
+		return nil, fmt.Errorf("failed to GET PayPal userinfo: %w", err)
+	}
+
+	user := &User{
+		IdProvider: PayPal,
+		Sub:        info.UserId,
+		Name:       info.Name,
+		Token:      token,
+	}
+	for _, email := range info.Emails {
+		if !email.Confirmed {
+			continue
+		}
+		if user.Email == "" || email.Primary {
+			user.Email = email.Value
+			user.EmailVerified = true
+		}
+		if email.Primary {
+			break
+		}
+	}
+
+	return user, nil
+}
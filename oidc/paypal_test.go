@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var _ Provider = paypalProvider{}
+
+func TestPayPalProvider_Login(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		emails        string
+		expectedEmail string
+	}{
+		{
+			"confirmed primary email",
+			`[{"value": "other@example.com", "primary": false, "confirmed": true}, {"value": "user@example.com", "primary": true, "confirmed": true}]`,
+			"user@example.com",
+		},
+		{
+			"unconfirmed primary email",
+			`[{"value": "other@example.com", "primary": false, "confirmed": true}, {"value": "user@example.com", "primary": true, "confirmed": false}]`,
+			"other@example.com",
+		},
+		{
+			"no confirmed email",
+			`[{"value": "user@example.com", "primary": true, "confirmed": false}]`,
+			"",
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		provider := NewPayPalProvider(true)
+		provider.ClientId = "DummyClientId"
+		provider.clientSecret = "DummyClientSecret"
+		provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, paypalSandboxApiUrl+"/v1/oauth2/token", func(req *http.Request) (*http.Response, error) {
+			_, _, ok := req.BasicAuth()
+			assert.True(t, ok, pattern.desc)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken", "token_type": "Bearer", "expires_in": 28800}`), nil
+		})
+		httpmock.RegisterResponder(http.MethodGet, paypalSandboxApiUrl+"/v1/identity/oauth2/userinfo", httpmock.NewStringResponder(
+			http.StatusOK,
+			`{"user_id": "https://www.paypal.com/webapps/auth/identity/user/mWq6_1sU85v5EG9yHdPxJRrhGHrnMJ-1PQKtX6pcsmA", "name": "Taro PayPal", "payer_id": "WDJJHEBZ4X2LY", "emails": `+pattern.emails+`}`,
+		))
+
+		user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, &User{
+			IdProvider:    PayPal,
+			Sub:           "https://www.paypal.com/webapps/auth/identity/user/mWq6_1sU85v5EG9yHdPxJRrhGHrnMJ-1PQKtX6pcsmA",
+			Email:         pattern.expectedEmail,
+			EmailVerified: pattern.expectedEmail != "",
+			Name:          "Taro PayPal",
+			Token:         user.Token,
+		}, user, pattern.desc)
+	}
+}
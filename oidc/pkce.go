@@ -0,0 +1,78 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+)
+
+const (
+	// codeVerifierBytes はcode_verifierとして生成する乱数のバイト数。base64urlエンコードすると43文字になる
+	codeVerifierBytes = 32
+	pkceMethodS256    = "S256"
+)
+
+// Pkce は認可コードの横取り攻撃を防ぐためのPKCEのcode_verifierとcode_challenge
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7636
+type Pkce struct {
+	// Verifier はリダイレクトを跨いで保存し、トークンリクエストで送る値
+	Verifier string
+	// Challenge は認可リクエストに含める値
+	Challenge string
+	Method    string
+}
+
+// NewPkce はランダムなcode_verifierと、それをS256でハッシュ化したcode_challengeを返す
+func NewPkce() (Pkce, error) {
+	verifier, err := randomToken(codeVerifierBytes)
+	if err != nil {
+		return Pkce{}, fmt.Errorf("failed to generate code_verifier: %w", err)
+	}
+
+	return Pkce{Verifier: verifier, Challenge: codeChallengeS256(verifier), Method: pkceMethodS256}, nil
+}
+
+func codeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// WithCodeChallenge は認可リクエストにcode_challengeを含める
+//
+// LoginUrlはPKCEを自動で生成するので、独自に生成したPkceを使う場合にのみ渡す
+func WithCodeChallenge(pkce Pkce) AuthCodeOption {
+	return func(values url.Values) {
+		values.Set("code_challenge", pkce.Challenge)
+		values.Set("code_challenge_method", pkce.Method)
+	}
+}
+
+// newPkceUnlessSet はoptsにcode_challengeが含まれずPKCEを無効にしていない場合に、新しいPkceを生成して返す
+//
+// Pkceを生成しなかった場合はゼロ値を返す
+func (c oidcClient) newPkceUnlessSet(opts []AuthCodeOption) (Pkce, error) {
+	if c.DisablePkce || hasParam(opts, "code_challenge") {
+		return Pkce{}, nil
+	}
+
+	return NewPkce()
+}
+
+// checkCodeVerifier はPKCEを無効にしていない場合に、optsにcode_verifierが含まれるかを確認する
+func (c oidcClient) checkCodeVerifier(opts []AuthCodeOption) error {
+	if c.DisablePkce || hasParam(opts, "code_verifier") {
+		return nil
+	}
+
+	return errCodeVerifierMissing
+}
+
+// WithCodeVerifier はトークンリクエストにcode_verifierを含める
+func WithCodeVerifier(verifier string) AuthCodeOption {
+	return func(values url.Values) {
+		values.Set("code_verifier", verifier)
+	}
+}
@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCodeChallengeS256(t *testing.T) {
+	// refs: https://datatracker.ietf.org/doc/html/rfc7636#appendix-B
+	actual := codeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", actual)
+}
+
+func TestNewPkce(t *testing.T) {
+	pkce, err := NewPkce()
+
+	assert.Nil(t, err)
+	assert.Len(t, pkce.Verifier, 43)
+	assert.Equal(t, codeChallengeS256(pkce.Verifier), pkce.Challenge)
+	assert.Equal(t, "S256", pkce.Method)
+}
+
+func TestOidcClient_AuthUrl_Pkce(t *testing.T) {
+	client := NewGoogleOidcClient()
+	pkce := Pkce{Verifier: "verifier", Challenge: "challenge", Method: "S256"}
+
+	authUrl, err := url.Parse(client.AuthUrl("code", []string{"openid"}, "", "12345678", "", WithCodeChallenge(pkce)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "challenge", authUrl.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", authUrl.Query().Get("code_challenge_method"))
+}
+
+func TestOidcClient_PostTokenEndpoint_CodeVerifier(t *testing.T) {
+	client := NewGoogleOidcClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var codeVerifier string
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		codeVerifier = req.PostForm.Get("code_verifier")
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"access_token": "DummyAccessToken"}`), nil
+	})
+
+	_, err := client.PostTokenEndpoint(context.Background(), "", "", "authorization_code", WithCodeVerifier("verifier"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "verifier", codeVerifier)
+}
+
+func TestOidcClient_Exchange_RequiresCodeVerifier(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		disablePkce   bool
+		opts          []AuthCodeOption
+		expectedErr   error
+		expectedCalls int
+	}{
+		{"code_verifier missing", false, nil, errCodeVerifierMissing, 0},
+		{"empty code_verifier", false, []AuthCodeOption{WithCodeVerifier("")}, errCodeVerifierMissing, 0},
+		{"PKCE disabled", true, nil, nil, 1},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		client := NewGoogleOidcClient()
+		client.DisablePkce = pattern.disablePkce
+		client.Retry = RetryPolicy{MaxAttempts: 1}
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(http.StatusBadRequest, `{"error": "invalid_grant"}`))
+
+		_, err := client.Exchange(context.Background(), "DummyCode", pattern.opts...)
+		if pattern.expectedErr != nil {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		} else {
+			// PKCEを無効にした場合はcode_verifierなしでトークンエンドポイントにリクエストする
+			var oauthErr *OAuthError
+			assert.ErrorAs(t, err, &oauthErr, pattern.desc)
+		}
+		assert.Equal(t, pattern.expectedCalls, httpmock.GetTotalCallCount(), pattern.desc)
+	}
+}
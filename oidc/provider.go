@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"context"
+)
+
+// Provider はSNSログインのプロバイダ
+//
+// OIDCのIdPとOAuth 2.0のみに対応したSNSを同じように扱えるようにする。
+// LoginUrlでユーザーをリダイレクトし、コールバックで受け取った認可コードをLoginに渡す
+type Provider interface {
+	// LoginUrl はユーザーをリダイレクトする認可エンドポイントのURLと、PKCEのcode_verifierとcode_challengeを返す
+	//
+	// OIDCのIdPの場合はnonceが必須で、OAuth 2.0のみのプロバイダの場合は使われない。
+	// 返されたPkceのVerifierはリダイレクトを跨いで保存し、LoginにWithCodeVerifierで渡す。
+	// PKCEを無効にした場合や、optsにWithCodeChallengeを渡した場合はゼロ値のPkceを返す
+	LoginUrl(state string, nonce string, opts ...AuthCodeOption) (string, Pkce, error)
+	// Login は認可コードをトークンに交換し、ログインしたユーザーの情報を返す
+	//
+	// nonceにはLoginUrlに渡したものを渡す。PKCEを無効にしていない場合はWithCodeVerifierが必須
+	Login(ctx context.Context, code string, nonce string, opts ...AuthCodeOption) (*User, error)
+	// Idp はログインに使うIdProviderを返す
+	//
+	// MetricsRecorderに渡すプロバイダの名前にはこのString()を使い、id_tokenの検証の計測値と揃える
+	Idp() IdProvider
+}
+
+// User はプロバイダによらない形に揃えたログインしたユーザーの情報
+type User struct {
+	IdProvider IdProvider
+	// Sub はプロバイダ内でのユーザーの識別子。メールアドレスではなくこちらで識別する
+	Sub   string
+	Email string
+	// EmailVerified はプロバイダがメールアドレスの所有を確認済みかどうか
+	EmailVerified bool
+	Name          string
+	// Username はXの@以降のハンドル名など、プロバイダ内で表示に使われるユーザー名。変更される可能性があるので識別には使わない
+	Username string
+	// Picture はプロフィール画像のURL
+	Picture string
+	// Token はログインで取得したトークン。リフレッシュや失効に使う
+	Token *Token
+}
+
+// newUserFromClaims はid_tokenもしくはUserInfoのクレームからUserを作る
+func newUserFromClaims(idProvider IdProvider, claims IdTokenClaims, token *Token) *User {
+	return &User{
+		IdProvider:    idProvider,
+		Sub:           claims.Sub,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+		Token:         token,
+	}
+}
+
+// Idp はクライアントのIdProviderを返す
+func (c oidcClient) Idp() IdProvider {
+	return c.IdProvider
+}
+
+// LoginUrl はScopesとRedirectUrlで認可コードフローの認可エンドポイントのURLを返す
+//
+// DisablePkceがfalseの場合はPKCEのcode_verifierを生成し、code_challengeを認可リクエストに含める
+func (c oidcClient) LoginUrl(state string, nonce string, opts ...AuthCodeOption) (string, Pkce, error) {
+	pkce, err := c.newPkceUnlessSet(opts)
+	if err != nil {
+		return "", Pkce{}, err
+	}
+	if pkce.Verifier != "" {
+		opts = append(opts, WithCodeChallenge(pkce))
+	}
+
+	return c.AuthUrl("code", c.Scopes, c.RedirectUrl, state, nonce, opts...), pkce, nil
+}
+
+// Login は認可コードを交換してid_tokenを検証し、nonceを確認してユーザーの情報を返す
+//
+// id_tokenにメールアドレスが含まれずUserInfoEndpointが設定されている場合は、UserInfoから取得する
+func (c oidcClient) Login(ctx context.Context, code string, nonce string, opts ...AuthCodeOption) (*User, error) {
+	token, err := c.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.IdToken.VerifyNonce(nonce); err != nil {
+		return nil, err
+	}
+
+	claims := token.IdToken.StandardClaims()
+	if claims.Email == "" && c.UserInfoEndpoint != "" {
+		info, err := c.UserInfo(ctx, token.AccessToken, token.IdToken)
+		if err != nil {
+			return nil, err
+		}
+		claims = info.StandardClaims()
+	}
+
+	return newUserFromClaims(c.IdProvider, claims, token), nil
+}
+
+// NewProviderFromIssuer はissuerのDiscoveryドキュメントから設定したプロバイダを返す
+//
+// プリセットのないOIDCに準拠したIdPを使う場合に使う。scopesを省略した場合はopenid、email、profileを要求し、
+// openidが含まれない場合は先頭に追加する。その他の設定も指定する場合はNewFromIssuerを使う
+func NewProviderFromIssuer(
+	ctx context.Context,
+	issuer string,
+	clientId string,
+	clientSecret string,
+	redirectUrl string,
+	scopes ...string,
+) (*oidcClient, error) {
+	opts := []ClientOption{WithClientId(clientId), WithClientSecret(clientSecret), WithRedirectUrl(redirectUrl)}
+	if len(scopes) > 0 {
+		opts = append(opts, WithScopes(scopes...))
+	}
+
+	return NewFromIssuer(ctx, issuer, opts...)
+}
@@ -0,0 +1,197 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+var _ Provider = oidcClient{}
+
+func TestOidcClient_Login(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	idTokenForTest := func(email string) string {
+		payload := validGooglePayloadForTest()
+		payload["nonce"] = "DummyNonce"
+		if email != "" {
+			payload["email"] = email
+			payload["email_verified"] = true
+		}
+
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		idToken       string
+		nonce         string
+		expectedEmail string
+	}{
+		{"email in id_token", true, idTokenForTest("user@example.com"), "DummyNonce", "user@example.com"},
+		{"email from userinfo", true, idTokenForTest(""), "DummyNonce", "userinfo@example.com"},
+		{"nonce mismatch", false, idTokenForTest("user@example.com"), "AnotherNonce", ""},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, httpmock.NewStringResponder(
+			http.StatusOK,
+			`{"access_token": "DummyAccessToken", "id_token": "`+pattern.idToken+`"}`,
+		))
+		httpmock.RegisterResponder(http.MethodGet, client.UserInfoEndpoint, httpmock.NewStringResponder(
+			http.StatusOK,
+			`{"sub": "1234567890", "email": "userinfo@example.com", "email_verified": true}`,
+		))
+
+		user, err := client.Login(context.Background(), "DummyCode", pattern.nonce, WithCodeVerifier("DummyCodeVerifier"))
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, Google, user.IdProvider, pattern.desc)
+			assert.Equal(t, "1234567890", user.Sub, pattern.desc)
+			assert.Equal(t, pattern.expectedEmail, user.Email, pattern.desc)
+			assert.True(t, user.EmailVerified, pattern.desc)
+			assert.Equal(t, "DummyAccessToken", user.Token.AccessToken, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, ErrNonceMismatch, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_LoginUrl(t *testing.T) {
+	callerPkce, err := NewPkce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc              string
+		disablePkce       bool
+		opts              []AuthCodeOption
+		isExpectGenerated bool
+		expectedChallenge string
+	}{
+		{"PKCE by default", false, nil, true, ""},
+		{"PKCE disabled", true, nil, false, ""},
+		{"challenge given by caller", false, []AuthCodeOption{WithCodeChallenge(callerPkce)}, false, callerPkce.Challenge},
+	}
+
+	for _, pattern := range patterns {
+		client := NewGoogleOidcClient()
+		client.ClientId = "client-1"
+		client.RedirectUrl = "https://rp.example.com/callback"
+		client.DisablePkce = pattern.disablePkce
+
+		rawUrl, pkce, err := client.LoginUrl("12345678", "DummyNonce", pattern.opts...)
+		assert.Nil(t, err, pattern.desc)
+		loginUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := loginUrl.Query()
+		assert.Equal(t, "12345678", query.Get("state"), pattern.desc)
+		assert.Equal(t, "DummyNonce", query.Get("nonce"), pattern.desc)
+		if pattern.isExpectGenerated {
+			assert.NotEmpty(t, pkce.Verifier, pattern.desc)
+			assert.Equal(t, codeChallengeS256(pkce.Verifier), query.Get("code_challenge"), pattern.desc)
+			assert.Equal(t, pkceMethodS256, query.Get("code_challenge_method"), pattern.desc)
+		} else {
+			assert.Equal(t, Pkce{}, pkce, pattern.desc)
+			assert.Equal(t, pattern.expectedChallenge, query.Get("code_challenge"), pattern.desc)
+		}
+	}
+}
+
+func TestNewProviderFromIssuer(t *testing.T) {
+	const issuer = "https://op.example.com"
+
+	patterns := []struct {
+		desc               string
+		scopes             []string
+		authMethods        string
+		expectedScopes     []string
+		expectedAuthMethod ClientAuthMethod
+	}{
+		{"default scopes", nil, `["client_secret_post", "client_secret_basic"]`, []string{"openid", "email", "profile"}, ClientSecretPost},
+		{"openid added", []string{"email"}, `["client_secret_basic"]`, []string{"openid", "email"}, ClientSecretBasic},
+		{"openid included", []string{"email", "openid"}, `[]`, []string{"email", "openid"}, ClientSecretPost},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		defaultDiscoveryCache.Purge()
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodGet, issuer+"/.well-known/openid-configuration", httpmock.NewStringResponder(http.StatusOK, `{
+  "issuer": "https://op.example.com",
+  "authorization_endpoint": "https://op.example.com/authorize",
+  "token_endpoint": "https://op.example.com/token",
+  "jwks_uri": "https://op.example.com/jwks",
+  "token_endpoint_auth_methods_supported": `+pattern.authMethods+`
+}`))
+
+		client, err := NewProviderFromIssuer(
+			context.Background(),
+			issuer,
+			"DummyClientId",
+			"DummyClientSecret",
+			"https://rp.example.com/callback",
+			pattern.scopes...,
+		)
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, GenericOidc, client.IdProvider, pattern.desc)
+		assert.Equal(t, "https://rp.example.com/callback", client.RedirectUrl, pattern.desc)
+		assert.Equal(t, pattern.expectedScopes, client.Scopes, pattern.desc)
+		assert.Equal(t, pattern.expectedAuthMethod, client.ClientAuthMethod, pattern.desc)
+	}
+}
+
+func TestNewFromIssuer(t *testing.T) {
+	const issuer = "https://op.example.com"
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	defaultDiscoveryCache.Purge()
+	httpmock.RegisterResponder(http.MethodGet, issuer+"/.well-known/openid-configuration", httpmock.NewStringResponder(http.StatusOK, `{
+  "issuer": "https://op.example.com",
+  "authorization_endpoint": "https://op.example.com/authorize",
+  "token_endpoint": "https://op.example.com/token",
+  "jwks_uri": "https://op.example.com/jwks",
+  "token_endpoint_auth_methods_supported": ["client_secret_basic"]
+}`))
+
+	_, err := NewFromIssuer(context.Background(), issuer)
+	assert.ErrorIs(t, err, errClientConfigMissing)
+
+	// オプションはDiscoveryドキュメントの内容を設定した後に適用する
+	client, err := NewFromIssuer(
+		context.Background(),
+		issuer,
+		WithIdProvider(Keycloak),
+		WithClientId("DummyClientId"),
+		WithScopes("email"),
+		WithClientAuthMethod(ClientSecretPost),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, Keycloak, client.IdProvider)
+	assert.Equal(t, "https://op.example.com/token", client.tokenEndpoint)
+	assert.Equal(t, []string{"openid", "email"}, client.Scopes)
+	assert.Equal(t, ClientSecretPost, client.ClientAuthMethod)
+}
@@ -0,0 +1,124 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Refresh はリフレッシュトークンを使ってトークンエンドポイントからトークンを取得し直す
+//
+// リフレッシュトークンがローテーションされた場合は新しいリフレッシュトークンを、
+// されなかった場合は渡されたリフレッシュトークンをそのままTokenに入れて返す。
+// レスポンスにid_tokenが含まれる場合は検証し、iss, sub, audなどがpreviousと同じかを確認する。
+// previousはログイン時などに取得した元のid_tokenで、レスポンスにid_tokenが含まれる場合は必須
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#RefreshTokens
+func (c oidcClient) Refresh(ctx context.Context, refreshToken string, previous *idToken) (*Token, error) {
+	if refreshToken == "" {
+		return nil, errRefreshTokenMissing
+	}
+
+	values := url.Values{}
+	values.Add("grant_type", "refresh_token")
+	values.Add("refresh_token", refreshToken)
+	tokenResp, err := c.postToken(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var token *Token
+	if tokenResp.IdToken == "" {
+		token = newToken(tokenResp)
+	} else {
+		token, err = c.verifyRefreshResponse(ctx, tokenResp, previous)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+// verifyRefreshResponse はリフレッシュで発行されたid_tokenを検証する
+//
+// auth_timeは元の認証の時刻のままなので、max_ageを過ぎても拒否しないようにmax_ageとauth_timeの必須チェックは行わない。
+// id_tokenをAPIの認証情報として受け付けるわけではないので、ReplayCacheによる再利用の確認も行わない
+func (c oidcClient) verifyRefreshResponse(ctx context.Context, tokenResp tokenResponse, previous *idToken) (*Token, error) {
+	if previous == nil {
+		return nil, errPreviousIdTokenMissing
+	}
+
+	idToken, err := NewIdToken(tokenResp.IdToken, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse id_token: %w", err)
+	}
+	v := c.Verifier()
+	v.claims.maxAge = 0
+	v.claims.requireAuthTime = false
+	v.replayCache = nil
+	if err := v.Verify(ctx, idToken); err != nil {
+		return nil, err
+	}
+	if err := validateRefreshedClaims(previous.StandardClaims(), idToken.StandardClaims()); err != nil {
+		return nil, err
+	}
+	if err := idToken.VerifyAccessToken(tokenResp.AccessToken); err != nil {
+		return nil, err
+	}
+
+	token := newToken(tokenResp)
+	token.IdToken = idToken
+
+	return token, nil
+}
+
+// validateRefreshedClaims はリフレッシュで発行されたid_tokenが元のid_tokenと同じ認証を表しているかを確認する
+//
+// iss, sub, aud, azpは同じ値でなければならず、auth_timeとnonceは含まれる場合のみ元の値と比較する
+func validateRefreshedClaims(previous IdTokenClaims, refreshed IdTokenClaims) error {
+	mismatch := func(claim string, expected interface{}, actual interface{}) error {
+		return fmt.Errorf("%w: %s %v != %v", errRefreshedTokenMismatch, claim, expected, actual)
+	}
+
+	if refreshed.Iss != previous.Iss {
+		return mismatch("iss", previous.Iss, refreshed.Iss)
+	}
+	if refreshed.Sub != previous.Sub {
+		return mismatch("sub", previous.Sub, refreshed.Sub)
+	}
+	if !sameAudience(previous.Aud, refreshed.Aud) {
+		return mismatch("aud", []string(previous.Aud), []string(refreshed.Aud))
+	}
+	if refreshed.Azp != previous.Azp {
+		return mismatch("azp", previous.Azp, refreshed.Azp)
+	}
+	if refreshed.AuthTime != 0 && previous.AuthTime != 0 && refreshed.AuthTime != previous.AuthTime {
+		return mismatch("auth_time", previous.AuthTime, refreshed.AuthTime)
+	}
+	if refreshed.Nonce != "" && refreshed.Nonce != previous.Nonce {
+		return mismatch("nonce", previous.Nonce, refreshed.Nonce)
+	}
+
+	return nil
+}
+
+// sameAudience はaとbが順序を除いて同じ値を含むかを返す
+func sameAudience(a Audience, b Audience) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	return strings.Join(sortedA, " ") == strings.Join(sortedB, " ")
+}
@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_Refresh(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	// auth_timeは元の認証の時刻のままなので、max_ageを過ぎていてもリフレッシュできる
+	client.MaxAge = time.Minute
+	authTime := time.Now().Add(-time.Hour).Unix()
+
+	idTokenForTest := func(override map[string]interface{}) string {
+		payload := validGooglePayloadForTest()
+		payload["auth_time"] = authTime
+		for key, value := range override {
+			payload[key] = value
+		}
+
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	}
+	previous, err := NewIdToken(idTokenForTest(nil), Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validIdToken := idTokenForTest(nil)
+
+	patterns := []struct {
+		desc                 string
+		isExpectValid        bool
+		refreshToken         string
+		previous             *idToken
+		body                 string
+		expectedRefreshToken string
+	}{
+		{
+			"rotated refresh token",
+			true,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "refresh_token": "NewRefreshToken", "expires_in": 3600}`,
+			"NewRefreshToken",
+		},
+		{
+			"refresh token not rotated",
+			true,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "expires_in": 3600}`,
+			"OldRefreshToken",
+		},
+		{
+			"with id_token",
+			true,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "id_token": "` + validIdToken + `"}`,
+			"OldRefreshToken",
+		},
+		{
+			"invalid id_token",
+			false,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "id_token": "invalid"}`,
+			"",
+		},
+		{
+			"sub mismatch",
+			false,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "id_token": "` + idTokenForTest(map[string]interface{}{"sub": "another"}) + `"}`,
+			"",
+		},
+		{
+			"auth_time changed",
+			false,
+			"OldRefreshToken",
+			previous,
+			`{"access_token": "NewAccessToken", "id_token": "` + idTokenForTest(map[string]interface{}{"auth_time": time.Now().Unix()}) + `"}`,
+			"",
+		},
+		{
+			"original id_token missing",
+			false,
+			"OldRefreshToken",
+			nil,
+			`{"access_token": "NewAccessToken", "id_token": "` + validIdToken + `"}`,
+			"",
+		},
+		{
+			"empty refresh token",
+			false,
+			"",
+			previous,
+			`{"access_token": "NewAccessToken"}`,
+			"",
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		var grantType, refreshToken string
+		httpmock.Reset()
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+			if err := req.ParseForm(); err != nil {
+				return nil, err
+			}
+			grantType = req.PostForm.Get("grant_type")
+			refreshToken = req.PostForm.Get("refresh_token")
+
+			return httpmock.NewStringResponse(http.StatusOK, pattern.body), nil
+		})
+
+		token, err := client.Refresh(context.Background(), pattern.refreshToken, pattern.previous)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "refresh_token", grantType, pattern.desc)
+			assert.Equal(t, pattern.refreshToken, refreshToken, pattern.desc)
+			assert.Equal(t, "NewAccessToken", token.AccessToken, pattern.desc)
+			assert.Equal(t, pattern.expectedRefreshToken, token.RefreshToken, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestValidateRefreshedClaims(t *testing.T) {
+	previous := IdTokenClaims{Iss: "https://example.com", Sub: "user-1", Aud: Audience{"client-1", "client-2"}, Azp: "client-1", AuthTime: 100, Nonce: "nonce"}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		modify        func(claims *IdTokenClaims)
+	}{
+		{"same claims", true, func(claims *IdTokenClaims) {}},
+		{"aud in another order", true, func(claims *IdTokenClaims) { claims.Aud = Audience{"client-2", "client-1"} }},
+		{"auth_time and nonce omitted", true, func(claims *IdTokenClaims) { claims.AuthTime, claims.Nonce = 0, "" }},
+		{"iss mismatch", false, func(claims *IdTokenClaims) { claims.Iss = "https://another.example.com" }},
+		{"sub mismatch", false, func(claims *IdTokenClaims) { claims.Sub = "user-2" }},
+		{"aud mismatch", false, func(claims *IdTokenClaims) { claims.Aud = Audience{"client-1"} }},
+		{"azp mismatch", false, func(claims *IdTokenClaims) { claims.Azp = "client-2" }},
+		{"auth_time mismatch", false, func(claims *IdTokenClaims) { claims.AuthTime = 200 }},
+		{"nonce mismatch", false, func(claims *IdTokenClaims) { claims.Nonce = "another" }},
+	}
+
+	for _, pattern := range patterns {
+		refreshed := previous
+		refreshed.Aud = append(Audience{}, previous.Aud...)
+		pattern.modify(&refreshed)
+		err := validateRefreshedClaims(previous, refreshed)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errRefreshedTokenMismatch, pattern.desc)
+		}
+	}
+}
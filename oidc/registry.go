@@ -0,0 +1,65 @@
+package oidc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registry は名前でProviderを引けるようにする
+//
+// /auth/{provider}/loginのようなルートでパスの名前からプロバイダを選ぶ場合に使い、
+// プロバイダごとにハンドラを書いたりswitch文で分岐したりしなくて済むようにする
+type registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewRegistry は空のregistryを返す
+func NewRegistry() *registry {
+	return &registry{providers: map[string]Provider{}}
+}
+
+// Register はnameでproviderを登録する。同じ名前が登録済みの場合はエラーを返す
+func (r *registry) Register(name string, provider Provider) error {
+	if name == "" || provider == nil {
+		return fmt.Errorf("failed to register provider: name and provider are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("%w: %s", errProviderDuplicated, name)
+	}
+	r.providers[name] = provider
+
+	return nil
+}
+
+// Lookup はnameで登録されたProviderを返す
+func (r *registry) Lookup(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errProviderNotFound, name)
+	}
+
+	return provider, nil
+}
+
+// Names は登録されたプロバイダの名前を辞書順で返す。ログインボタンの一覧を表示する場合などに使う
+func (r *registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,42 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	google := NewGoogleOidcClient()
+	github := NewGitHubProvider()
+
+	assert.Nil(t, registry.Register("google", google))
+	assert.Nil(t, registry.Register("github", github))
+	assert.ErrorIs(t, registry.Register("google", NewGoogleOidcClient()), errProviderDuplicated)
+	assert.Error(t, registry.Register("", google))
+	assert.Error(t, registry.Register("nil", nil))
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		name          string
+		expected      Provider
+	}{
+		{"oidc provider", true, "google", google},
+		{"oauth provider", true, "github", github},
+		{"not registered", false, "facebook", nil},
+	}
+
+	for _, pattern := range patterns {
+		provider, err := registry.Lookup(pattern.name)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Same(t, pattern.expected, provider, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errProviderNotFound, pattern.desc)
+		}
+	}
+
+	assert.Equal(t, []string{"github", "google"}, registry.Names())
+}
@@ -0,0 +1,112 @@
+package oidc
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ReplayCache は検証済みのid_tokenのjtiを記録し、同じトークンの再利用を検出するストレージ
+//
+// id_tokenをAPIの認証情報として直接受け付ける場合に、漏洩したトークンを使い回されないようにする。
+// 複数のインスタンスで動かす場合は、全インスタンスで共有できるストレージを実装する
+type ReplayCache interface {
+	// Add はkeyをexpiresAtまで記録する。すでに記録されている場合はfalseを返す
+	//
+	// 同時に同じkeyで呼ばれても、trueを返すのは1回だけになるように実装する
+	Add(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+}
+
+// defaultMaxReplayEntries はmemoryReplayCacheに記録できるjtiの数の既定値
+const defaultMaxReplayEntries = 100000
+
+// memoryReplayCache はjtiをメモリに記録するReplayCache
+type memoryReplayCache struct {
+	// MaxEntries は記録できる数。上限に達した場合は期限切れのものを削除し、それでも空きがなければAddがエラーを返す
+	MaxEntries int
+	mu         sync.Mutex
+	entries    map[string]time.Time
+	// expiries は期限切れのエントリを早い順に取り除くためのヒープ
+	expiries replayExpiries
+	now      func() time.Time
+}
+
+// NewMemoryReplayCache はjtiをメモリに記録するReplayCacheを返す
+//
+// 記録はプロセス内でしか共有されないので、複数のインスタンスで動かす場合は共有のストレージを使う
+func NewMemoryReplayCache() *memoryReplayCache {
+	return &memoryReplayCache{MaxEntries: defaultMaxReplayEntries, entries: map[string]time.Time{}, now: time.Now}
+}
+
+func (c *memoryReplayCache) Add(_ context.Context, key string, expiresAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 期限切れのエントリが溜まり続けないように、書き込みのたびに期限の早いものから取り除く
+	now := c.now()
+	for len(c.expiries) > 0 && !now.Before(c.expiries[0].expiresAt) {
+		expired := heap.Pop(&c.expiries).(replayEntry)
+		delete(c.entries, expired.key)
+	}
+
+	if _, ok := c.entries[key]; ok {
+		return false, nil
+	}
+	if len(c.entries) >= c.MaxEntries {
+		return false, errReplayCacheFull
+	}
+	c.entries[key] = expiresAt
+	heap.Push(&c.expiries, replayEntry{key: key, expiresAt: expiresAt})
+
+	return true, nil
+}
+
+type replayEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// replayExpiries はexpiresAtが早い順に取り出せるreplayEntryのヒープ
+type replayExpiries []replayEntry
+
+func (e replayExpiries) Len() int           { return len(e) }
+func (e replayExpiries) Less(i, j int) bool { return e[i].expiresAt.Before(e[j].expiresAt) }
+func (e replayExpiries) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+func (e *replayExpiries) Push(x interface{}) {
+	*e = append(*e, x.(replayEntry))
+}
+
+func (e *replayExpiries) Pop() interface{} {
+	old := *e
+	entry := old[len(old)-1]
+	*e = old[:len(old)-1]
+
+	return entry
+}
+
+// checkReplay はid_tokenのjtiをReplayCacheに記録し、すでに使われたトークンであればエラーを返す
+//
+// jtiはIdPごとに一意なので、issと組み合わせたものをキーにする。
+// expを過ぎたトークンはclaimsの検証で拒否されるので、exp+leewayまで記録しておけば十分
+func (v verifier) checkReplay(ctx context.Context, claims IdTokenClaims) error {
+	if v.replayCache == nil {
+		return nil
+	}
+	if claims.Jti == "" {
+		return errJtiMissing
+	}
+
+	expiresAt := time.Unix(claims.Exp, 0).Add(v.claims.leeway)
+	ok, err := v.replayCache.Add(ctx, claims.Iss+" "+claims.Jti, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to record id_token jti: %w", err)
+	}
+	if !ok {
+		return ErrTokenReplayed
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayCache_Add(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryReplayCache()
+	cache.now = func() time.Time { return now }
+
+	ok, err := cache.Add(context.Background(), "jti-1", now.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, _ = cache.Add(context.Background(), "jti-1", now.Add(time.Minute))
+	assert.False(t, ok)
+
+	ok, _ = cache.Add(context.Background(), "jti-2", now.Add(time.Minute))
+	assert.True(t, ok)
+
+	// 期限が過ぎたエントリは取り除かれる
+	now = now.Add(time.Minute)
+	ok, _ = cache.Add(context.Background(), "jti-1", now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Len(t, cache.entries, 1)
+	assert.Len(t, cache.expiries, 1)
+}
+
+func TestMemoryReplayCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewMemoryReplayCache()
+	cache.MaxEntries = 2
+	cache.now = func() time.Time { return now }
+
+	_, _ = cache.Add(ctx, "jti-1", now.Add(2*time.Minute))
+	_, _ = cache.Add(ctx, "jti-2", now.Add(time.Minute))
+	_, err := cache.Add(ctx, "jti-3", now.Add(time.Minute))
+	assert.ErrorIs(t, err, errReplayCacheFull)
+
+	// 上限に達していても、記録済みのkeyはエラーにせずfalseを返す
+	ok, err := cache.Add(ctx, "jti-1", now.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// 期限が早いものから取り除かれて空きができる
+	now = now.Add(time.Minute)
+	ok, err = cache.Add(ctx, "jti-3", now.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, _ = cache.Add(ctx, "jti-1", now.Add(time.Minute))
+	assert.False(t, ok)
+}
+
+func TestVerifier_Verify_ReplayCache(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newVerifier(
+		Google,
+		newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+		"",
+		[]string{"RS256"},
+		false,
+		StaticKeys{"key-1": &rsaKey.PublicKey},
+	)
+	v.replayCache = NewMemoryReplayCache()
+
+	rawTokenForTest := func(jti string, exp time.Time) string {
+		payload := validGooglePayloadForTest()
+		payload["exp"] = exp.Unix()
+		if jti != "" {
+			payload["jti"] = jti
+		}
+
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	}
+	valid := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+
+	patterns := []struct {
+		desc        string
+		rawToken    string
+		expectedErr error
+	}{
+		{"first use", rawTokenForTest("jti-1", valid), nil},
+		{"replayed", rawTokenForTest("jti-1", valid), ErrTokenReplayed},
+		{"another jti", rawTokenForTest("jti-2", valid), nil},
+		{"jti missing", rawTokenForTest("", valid), errJtiMissing},
+		// 検証に失敗したトークンのjtiは記録しない
+		{"expired", rawTokenForTest("jti-3", expired), ErrTokenExpired},
+		{"after failed verification", rawTokenForTest("jti-3", valid), nil},
+	}
+
+	for _, pattern := range patterns {
+		_, err := v.VerifyRawToken(context.Background(), pattern.rawToken)
+
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
+	defaultRetryJitter         = 0.2
+)
+
+// defaultRetryableStatusCodes はリトライするステータスコード。IdP側の一時的な障害を表すもの
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// singleUseGrantTypes はトークンエンドポイントに1度しか使えない値を送るgrant_type
+//
+// IdPが処理した後にレスポンスだけが失われた場合、送り直すと値が使用済みになっておりinvalid_grantになる。
+// リフレッシュトークンもローテーションするIdPでは1度しか使えない
+var singleUseGrantTypes = map[string]bool{
+	"authorization_code": true,
+	"refresh_token":      true,
+	deviceCodeGrantType:  true,
+	cibaGrantType:        true,
+}
+
+// RetryPolicy はIdPへのリクエストのリトライの設定。0の項目はデフォルト値を使う
+//
+// リトライしない場合はMaxAttemptsを1にする。
+// 接続の失敗やタイムアウトなどの通信エラーとRetryableStatusCodesのステータスコードのみをリトライする
+type RetryPolicy struct {
+	// MaxAttempts は最初のリクエストを含めた最大の試行回数
+	MaxAttempts int
+	// InitialBackoff は1回目のリトライまでの待ち時間。以降は2倍ずつ増える
+	InitialBackoff time.Duration
+	// MaxBackoff はリトライまでの待ち時間の上限
+	MaxBackoff time.Duration
+	// Jitter は待ち時間をランダムにずらす割合。0.2の場合は待ち時間の±20%の範囲でずらす
+	Jitter float64
+	// RetryableStatusCodes はリトライするレスポンスのステータスコード
+	RetryableStatusCodes []int
+	// RetrySingleUseGrants は認可コードやリフレッシュトークンなど、1度しか使えない値を送るトークンリクエストもリトライするかどうか
+	//
+	// 既定ではリトライしない。IdPが同じ値の再送を受け付ける場合にのみ有効にする
+	RetrySingleUseGrants bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryMaxAttempts
+	}
+	p.InitialBackoff = durationOrDefault(p.InitialBackoff, defaultRetryInitialBackoff)
+	p.MaxBackoff = durationOrDefault(p.MaxBackoff, defaultRetryMaxBackoff)
+	if p.Jitter <= 0 {
+		p.Jitter = defaultRetryJitter
+	}
+	if p.RetryableStatusCodes == nil {
+		p.RetryableStatusCodes = defaultRetryableStatusCodes
+	}
+
+	return p
+}
+
+// forGrant はgrantTypeのトークンリクエストに使うリトライの設定を返す
+//
+// 1度しか使えない値を送るリクエストは、RetrySingleUseGrantsが指定されていない限りリトライしない
+func (p RetryPolicy) forGrant(grantType string) RetryPolicy {
+	if singleUseGrantTypes[grantType] && !p.RetrySingleUseGrants {
+		p.MaxAttempts = 1
+	}
+
+	return p
+}
+
+// shouldRetry は一時的な通信エラーもしくはリトライ対象のステータスコードの場合にtrueを返す
+func (p RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return isTransientError(err)
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTransientError は送り直せば成功する可能性のある通信エラーかを返す
+//
+// 接続の失敗やタイムアウトのみを対象とし、HttpClientの設定の誤り、証明書の検証エラー、
+// レスポンスが大きすぎる場合、UrlPolicyで拒否された場合などは送り直しても結果が変わらないのでリトライしない
+func isTransientError(err error) bool {
+	if errors.Is(err, errPrivateAddress) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// url.Errorもnet.Errorを実装しているので、その内側のnet.OpErrorで接続や読み書きの失敗かを判別する
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr)
+}
+
+// backoff はattempt回目の試行が失敗した後に待つ時間を返す
+//
+// レスポンスにRetry-Afterヘッダ(秒数)がある場合はそれに従う。いずれの場合もMaxBackoffを上限とする
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec >= 0 {
+			return minDuration(time.Duration(sec)*time.Second, p.MaxBackoff)
+		}
+	}
+
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = minDuration(d, p.MaxBackoff)
+
+	// 複数のクライアントが同時にリトライしてIdPに負荷が集中しないようにずらす。暗号論的な乱数である必要はない
+	jitter := (rand.Float64()*2 - 1) * p.Jitter * float64(d)
+
+	return minDuration(d+time.Duration(jitter), p.MaxBackoff)
+}
+
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// sleepWithContext はdだけ待つ。ctxがキャンセルされた場合は待たずにエラーを返す
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,184 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Jitter:         0.1,
+	}.withDefaults()
+
+	patterns := []struct {
+		desc     string
+		attempt  int
+		resp     *http.Response
+		expected time.Duration
+	}{
+		{"first retry", 1, nil, 100 * time.Millisecond},
+		{"second retry", 2, nil, 200 * time.Millisecond},
+		{"third retry", 3, nil, 400 * time.Millisecond},
+		{"capped by max backoff", 10, nil, time.Second},
+		{"retry-after", 1, &http.Response{Header: http.Header{"Retry-After": {"0"}}}, 0},
+	}
+
+	for _, pattern := range patterns {
+		actual := policy.backoff(pattern.attempt, pattern.resp)
+
+		jitter := time.Duration(float64(pattern.expected) * policy.Jitter)
+		assert.GreaterOrEqual(t, int64(actual), int64(pattern.expected-jitter), pattern.desc)
+		assert.LessOrEqual(t, int64(actual), int64(pattern.expected+jitter), pattern.desc)
+		assert.LessOrEqual(t, int64(actual), int64(policy.MaxBackoff), pattern.desc)
+	}
+}
+
+func TestOidcClient_Retry(t *testing.T) {
+	patterns := []struct {
+		desc            string
+		retry           RetryPolicy
+		responses       []*http.Response
+		expectedIdToken string
+		expectedCalls   int
+	}{
+		{
+			"do not retry authorization code by default",
+			RetryPolicy{InitialBackoff: time.Millisecond},
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+				httpmock.NewStringResponse(http.StatusOK, `{"id_token": "DummyIdToken"}`),
+			},
+			"",
+			1,
+		},
+		{
+			"retry 5xx and succeed",
+			RetryPolicy{InitialBackoff: time.Millisecond, RetrySingleUseGrants: true},
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+				httpmock.NewStringResponse(http.StatusOK, `{"id_token": "DummyIdToken"}`),
+			},
+			"DummyIdToken",
+			2,
+		},
+		{
+			"give up after max attempts",
+			RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetrySingleUseGrants: true},
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusBadGateway, ""),
+				httpmock.NewStringResponse(http.StatusBadGateway, ""),
+				httpmock.NewStringResponse(http.StatusOK, `{"id_token": "DummyIdToken"}`),
+			},
+			"",
+			2,
+		},
+		{
+			"do not retry 4xx",
+			RetryPolicy{InitialBackoff: time.Millisecond, RetrySingleUseGrants: true},
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "invalid_grant"}`),
+				httpmock.NewStringResponse(http.StatusOK, `{"id_token": "DummyIdToken"}`),
+			},
+			"",
+			1,
+		},
+		{
+			"retry disabled",
+			RetryPolicy{MaxAttempts: 1},
+			[]*http.Response{
+				httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+				httpmock.NewStringResponse(http.StatusOK, `{"id_token": "DummyIdToken"}`),
+			},
+			"",
+			1,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		client := NewGoogleOidcClient()
+		client.Retry = pattern.retry
+
+		httpmock.Reset()
+		var bodies []string
+		responder := httpmock.ResponderFromMultipleResponses(pattern.responses)
+		httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+			// リトライ時にもボディが送られていることを確認する
+			if err := req.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			bodies = append(bodies, req.PostForm.Get("code"))
+
+			return responder(req)
+		})
+
+		tokenResp, _ := client.PostTokenEndpoint(context.Background(), "code", "", "authorization_code")
+
+		assert.Equal(t, pattern.expectedIdToken, tokenResp.IdToken, pattern.desc)
+		assert.Equal(t, pattern.expectedCalls, httpmock.GetTotalCallCount(), pattern.desc)
+		for _, body := range bodies {
+			assert.Equal(t, "code", body, pattern.desc)
+		}
+	}
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	dialErr := &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	timeoutErr := &url.Error{Op: "Post", URL: "https://example.com", Err: &net.DNSError{IsTimeout: true}}
+	privateErr := &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errPrivateAddress}}
+	certErr := &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("x509: certificate signed by unknown authority")}
+
+	patterns := []struct {
+		desc     string
+		resp     *http.Response
+		err      error
+		expected bool
+	}{
+		{"dial failure", nil, fmt.Errorf("failed to send request: %w", dialErr), true},
+		{"timeout", nil, fmt.Errorf("failed to send request: %w", timeoutErr), true},
+		{"private address", nil, fmt.Errorf("failed to send request: %w", privateErr), false},
+		{"certificate error", nil, fmt.Errorf("failed to send request: %w", certErr), false},
+		{"response too large", nil, fmt.Errorf("%w: exceeds 1 bytes", errResponseTooLarge), false},
+		{"invalid transport config", nil, errors.New("failed to parse proxy url"), false},
+		{"retryable status", &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		{"client error", &http.Response{StatusCode: http.StatusBadRequest}, nil, false},
+	}
+
+	policy := RetryPolicy{}.withDefaults()
+	for _, pattern := range patterns {
+		assert.Equal(t, pattern.expected, policy.shouldRetry(pattern.resp, pattern.err), pattern.desc)
+	}
+}
+
+func TestRetryPolicy_ForGrant(t *testing.T) {
+	patterns := []struct {
+		desc                string
+		retrySingleUseGrant bool
+		grantType           string
+		expected            int
+	}{
+		{"authorization code", false, "authorization_code", 1},
+		{"refresh token", false, "refresh_token", 1},
+		{"device code", false, deviceCodeGrantType, 1},
+		{"ciba", false, cibaGrantType, 1},
+		{"client credentials", false, "client_credentials", 3},
+		{"opt in", true, "authorization_code", 3},
+	}
+
+	for _, pattern := range patterns {
+		policy := RetryPolicy{RetrySingleUseGrants: pattern.retrySingleUseGrant}.withDefaults().forGrant(pattern.grantType)
+		assert.Equal(t, pattern.expected, policy.MaxAttempts, pattern.desc)
+	}
+}
@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Revoke はトークン失効エンドポイントにリフレッシュトークンもしくはアクセストークンの失効を要求する
+//
+// ログアウト時にIdP側のトークンも無効にするために使う。tokenTypeHintは"refresh_token"や"access_token"で、空の場合は送らない。
+// 既に無効なトークンに対してもIdPは成功を返すので、失敗した場合のみエラーを返す
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7009
+func (c oidcClient) Revoke(ctx context.Context, token string, tokenTypeHint string) error {
+	if c.RevocationEndpoint == "" {
+		return errRevocationMissing
+	}
+
+	values := url.Values{}
+	values.Set("token", token)
+	if tokenTypeHint != "" {
+		values.Set("token_type_hint", tokenTypeHint)
+	}
+	if err := c.postFormWithClientAuth(ctx, c.RevocationEndpoint, values, nil); err != nil {
+		return fmt.Errorf("failed to POST revocation endpoint: %w", err)
+	}
+	// 失効させたトークンがキャッシュにより有効と判定され続けないようにする
+	c.IntrospectionCache.delete(token)
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_Revoke(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		status        int
+		body          string
+		tokenTypeHint string
+	}{
+		{"refresh token", true, http.StatusOK, "", "refresh_token"},
+		{"without hint", true, http.StatusOK, "", ""},
+		{"unsupported_token_type", false, http.StatusBadRequest, `{"error": "unsupported_token_type"}`, "access_token"},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		pattern := pattern
+		httpmock.RegisterResponder(http.MethodPost, client.RevocationEndpoint, func(req *http.Request) (*http.Response, error) {
+			assert.Nil(t, req.ParseForm(), pattern.desc)
+			assert.Equal(t, "DummyRefreshToken", req.PostForm.Get("token"), pattern.desc)
+			assert.Equal(t, pattern.tokenTypeHint, req.PostForm.Get("token_type_hint"), pattern.desc)
+			assert.Equal(t, client.ClientId, req.PostForm.Get("client_id"), pattern.desc)
+			assert.Equal(t, string(client.clientSecret), req.PostForm.Get("client_secret"), pattern.desc)
+
+			return httpmock.NewStringResponse(pattern.status, pattern.body), nil
+		})
+
+		err := client.Revoke(context.Background(), "DummyRefreshToken", pattern.tokenTypeHint)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			var tokenErr *OAuthError
+			assert.True(t, errors.As(err, &tokenErr), pattern.desc)
+			assert.Equal(t, "unsupported_token_type", tokenErr.Code, pattern.desc)
+		}
+	}
+
+	client.RevocationEndpoint = ""
+	assert.ErrorIs(t, client.Revoke(context.Background(), "DummyRefreshToken", ""), errRevocationMissing)
+}
+
+func TestOidcClient_Revoke_PurgeIntrospectionCache(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.IntrospectionEndpoint = testIntrospectionUrl
+	client.IntrospectionCache = NewIntrospectionCache(time.Hour)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, testIntrospectionUrl, httpmock.NewStringResponder(http.StatusOK, `{"active": true}`))
+	httpmock.RegisterResponder(http.MethodPost, client.RevocationEndpoint, httpmock.NewStringResponder(http.StatusOK, ""))
+
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+	assert.Nil(t, client.Revoke(context.Background(), "DummyAccessToken", "access_token"))
+	_, _ = client.Introspect(context.Background(), "DummyAccessToken", "")
+
+	assert.Equal(t, 2, httpmock.GetCallCountInfo()["POST "+testIntrospectionUrl])
+}
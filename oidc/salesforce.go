@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Salesforceのログインに使うインスタンス
+//
+// My Domainを使う場合はhttps://MyDomainName.my.salesforce.comを直接渡す
+const (
+	// SalesforceLoginUrl は本番環境とDeveloper Editionのorg
+	SalesforceLoginUrl = "https://login.salesforce.com"
+	// SalesforceSandboxUrl はSandboxのorg
+	SalesforceSandboxUrl = "https://test.salesforce.com"
+)
+
+// NewSalesforceOidcClient はSalesforceのクライアントを返す
+//
+// instanceUrlにはSalesforceLoginUrl、SalesforceSandboxUrlもしくはMy DomainのURLを渡す。
+// Salesforceのid_tokenのsubはユーザーのIDではなくIDのURLなので、orgとユーザーのIDはParseSalesforceIdentityUrlで取り出す
+//
+// refs: https://help.salesforce.com/s/articleView?id=sf.remoteaccess_using_openid.htm
+func NewSalesforceOidcClient(instanceUrl string, opts ...ClientOption) *oidcClient {
+	issuer := strings.TrimSuffix(instanceUrl, "/")
+	base := issuer + "/services/oauth2"
+	client := newOidcClient(
+		Salesforce,
+		issuer,
+		os.Getenv("SALESFORCE_CLIENT_ID"),
+		clientSecret(os.Getenv("SALESFORCE_CLIENT_SECRET")),
+		base+"/authorize",
+		base+"/token",
+		issuer+"/id/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = base + "/userinfo"
+	client.IntrospectionEndpoint = base + "/introspect"
+	client.RevocationEndpoint = base + "/revoke"
+	client.Apply(opts...)
+
+	return client
+}
+
+// ParseSalesforceIdentityUrl はhttps://login.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiUのようなIDのURLから
+// orgのIDとユーザーのIDを取り出す
+func ParseSalesforceIdentityUrl(identityUrl string) (orgId string, userId string, err error) {
+	_, path, ok := strings.Cut(identityUrl, "/id/")
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", errInvalidIdentityUrl, identityUrl)
+	}
+	segments := strings.Split(path, "/")
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return "", "", fmt.Errorf("%w: %s", errInvalidIdentityUrl, identityUrl)
+	}
+
+	return segments[0], segments[1], nil
+}
+
+// SalesforceIdentity はSalesforceのIDのURLが返すユーザーの情報
+//
+// refs: https://help.salesforce.com/s/articleView?id=sf.remoteaccess_using_openid_identity_urls.htm
+type SalesforceIdentity struct {
+	Id             string `json:"id"`
+	UserId         string `json:"user_id"`
+	OrganizationId string `json:"organization_id"`
+	Username       string `json:"username"`
+	DisplayName    string `json:"display_name"`
+	Email          string `json:"email"`
+	EmailVerified  bool   `json:"email_verified"`
+	// Urls はRESTやSOAPなどのAPIのURL。{version}は使うAPIのバージョンに置き換える
+	Urls map[string]string `json:"urls"`
+}
+
+// SalesforceIdentity はIDのURLからユーザーとorgの情報を取得する
+//
+// アクセストークンを他のホストに送らないように、IDのURLがクライアントのissuerのものであることを確認する
+func (c oidcClient) SalesforceIdentity(ctx context.Context, identityUrl string, accessToken string) (*SalesforceIdentity, error) {
+	if !strings.HasPrefix(identityUrl, c.Issuer+"/id/") {
+		return nil, fmt.Errorf("%w: %s", errInvalidIdentityUrl, identityUrl)
+	}
+
+	identity := &SalesforceIdentity{}
+	if err := c.getJson(ctx, identityUrl, accessToken, identity); err != nil {
+		return nil, fmt.Errorf("failed to GET Salesforce identity url: %w", err)
+	}
+
+	return identity, nil
+}
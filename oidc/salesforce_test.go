@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestParseSalesforceIdentityUrl(t *testing.T) {
+	patterns := []struct {
+		desc           string
+		isExpectValid  bool
+		identityUrl    string
+		expectedOrgId  string
+		expectedUserId string
+	}{
+		{"valid", true, "https://login.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU", "00Dxx0000001gPL", "005xx000001SwiU"},
+		{"sandbox", true, "https://test.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU", "00Dxx0000001gPL", "005xx000001SwiU"},
+		{"no id path", false, "https://login.salesforce.com/00Dxx0000001gPL/005xx000001SwiU", "", ""},
+		{"user id missing", false, "https://login.salesforce.com/id/00Dxx0000001gPL/", "", ""},
+		{"extra segment", false, "https://login.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU/extra", "", ""},
+	}
+
+	for _, pattern := range patterns {
+		orgId, userId, err := ParseSalesforceIdentityUrl(pattern.identityUrl)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expectedOrgId, orgId, pattern.desc)
+			assert.Equal(t, pattern.expectedUserId, userId, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errInvalidIdentityUrl, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_SalesforceIdentity(t *testing.T) {
+	const identityUrl = "https://test.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU"
+	client := NewSalesforceOidcClient(SalesforceSandboxUrl)
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, identityUrl, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "id": "https://test.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU",
+  "user_id": "005xx000001SwiU",
+  "organization_id": "00Dxx0000001gPL",
+  "username": "user@example.com.sandbox",
+  "display_name": "Taro Salesforce",
+  "email": "user@example.com",
+  "email_verified": true,
+  "urls": {"rest": "https://example.my.salesforce.com/services/data/v{version}/"}
+}`), nil
+	})
+
+	identity, err := client.SalesforceIdentity(context.Background(), identityUrl, "DummyAccessToken")
+	assert.Nil(t, err)
+	assert.Equal(t, "00Dxx0000001gPL", identity.OrganizationId)
+	assert.Equal(t, "https://example.my.salesforce.com/services/data/v{version}/", identity.Urls["rest"])
+
+	// 別のインスタンスのIDのURLにはアクセストークンを送らない
+	_, err = client.SalesforceIdentity(context.Background(), "https://login.salesforce.com/id/00Dxx0000001gPL/005xx000001SwiU", "DummyAccessToken")
+	assert.ErrorIs(t, err, errInvalidIdentityUrl)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
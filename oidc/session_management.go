@@ -0,0 +1,118 @@
+package oidc
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultCheckSessionInterval はRPのiframeがIdPのiframeにログイン状態を問い合わせる間隔
+const defaultCheckSessionInterval = 5 * time.Second
+
+// rpIframeTemplate はOP Session ManagementのRPのiframe
+//
+// IdPのcheck_session_iframeを埋め込み、一定間隔で"client_id session_state"をpostMessageで送る。
+// "changed"が返された場合はIdP側でログイン状態が変わったので、トップレベルのページをchangedUrlに遷移させる
+//
+// refs: https://openid.net/specs/openid-connect-session-1_0.html#RPiframe
+var rpIframeTemplate = template.Must(template.New("rp_iframe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>check session</title></head>
+<body>
+<iframe id="op" src="{{.CheckSessionIframe}}" style="display:none"></iframe>
+<script>
+  var message = {{.Message}};
+  var opOrigin = {{.OpOrigin}};
+  var op = document.getElementById("op");
+  var timer;
+  function check() {
+    op.contentWindow.postMessage(message, opOrigin);
+  }
+  window.addEventListener("message", function (e) {
+    if (e.origin !== opOrigin) {
+      return;
+    }
+    if (e.data === "changed") {
+      clearInterval(timer);
+      window.top.location.href = {{.ChangedUrl}};
+    }
+  }, false);
+  op.addEventListener("load", function () {
+    check();
+    timer = setInterval(check, {{.IntervalMillis}});
+  });
+</script>
+</body>
+</html>
+`))
+
+// rpIframeParams はrpIframeTemplateに埋め込む値
+type rpIframeParams struct {
+	CheckSessionIframe string
+	Message            string
+	OpOrigin           string
+	ChangedUrl         string
+	IntervalMillis     int64
+}
+
+// CheckSessionMessage はIdPのcheck_session_iframeにpostMessageで送るメッセージを返す
+//
+// sessionStateには認可レスポンスのsession_stateを渡す
+func (c oidcClient) CheckSessionMessage(sessionState string) string {
+	return c.ClientId + " " + sessionState
+}
+
+// CheckSessionOrigin はIdPのcheck_session_iframeのオリジンを返す
+//
+// postMessageの送信先と、受け取ったメッセージの送信元の確認に使う
+func (c oidcClient) CheckSessionOrigin() (string, error) {
+	if c.CheckSessionIframe == "" {
+		return "", errCheckSessionMissing
+	}
+
+	iframeUrl, err := url.Parse(c.CheckSessionIframe)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse check_session_iframe: %w", err)
+	}
+
+	return iframeUrl.Scheme + "://" + iframeUrl.Host, nil
+}
+
+// RpIframeHandler はOP Session ManagementのRPのiframeを返すhttp.Handlerを返す
+//
+// sessionStateはリクエストのセッションに保存したsession_stateを返す関数で、空の場合はログインしていないとみなして204を返す。
+// IdP側でログイン状態が変わった場合はトップレベルのページをchangedUrlに遷移させるので、
+// prompt=noneで認可リクエストを送り直すか、ローカルのセッションを破棄するページを指定する。
+// intervalが0の場合は5秒ごとに確認する
+func (c oidcClient) RpIframeHandler(sessionState func(r *http.Request) string, changedUrl string, interval time.Duration) (http.Handler, error) {
+	opOrigin, err := c.CheckSessionOrigin()
+	if err != nil {
+		return nil, err
+	}
+	interval = durationOrDefault(interval, defaultCheckSessionInterval)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := sessionState(r)
+		if state == "" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		// RP自身のページにのみ埋め込めるようにする
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'self'")
+		if err := rpIframeTemplate.Execute(w, rpIframeParams{
+			CheckSessionIframe: c.CheckSessionIframe,
+			Message:            c.CheckSessionMessage(state),
+			OpOrigin:           opOrigin,
+			ChangedUrl:         changedUrl,
+			IntervalMillis:     interval.Milliseconds(),
+		}); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}), nil
+}
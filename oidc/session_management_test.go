@@ -0,0 +1,71 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOidcClient_CheckSessionOrigin(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		iframe        string
+		expected      string
+	}{
+		{"with path", true, "https://op.example.com/connect/check_session", "https://op.example.com"},
+		{"with port", true, "https://op.example.com:8443/check_session", "https://op.example.com:8443"},
+		{"not configured", false, "", ""},
+		{"invalid url", false, "://invalid", ""},
+	}
+
+	client := NewGoogleOidcClient()
+	for _, pattern := range patterns {
+		client.CheckSessionIframe = pattern.iframe
+		actual, err := client.CheckSessionOrigin()
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, actual, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestOidcClient_RpIframeHandler(t *testing.T) {
+	client := NewGoogleOidcClient()
+	client.ClientId = "client-1"
+	client.CheckSessionIframe = "https://op.example.com/check_session"
+
+	h, err := client.RpIframeHandler(func(r *http.Request) string {
+		return r.URL.Query().Get("session_state")
+	}, "/auth/relogin", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rp_iframe?session_state=DummyState.salt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `src="https://op.example.com/check_session"`)
+	assert.Contains(t, body, `var message = "client-1 DummyState.salt";`)
+	assert.Contains(t, body, `var opOrigin = "https://op.example.com";`)
+	assert.Contains(t, body, `setInterval(check,  2000 )`)
+
+	// session_stateはJavaScriptの文字列としてエスケープされる
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/rp_iframe?session_state=%22%3B%3C%2Fscript%3E`, nil))
+	assert.NotContains(t, w.Body.String(), `";</script>`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rp_iframe", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	client.CheckSessionIframe = ""
+	_, err = client.RpIframeHandler(func(*http.Request) string { return "" }, "/auth/relogin", 0)
+	assert.ErrorIs(t, err, errCheckSessionMissing)
+}
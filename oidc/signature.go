@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	// crypto.SHA256などで使うハッシュ関数の実装を登録する
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+	"math/big"
+)
+
+// algKeyTypes は署名アルゴリズムごとに使われるJWKのkty
+var algKeyTypes = map[string]string{
+	"RS256": "RSA",
+	"PS256": "RSA",
+	"PS384": "RSA",
+	"PS512": "RSA",
+	"ES256": "EC",
+	"ES384": "EC",
+	"ES512": "EC",
+	"EdDSA": "OKP",
+}
+
+// ecdsaAlgCurves はESアルゴリズムごとに使われる曲線(JWKのcrv)
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7518#section-3.4
+var ecdsaAlgCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// validateSignature は公開鍵でid_tokenの署名を検証する
+func (token idToken) validateSignature(pubKey crypto.PublicKey) error {
+	decSignature, err := base64.RawURLEncoding.DecodeString(token.rawSignature)
+	if err != nil {
+		return fmt.Errorf("failed to base64 decode id_token signature: %w", err)
+	}
+
+	if err := verifySignature(token.header.Alg, pubKey, token.signingInput(), decSignature); err != nil {
+		return fmt.Errorf("failed to verify id_token signature: %w", err)
+	}
+
+	return nil
+}
+
+// verifySignature はJWTヘッダのalgに従って署名を検証する
+//
+// signingInputはbase64urlエンコードされたheaderとpayloadを"."で繋いだもの
+func verifySignature(alg string, pubKey crypto.PublicKey, signingInput string, signature []byte) error {
+	switch alg {
+	case "RS256":
+		return verifyRsaPkcs1v15(pubKey, crypto.SHA256, signingInput, signature)
+	case "PS256":
+		return verifyRsaPss(pubKey, crypto.SHA256, signingInput, signature)
+	case "PS384":
+		return verifyRsaPss(pubKey, crypto.SHA384, signingInput, signature)
+	case "PS512":
+		return verifyRsaPss(pubKey, crypto.SHA512, signingInput, signature)
+	case "ES256":
+		return verifyEcdsa(pubKey, alg, crypto.SHA256, signingInput, signature)
+	case "ES384":
+		return verifyEcdsa(pubKey, alg, crypto.SHA384, signingInput, signature)
+	case "ES512":
+		return verifyEcdsa(pubKey, alg, crypto.SHA512, signingInput, signature)
+	case "EdDSA":
+		return verifyEd25519(pubKey, signingInput, signature)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedAlg, alg)
+	}
+}
+
+func verifyRsaPkcs1v15(pubKey crypto.PublicKey, hash crypto.Hash, signingInput string, signature []byte) error {
+	rsaKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return errKeyAlgMismatch
+	}
+
+	if err := rsa.VerifyPKCS1v15(rsaKey, hash, digest(hash, signingInput), signature); err != nil {
+		return fmt.Errorf("%w: %s", ErrSignatureInvalid, err.Error())
+	}
+
+	return nil
+}
+
+// verifyRsaPss はRSASSA-PSSの署名を検証する
+//
+// JWSではMGF1のハッシュ関数は署名のハッシュ関数と同じで、ソルト長はハッシュ長と等しい。
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7518#section-3.5
+func verifyRsaPss(pubKey crypto.PublicKey, hash crypto.Hash, signingInput string, signature []byte) error {
+	rsaKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return errKeyAlgMismatch
+	}
+
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	if err := rsa.VerifyPSS(rsaKey, hash, digest(hash, signingInput), signature, opts); err != nil {
+		return fmt.Errorf("%w: %s", ErrSignatureInvalid, err.Error())
+	}
+
+	return nil
+}
+
+// verifyEcdsa はJWS形式(RとSを固定長で連結したもの)のECDSA署名を検証する
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7518#section-3.4
+func verifyEcdsa(pubKey crypto.PublicKey, alg string, hash crypto.Hash, signingInput string, signature []byte) error {
+	ecKey, ok := pubKey.(*ecdsa.PublicKey)
+	if !ok || ecKey.Curve.Params().Name != ecdsaAlgCurves[alg] {
+		return errKeyAlgMismatch
+	}
+
+	keySize := (ecKey.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*keySize {
+		return fmt.Errorf("%w: unexpected ECDSA signature length %d", ErrSignatureInvalid, len(signature))
+	}
+	r := new(big.Int).SetBytes(signature[:keySize])
+	s := new(big.Int).SetBytes(signature[keySize:])
+
+	if !ecdsa.Verify(ecKey, digest(hash, signingInput), r, s) {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// verifyEd25519 はEdDSAの署名を検証する。JWSで使われるEdDSAの曲線はEd25519のみサポートする
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc8037#section-3.1
+func verifyEd25519(pubKey crypto.PublicKey, signingInput string, signature []byte) error {
+	edKey, ok := pubKey.(ed25519.PublicKey)
+	if !ok {
+		return errKeyAlgMismatch
+	}
+
+	if !ed25519.Verify(edKey, []byte(signingInput), signature) {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// verifyHmac はclient_secretを鍵としたHMACの署名を検証する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#Signing
+func verifyHmac(secret clientSecret, hash crypto.Hash, signingInput string, signature []byte) error {
+	if secret == "" {
+		return errEmptyHmacSecret
+	}
+
+	mac := hmac.New(hash.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	// タイミング攻撃を防ぐために定数時間で比較する
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+func digest(hash crypto.Hash, signingInput string) []byte {
+	h := hash.New()
+	h.Write([]byte(signingInput))
+
+	return h.Sum(nil)
+}
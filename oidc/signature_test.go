@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testSigningInput = "eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiIxMjM0NTY3ODkwIn0"
+
+func signEcdsaForTest(t *testing.T, key *ecdsa.PrivateKey, hash crypto.Hash, signingInput string) []byte {
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest(hash, signingInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keySize := (key.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*keySize)
+	r.FillBytes(signature[:keySize])
+	s.FillBytes(signature[keySize:])
+
+	return signature
+}
+
+func TestVerifySignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest(crypto.SHA256, testSigningInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pssSigs := map[crypto.Hash][]byte{}
+	for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+		sig, err := rsa.SignPSS(rand.Reader, rsaKey, hash, digest(hash, testSigningInput), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pssSigs[hash] = sig
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecSig := signEcdsaForTest(t, ecKey, crypto.SHA256, testSigningInput)
+
+	ec384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec384Sig := signEcdsaForTest(t, ec384Key, crypto.SHA384, testSigningInput)
+
+	ec521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec521Sig := signEcdsaForTest(t, ec521Key, crypto.SHA512, testSigningInput)
+
+	edPubKey, edPrivKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edPrivKey, []byte(testSigningInput))
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		alg           string
+		pubKey        crypto.PublicKey
+		signingInput  string
+		signature     []byte
+	}{
+		{"valid RS256", true, "RS256", &rsaKey.PublicKey, testSigningInput, rsaSig},
+		{"tampered RS256", false, "RS256", &rsaKey.PublicKey, testSigningInput + "x", rsaSig},
+		{"valid PS256", true, "PS256", &rsaKey.PublicKey, testSigningInput, pssSigs[crypto.SHA256]},
+		{"tampered PS256", false, "PS256", &rsaKey.PublicKey, testSigningInput + "x", pssSigs[crypto.SHA256]},
+		{"valid PS384", true, "PS384", &rsaKey.PublicKey, testSigningInput, pssSigs[crypto.SHA384]},
+		{"valid PS512", true, "PS512", &rsaKey.PublicKey, testSigningInput, pssSigs[crypto.SHA512]},
+		{"PS256 signature as PS512", false, "PS512", &rsaKey.PublicKey, testSigningInput, pssSigs[crypto.SHA256]},
+		{"PKCS1v15 signature as PS256", false, "PS256", &rsaKey.PublicKey, testSigningInput, rsaSig},
+		{"EC key with PS256", false, "PS256", &ecKey.PublicKey, testSigningInput, pssSigs[crypto.SHA256]},
+		{"valid ES256", true, "ES256", &ecKey.PublicKey, testSigningInput, ecSig},
+		{"tampered ES256", false, "ES256", &ecKey.PublicKey, testSigningInput + "x", ecSig},
+		{"truncated ES256 signature", false, "ES256", &ecKey.PublicKey, testSigningInput, ecSig[1:]},
+		{"valid ES384", true, "ES384", &ec384Key.PublicKey, testSigningInput, ec384Sig},
+		{"tampered ES384", false, "ES384", &ec384Key.PublicKey, testSigningInput + "x", ec384Sig},
+		{"valid ES512", true, "ES512", &ec521Key.PublicKey, testSigningInput, ec521Sig},
+		{"tampered ES512", false, "ES512", &ec521Key.PublicKey, testSigningInput + "x", ec521Sig},
+		{"P-256 key with ES384", false, "ES384", &ecKey.PublicKey, testSigningInput, ecSig},
+		{"valid EdDSA", true, "EdDSA", edPubKey, testSigningInput, edSig},
+		{"tampered EdDSA", false, "EdDSA", edPubKey, testSigningInput + "x", edSig},
+		{"RSA key with EdDSA", false, "EdDSA", &rsaKey.PublicKey, testSigningInput, edSig},
+		{"RSA key with ES256", false, "ES256", &rsaKey.PublicKey, testSigningInput, ecSig},
+		{"EC key with RS256", false, "RS256", &ecKey.PublicKey, testSigningInput, rsaSig},
+		{"unsupported alg", false, "XX256", &rsaKey.PublicKey, testSigningInput, rsaSig},
+	}
+
+	for _, pattern := range patterns {
+		err := verifySignature(pattern.alg, pattern.pubKey, pattern.signingInput, pattern.signature)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
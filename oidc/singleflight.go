@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"sync"
+)
+
+// jwksFlightCall は実行中のJWKsの取得処理
+type jwksFlightCall struct {
+	wg   sync.WaitGroup
+	keys jwks
+	err  error
+}
+
+// jwksFlightGroup は同じキーに対する処理が同時に呼ばれた場合に、1回の実行結果を共有する
+//
+// 同時に多数のトークンを検証する際に、JWKsエンドポイントへのリクエストが重複しないようにするために使う
+type jwksFlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*jwksFlightCall
+}
+
+// do はkeyに対してfnを実行する。同じkeyで実行中の処理がある場合はその完了を待ち、同じ結果を返す
+func (g *jwksFlightGroup) do(key string, fn func() (jwks, error)) (jwks, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*jwksFlightCall{}
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+
+		return call.keys, call.err
+	}
+	call := &jwksFlightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.keys, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.keys, call.err
+}
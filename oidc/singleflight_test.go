@@ -0,0 +1,55 @@
+package oidc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJwksFlightGroup_Do(t *testing.T) {
+	var group jwksFlightGroup
+	var calls int32
+	release := make(chan struct{})
+
+	const concurrency = 10
+	var started, finished sync.WaitGroup
+	started.Add(concurrency)
+	finished.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer finished.Done()
+			started.Done()
+			keys, err := group.do(testJwksUrl, func() (jwks, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+
+				return jwks{Keys: []jwk{{Kid: "key-1"}}}, nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "key-1", keys.Keys[0].Kid)
+		}()
+	}
+	started.Wait()
+	// すべてのgoroutineがdoを呼ぶまで待つ
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	finished.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestJwksFlightGroup_DoAfterComplete(t *testing.T) {
+	var group jwksFlightGroup
+	errFetch := errors.New("fetch error")
+
+	_, err := group.do(testJwksUrl, func() (jwks, error) { return jwks{}, errFetch })
+	assert.ErrorIs(t, err, errFetch)
+
+	// 完了した処理の結果は使い回さない
+	keys, err := group.do(testJwksUrl, func() (jwks, error) { return jwks{Keys: []jwk{{Kid: "key-1"}}}, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, "key-1", keys.Keys[0].Kid)
+}
@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// SlackClaims はSlackのid_tokenに含まれるワークスペースとEnterprise Gridのクレーム
+//
+// idToken.Claimsに渡して取り出す
+//
+// refs: https://api.slack.com/authentication/sign-in-with-slack#response
+type SlackClaims struct {
+	// UserId はワークスペース内でのユーザーのID
+	UserId string `json:"https://slack.com/user_id"`
+	// TeamId はユーザーがサインインしたワークスペースのID
+	TeamId     string `json:"https://slack.com/team_id"`
+	TeamName   string `json:"https://slack.com/team_name"`
+	TeamDomain string `json:"https://slack.com/team_domain"`
+	// EnterpriseId はEnterprise GridのOrgのID。Enterprise Gridでない場合は空
+	EnterpriseId   string `json:"https://slack.com/enterprise_id"`
+	EnterpriseName string `json:"https://slack.com/enterprise_name"`
+}
+
+// slackIdTokenPayload はSlackのid_tokenのpayloadをunmarshalするための構造体
+type slackIdTokenPayload struct {
+	IdTokenClaims
+	SlackClaims
+}
+
+// NewSlackOidcClient はSign in with Slackのクライアントを返す
+//
+// refs: https://api.slack.com/authentication/sign-in-with-slack
+func NewSlackOidcClient(opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		Slack,
+		"https://slack.com",
+		os.Getenv("SLACK_CLIENT_ID"),
+		clientSecret(os.Getenv("SLACK_CLIENT_SECRET")),
+		"https://slack.com/openid/connect/authorize",
+		"https://slack.com/api/openid.connect.token",
+		"https://slack.com/openid/connect/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = "https://slack.com/api/openid.connect.userInfo"
+	client.Apply(opts...)
+
+	return client
+}
+
+// WithSlackTeam は認可リクエストにteamを含め、サインインするワークスペースを指定する
+//
+// ユーザーが既にそのワークスペースにサインインしている場合は選択画面を省略できる。
+// ユーザーが別のワークスペースを選ぶこともできるので、ログイン後にVerifySlackTeamで確認する
+func WithSlackTeam(teamId string) AuthCodeOption {
+	return func(values url.Values) {
+		values.Set("team", teamId)
+	}
+}
+
+// VerifySlackTeam はid_tokenのワークスペースもしくはEnterprise GridのOrgがallowedIdsに含まれるかを確認する
+//
+// 特定のワークスペースのメンバーのみにログインを許可する場合に使う
+func VerifySlackTeam(token *idToken, allowedIds ...string) (SlackClaims, error) {
+	claims := SlackClaims{}
+	if err := token.Claims(&claims); err != nil {
+		return SlackClaims{}, err
+	}
+
+	for _, id := range allowedIds {
+		if id == "" {
+			continue
+		}
+		if id == claims.TeamId || id == claims.EnterpriseId {
+			return claims, nil
+		}
+	}
+
+	return SlackClaims{}, fmt.Errorf("%w: team_id=%s", errSlackTeamNotAllowed, claims.TeamId)
+}
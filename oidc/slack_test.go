@@ -0,0 +1,59 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestVerifySlackTeam(t *testing.T) {
+	payload := map[string]interface{}{
+		"iss":                             "https://slack.com",
+		"aud":                             "DummyClientId",
+		"sub":                             "U0R7JM",
+		"exp":                             time.Now().Add(time.Hour).Unix(),
+		"iat":                             time.Now().Unix(),
+		"https://slack.com/user_id":       "U0R7JM",
+		"https://slack.com/team_id":       "T0R7GR",
+		"https://slack.com/team_name":     "Example Workspace",
+		"https://slack.com/team_domain":   "example",
+		"https://slack.com/enterprise_id": "E0R7AB",
+	}
+	rawIdToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256"}, payload, hmacSignerForTest("unused"))
+	token, err := NewIdToken(rawIdToken, Slack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		allowedIds    []string
+	}{
+		{"team allowed", true, []string{"T0R7GR"}},
+		{"enterprise allowed", true, []string{"TXXXXX", "E0R7AB"}},
+		{"not allowed", false, []string{"TXXXXX"}},
+		{"empty id", false, []string{""}},
+		{"no allowed ids", false, nil},
+	}
+
+	for _, pattern := range patterns {
+		claims, err := VerifySlackTeam(token, pattern.allowedIds...)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, SlackClaims{
+				UserId:       "U0R7JM",
+				TeamId:       "T0R7GR",
+				TeamName:     "Example Workspace",
+				TeamDomain:   "example",
+				EnterpriseId: "E0R7AB",
+			}, claims, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errSlackTeamNotAllowed, pattern.desc)
+		}
+	}
+
+	// Slack固有のクレームがあってもOIDC Coreのクレームを取り出せる
+	assert.Equal(t, "U0R7JM", token.StandardClaims().Sub)
+}
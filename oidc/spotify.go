@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// spotifyApiUrl はSpotifyのWeb APIのベースURL
+const spotifyApiUrl = "https://api.spotify.com/v1"
+
+// spotifyProvider はSpotifyのOAuth 2.0のプロバイダ
+//
+// SpotifyはOIDCに対応していないため、アクセストークンでWeb APIからユーザーの情報を取得する
+type spotifyProvider struct {
+	*oidcClient
+	// ApiUrl はWeb APIのベースURL
+	ApiUrl string
+}
+
+// spotifyMe はGET /meのレスポンス
+//
+// refs: https://developer.spotify.com/documentation/web-api/reference/get-current-users-profile
+type spotifyMe struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	// Images はプロフィール画像。大きいものから順に並ぶ
+	Images []struct {
+		Url string `json:"url"`
+	} `json:"images"`
+}
+
+// NewSpotifyProvider はSpotifyのプロバイダを返す
+//
+// refs: https://developer.spotify.com/documentation/web-api/tutorials/code-flow
+func NewSpotifyProvider(opts ...ClientOption) *spotifyProvider {
+	client := newOidcClient(
+		Spotify,
+		"",
+		os.Getenv("SPOTIFY_CLIENT_ID"),
+		clientSecret(os.Getenv("SPOTIFY_CLIENT_SECRET")),
+		"https://accounts.spotify.com/authorize",
+		"https://accounts.spotify.com/api/token",
+		"",
+		nil,
+	)
+	client.Scopes = []string{"user-read-email", "user-read-private"}
+	client.ClientAuthMethod = ClientSecretBasic
+	client.Apply(opts...)
+
+	return &spotifyProvider{oidcClient: client, ApiUrl: spotifyApiUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、Web APIからユーザーの情報を取得する
+//
+// nonceは使われない。Spotifyはメールアドレスの所有を確認していないため、EmailVerifiedは常にfalseになる
+func (p spotifyProvider) Login(ctx context.Context, code string, _ string, opts ...AuthCodeOption) (*User, error) {
+	tokenResp, err := p.postAuthorizationCode(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	token := newToken(tokenResp)
+
+	me := spotifyMe{}
+	if err := p.getJson(ctx, p.ApiUrl+"/me", token.AccessToken, &me); err != nil {
+		return nil, fmt.Errorf("failed to GET Spotify user: %w", err)
+	}
+
+	user := &User{
+		IdProvider: Spotify,
+		Sub:        me.Id,
+		Email:      me.Email,
+		Name:       me.DisplayName,
+		Token:      token,
+	}
+	if len(me.Images) > 0 {
+		user.Picture = me.Images[0].Url
+	}
+
+	return user, nil
+}
@@ -0,0 +1,46 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var _ Provider = spotifyProvider{}
+
+func TestSpotifyProvider_Login(t *testing.T) {
+	provider := NewSpotifyProvider()
+	provider.clientSecret = "DummyClientSecret"
+	provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, provider.tokenEndpoint, httpmock.NewStringResponder(
+		http.StatusOK,
+		`{"access_token": "DummyAccessToken", "token_type": "Bearer", "scope": "user-read-email user-read-private", "expires_in": 3600, "refresh_token": "DummyRefreshToken"}`,
+	))
+	httpmock.RegisterResponder(http.MethodGet, spotifyApiUrl+"/me", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "id": "wizzler",
+  "display_name": "Taro Spotify",
+  "email": "user@example.com",
+  "images": [{"url": "https://i.scdn.co/image/large", "height": 300, "width": 300}, {"url": "https://i.scdn.co/image/small", "height": 64, "width": 64}]
+}`), nil
+	})
+
+	user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyCodeVerifier"))
+	assert.Nil(t, err)
+	assert.Equal(t, &User{
+		IdProvider: Spotify,
+		Sub:        "wizzler",
+		Email:      "user@example.com",
+		Name:       "Taro Spotify",
+		Picture:    "https://i.scdn.co/image/large",
+		Token:      user.Token,
+	}, user)
+	assert.Equal(t, "DummyRefreshToken", user.Token.RefreshToken)
+}
@@ -0,0 +1,132 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// stateBytes はstateとして生成する乱数のバイト数
+	stateBytes        = 32
+	defaultStateTtl   = 10 * time.Minute
+	defaultStateName  = "state"
+	stateParam        = "state"
+	defaultCookiePath = "/"
+)
+
+// StateStore は認可リクエストのstateを保存するストレージ
+//
+// stateはリクエストを送ったブラウザと紐付けて保存し、コールバックで1度だけ取り出せるようにする必要がある
+type StateStore interface {
+	// Save はstateをexpiresAtまで保存する
+	Save(w http.ResponseWriter, r *http.Request, state string, expiresAt time.Time) error
+	// Consume は保存したstateを取り出し、再利用できないように削除する
+	Consume(w http.ResponseWriter, r *http.Request) (string, error)
+}
+
+// stateManager は認可リクエストのstateの発行とコールバックでの検証を行う
+type stateManager struct {
+	store StateStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewStateManager はstoreにstateを保存するstateManagerを返す。storeがnilの場合はCookieに保存する
+func NewStateManager(store StateStore, ttl time.Duration) *stateManager {
+	if store == nil {
+		store = NewCookieStateStore()
+	}
+
+	return &stateManager{store: store, ttl: durationOrDefault(ttl, defaultStateTtl), now: time.Now}
+}
+
+// Issue はランダムなstateを生成して保存し、認可リクエストに含めるstateを返す
+func (m stateManager) Issue(w http.ResponseWriter, r *http.Request) (string, error) {
+	state, err := randomToken(stateBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	if err := m.store.Save(w, r, state, m.now().Add(m.ttl)); err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return state, nil
+}
+
+// Verify はコールバックのクエリもしくはPOSTのボディのstateが保存したstateと一致するかを確認してCSRF攻撃を防ぐ
+func (m stateManager) Verify(w http.ResponseWriter, r *http.Request) error {
+	saved, err := m.store.Consume(w, r)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if saved == "" {
+		return errStateMissing
+	}
+
+	// form_postの場合はPOSTのボディでstateが返される
+	state := r.FormValue(stateParam)
+	if subtle.ConstantTimeCompare([]byte(state), []byte(saved)) != 1 {
+		return ErrStateMismatch
+	}
+
+	return nil
+}
+
+// cookieStateStore はstateをCookieに保存するStateStore
+type cookieStateStore struct {
+	// Name はstateを保存するCookieの名前
+	Name string
+	Path string
+	// Secure はHTTPS接続でのみCookieを送るかどうか
+	Secure bool
+	// SameSite はCookieのSameSite属性
+	//
+	// form_postではIdPからクロスサイトのPOSTでコールバックされるため、Laxのままだと
+	// Cookieが送られない。その場合はNoneにしてSecureを有効にする
+	SameSite http.SameSite
+}
+
+// NewCookieStateStore はstateをCookieに保存するStateStoreを返す
+func NewCookieStateStore() *cookieStateStore {
+	return &cookieStateStore{Name: defaultStateName, Path: defaultCookiePath, SameSite: http.SameSiteLaxMode}
+}
+
+func (s cookieStateStore) Save(w http.ResponseWriter, _ *http.Request, state string, expiresAt time.Time) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.Name,
+		Value:    state,
+		Path:     s.Path,
+		Expires:  expiresAt,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: s.SameSite,
+	})
+
+	return nil
+}
+
+func (s cookieStateStore) Consume(w http.ResponseWriter, r *http.Request) (string, error) {
+	cookie, err := r.Cookie(s.Name)
+	if errors.Is(err, http.ErrNoCookie) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read state cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.Name,
+		Value:    "",
+		Path:     s.Path,
+		MaxAge:   -1,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: s.SameSite,
+	})
+
+	return cookie.Value, nil
+}
@@ -0,0 +1,90 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// callbackRequestForTest はIssueで設定されたCookieを付けたコールバックのリクエストを返す
+func callbackRequestForTest(issued *httptest.ResponseRecorder, queryState string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/callback?state="+queryState, nil)
+	for _, cookie := range issued.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+
+	return r
+}
+
+func TestStateManager_Verify(t *testing.T) {
+	manager := NewStateManager(nil, 0)
+
+	issued := httptest.NewRecorder()
+	state, err := manager.Issue(issued, httptest.NewRequest(http.MethodGet, "/login", nil))
+	assert.Nil(t, err)
+	assert.Len(t, state, 43)
+
+	patterns := []struct {
+		desc       string
+		issued     *httptest.ResponseRecorder
+		queryState string
+		expected   error
+	}{
+		{"valid", issued, state, nil},
+		{"mismatch", issued, "another-state", ErrStateMismatch},
+		{"empty query", issued, "", ErrStateMismatch},
+		{"cookie missing", httptest.NewRecorder(), state, errStateMissing},
+	}
+
+	for _, pattern := range patterns {
+		err := manager.Verify(httptest.NewRecorder(), callbackRequestForTest(pattern.issued, pattern.queryState))
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestCookieStateStore(t *testing.T) {
+	store := NewCookieStateStore()
+	expiresAt := time.Now().Add(time.Minute)
+
+	issued := httptest.NewRecorder()
+	assert.Nil(t, store.Save(issued, nil, "12345678", expiresAt))
+	cookies := issued.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "state", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+
+	// 取り出したstateのCookieは削除される
+	w := httptest.NewRecorder()
+	state, err := store.Consume(w, callbackRequestForTest(issued, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678", state)
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+}
+
+func TestStateManager_Verify_FormPost(t *testing.T) {
+	manager := NewStateManager(nil, 0)
+
+	issued := httptest.NewRecorder()
+	state, err := manager.Issue(issued, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(url.Values{"state": {state}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range issued.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+
+	assert.Nil(t, manager.Verify(httptest.NewRecorder(), r))
+}
@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+const tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// トークンエクスチェンジで使うトークンの種類
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc8693#section-3
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIdToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJwt          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchangeRequest はトークンエクスチェンジのリクエスト
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc8693#section-2.1
+type TokenExchangeRequest struct {
+	// SubjectToken は交換元のトークン。必須
+	SubjectToken string
+	// SubjectTokenType はSubjectTokenの種類。必須
+	SubjectTokenType string
+	// ActorToken は委譲の場合に代理で操作する主体のトークン。なりすましの場合は空にする
+	ActorToken     string
+	ActorTokenType string
+	// RequestedTokenType は発行してほしいトークンの種類。空の場合はIdPが決める
+	RequestedTokenType string
+	// Audience は発行するトークンを使う相手となるサービスの論理名
+	Audience []string
+	// Resource は発行するトークンを使う相手となるサービスのURI
+	Resource []string
+	Scopes   []string
+}
+
+// ExchangeToken はトークンエクスチェンジでsubject_tokenを別のトークンに交換する
+//
+// 発行されたトークンはTokenのAccessTokenに、種類はIssuedTokenTypeに入る
+func (c oidcClient) ExchangeToken(ctx context.Context, req TokenExchangeRequest) (*Token, error) {
+	if req.SubjectToken == "" || req.SubjectTokenType == "" {
+		return nil, errSubjectTokenMissing
+	}
+
+	values := url.Values{}
+	values.Add("grant_type", tokenExchangeGrantType)
+	values.Add("subject_token", req.SubjectToken)
+	values.Add("subject_token_type", req.SubjectTokenType)
+	if req.ActorToken != "" {
+		values.Add("actor_token", req.ActorToken)
+		values.Add("actor_token_type", req.ActorTokenType)
+	}
+	if req.RequestedTokenType != "" {
+		values.Add("requested_token_type", req.RequestedTokenType)
+	}
+	for _, audience := range req.Audience {
+		values.Add("audience", audience)
+	}
+	for _, resource := range req.Resource {
+		values.Add("resource", resource)
+	}
+	if len(req.Scopes) > 0 {
+		values.Add("scope", strings.Join(req.Scopes, " "))
+	}
+
+	tokenResp, err := c.postToken(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return newToken(tokenResp), nil
+}
@@ -0,0 +1,55 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestOidcClient_ExchangeToken(t *testing.T) {
+	client := NewGoogleOidcClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	httpmock.RegisterResponder(http.MethodPost, client.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = req.PostForm
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "access_token": "DownstreamAccessToken",
+  "issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+  "token_type": "Bearer",
+  "expires_in": 60
+}`), nil
+	})
+
+	token, err := client.ExchangeToken(context.Background(), TokenExchangeRequest{
+		SubjectToken:     "UserAccessToken",
+		SubjectTokenType: TokenTypeAccessToken,
+		ActorToken:       "GatewayToken",
+		ActorTokenType:   TokenTypeJwt,
+		Audience:         []string{"backend-a", "backend-b"},
+		Scopes:           []string{"read"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DownstreamAccessToken", token.AccessToken)
+	assert.Equal(t, TokenTypeAccessToken, token.IssuedTokenType)
+	assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", form.Get("grant_type"))
+	assert.Equal(t, "UserAccessToken", form.Get("subject_token"))
+	assert.Equal(t, TokenTypeAccessToken, form.Get("subject_token_type"))
+	assert.Equal(t, "GatewayToken", form.Get("actor_token"))
+	assert.Equal(t, []string{"backend-a", "backend-b"}, form["audience"])
+	assert.Equal(t, "read", form.Get("scope"))
+	assert.Empty(t, form.Get("requested_token_type"))
+
+	_, err = client.ExchangeToken(context.Background(), TokenExchangeRequest{SubjectToken: "UserAccessToken"})
+	assert.ErrorIs(t, err, errSubjectTokenMissing)
+}
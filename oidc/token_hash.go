@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+// algHashes は署名アルゴリズムごとに使われるハッシュ関数
+//
+// EdDSAはEd25519のみサポートしているため、OIDC CoreのerrataにならいSHA-512を使う
+var algHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"HS256": crypto.SHA256,
+	"EdDSA": crypto.SHA512,
+}
+
+// tokenHash はat_hashやc_hashの値を計算する
+//
+// id_tokenのalgに対応するハッシュ関数でハッシュ化し、左半分をbase64urlエンコードしたもの
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func tokenHash(alg string, value string) (string, error) {
+	hash, ok := algHashes[alg]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errUnsupportedAlg, alg)
+	}
+
+	sum := digest(hash, value)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
+
+// verifyTokenHash はclaimがvalueのハッシュと一致するかを確認する
+func verifyTokenHash(alg string, claim string, value string) error {
+	expected, err := tokenHash(alg, value)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(claim), []byte(expected)) != 1 {
+		return errTokenHashMismatch
+	}
+
+	return nil
+}
+
+// VerifyAccessToken はid_tokenのat_hashクレームがアクセストークンと一致するかを確認する
+//
+// 認可コードフローではat_hashは任意なので、クレームがない場合は検証しない
+func (token *idToken) VerifyAccessToken(accessToken string) error {
+	atHash := token.Payload.standardClaims().AtHash
+	if atHash == "" {
+		return nil
+	}
+
+	if err := verifyTokenHash(token.header.Alg, atHash, accessToken); err != nil {
+		return fmt.Errorf("failed to verify at_hash: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyCode はハイブリッドフローで認可エンドポイントから返されたid_tokenのc_hashクレームが認可コードと一致するかを確認する
+//
+// response_typeが"code id_token"の場合はc_hashは必須なので、クレームがない場合もエラーを返す
+func (token *idToken) VerifyCode(code string) error {
+	cHash := token.Payload.standardClaims().CHash
+	if cHash == "" {
+		return errCHashMissing
+	}
+
+	if err := verifyTokenHash(token.header.Alg, cHash, code); err != nil {
+		return fmt.Errorf("failed to verify c_hash: %w", err)
+	}
+
+	return nil
+}
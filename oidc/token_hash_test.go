@@ -0,0 +1,85 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTokenHash(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		alg           string
+		value         string
+		expected      string
+	}{
+		// refs: https://openid.net/specs/openid-connect-core-1_0.html#code-id_tokenExample
+		{"RS256 at_hash", true, "RS256", "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", "77QmUPtjPfzWtF2AnpK9RQ"},
+		{"RS256 c_hash", true, "RS256", "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk", "LDktKdoQak3Pk0cnXxCltA"},
+		{"ES384", true, "ES384", "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", "jtAeDp945y1dDqU3nkIVGNZP1HjH_MFs"},
+		{"unsupported alg", false, "XX256", "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", ""},
+	}
+
+	for _, pattern := range patterns {
+		actual, err := tokenHash(pattern.alg, pattern.value)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, pattern.expected, actual, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestIdToken_VerifyAccessToken(t *testing.T) {
+	const accessToken = "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y"
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		atHash        string
+		accessToken   string
+	}{
+		{"valid", true, "77QmUPtjPfzWtF2AnpK9RQ", accessToken},
+		{"another access token", false, "77QmUPtjPfzWtF2AnpK9RQ", "another-access-token"},
+		{"at_hash not present", true, "", accessToken},
+	}
+
+	for _, pattern := range patterns {
+		token := &idToken{header: header{Alg: "RS256"}, Payload: &IdTokenClaims{AtHash: pattern.atHash}}
+		err := token.VerifyAccessToken(pattern.accessToken)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, errTokenHashMismatch, pattern.desc)
+		}
+	}
+}
+
+func TestIdToken_VerifyCode(t *testing.T) {
+	const code = "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk"
+
+	patterns := []struct {
+		desc     string
+		cHash    string
+		code     string
+		expected error
+	}{
+		{"valid", "LDktKdoQak3Pk0cnXxCltA", code, nil},
+		{"injected code", "LDktKdoQak3Pk0cnXxCltA", "another-code", errTokenHashMismatch},
+		{"c_hash not present", "", code, errCHashMissing},
+	}
+
+	for _, pattern := range patterns {
+		token := &idToken{header: header{Alg: "RS256"}, Payload: &IdTokenClaims{CHash: pattern.cHash}}
+		err := token.VerifyCode(pattern.code)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
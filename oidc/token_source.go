@@ -0,0 +1,79 @@
+package oidc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultExpiryDelta は有効期限のどれだけ前にトークンを取得し直すか
+const defaultExpiryDelta = time.Minute
+
+// TokenSource は有効なトークンを返す
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// reuseTokenSource は有効期限が近づくまで同じトークンを返し、有効期限が近づいたらrefreshで取得し直すTokenSource
+//
+// 複数のgoroutineから同時に呼ばれても取得し直すのは1度だけになる
+type reuseTokenSource struct {
+	mu      sync.Mutex
+	token   *Token
+	refresh func(ctx context.Context, token *Token) (*Token, error)
+	// expiryDelta は有効期限のどれだけ前に取得し直すか
+	expiryDelta time.Duration
+	now         func() time.Time
+}
+
+func newReuseTokenSource(token *Token, refresh func(ctx context.Context, token *Token) (*Token, error)) *reuseTokenSource {
+	return &reuseTokenSource{token: token, refresh: refresh, expiryDelta: defaultExpiryDelta, now: time.Now}
+}
+
+// TokenSource はtokenを起点にリフレッシュトークンで自動的にトークンを取得し直すTokenSourceを返す
+//
+// 取得し直したレスポンスにid_tokenが含まれない場合は元のid_tokenを引き継ぐ
+func (c oidcClient) TokenSource(token *Token) *reuseTokenSource {
+	return newReuseTokenSource(token, func(ctx context.Context, token *Token) (*Token, error) {
+		if token == nil {
+			return nil, errRefreshTokenMissing
+		}
+
+		refreshed, err := c.Refresh(ctx, token.RefreshToken, token.IdToken)
+		if err != nil {
+			return nil, err
+		}
+		if refreshed.IdToken == nil {
+			refreshed.IdToken = token.IdToken
+		}
+
+		return refreshed, nil
+	})
+}
+
+// Token は有効なトークンを返す。有効期限が近づいている場合は取得し直す
+func (s *reuseTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.valid(s.now().Add(s.expiryDelta)) {
+		return s.token, nil
+	}
+
+	token, err := s.refresh(ctx, s.token)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+
+	return token, nil
+}
+
+// valid はat時点でアクセストークンが有効かどうかを返す。有効期限がない場合は常に有効とみなす
+func (t *Token) valid(at time.Time) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+
+	return t.Expiry.IsZero() || at.Before(t.Expiry)
+}
@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReuseTokenSource_Token(t *testing.T) {
+	now := time.Now()
+
+	patterns := []struct {
+		desc          string
+		token         *Token
+		expectRefresh bool
+	}{
+		{"valid", &Token{AccessToken: "old", Expiry: now.Add(time.Hour)}, false},
+		{"no expiry", &Token{AccessToken: "old"}, false},
+		{"expires soon", &Token{AccessToken: "old", Expiry: now.Add(30 * time.Second)}, true},
+		{"expired", &Token{AccessToken: "old", Expiry: now.Add(-time.Second)}, true},
+		{"no token", nil, true},
+	}
+
+	for _, pattern := range patterns {
+		refreshed := false
+		source := newReuseTokenSource(pattern.token, func(ctx context.Context, token *Token) (*Token, error) {
+			refreshed = true
+
+			return &Token{AccessToken: "new", Expiry: now.Add(time.Hour)}, nil
+		})
+		source.now = func() time.Time { return now }
+
+		token, err := source.Token(context.Background())
+
+		assert.Nil(t, err, pattern.desc)
+		assert.Equal(t, pattern.expectRefresh, refreshed, pattern.desc)
+		if pattern.expectRefresh {
+			assert.Equal(t, "new", token.AccessToken, pattern.desc)
+		} else {
+			assert.Equal(t, "old", token.AccessToken, pattern.desc)
+		}
+	}
+}
+
+func TestReuseTokenSource_Concurrent(t *testing.T) {
+	var calls int32
+	source := newReuseTokenSource(nil, func(ctx context.Context, token *Token) (*Token, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		return &Token{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = source.Token(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestReuseTokenSource_RefreshError(t *testing.T) {
+	errRefresh := errors.New("refresh failed")
+	source := newReuseTokenSource(&Token{AccessToken: "old", Expiry: time.Now().Add(-time.Hour)}, func(ctx context.Context, token *Token) (*Token, error) {
+		return nil, errRefresh
+	})
+
+	_, err := source.Token(context.Background())
+	assert.ErrorIs(t, err, errRefresh)
+
+	// 失敗した場合は元のトークンを保持し、次の呼び出しで再度取得し直す
+	assert.Equal(t, "old", source.token.AccessToken)
+}
@@ -0,0 +1,38 @@
+package oidc
+
+import "context"
+
+// SpanAttribute はスパンに付ける属性
+type SpanAttribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer はIdPへのリクエストとid_tokenの検証のスパンを作成する
+//
+// OpenTelemetryなどのトレーシング基盤に送る場合に実装し、oidcClientのTracerに設定する。
+// OpenTelemetryの実装はsns-login/oteltraceにある
+type Tracer interface {
+	// StartSpan はctxのスパンの子としてnameのスパンを開始する
+	//
+	// スパンを含むcontextと、処理の結果のエラーを受け取ってスパンを終了する関数を返す
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, func(err error))
+}
+
+// NopTracer はスパンを作成しないTracer
+var NopTracer Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+func (nopTracer) StartSpan(ctx context.Context, _ string, _ ...SpanAttribute) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+// startSpan はtでctxのスパンの子としてnameのスパンを開始する。tがnilの場合はスパンを作成しない
+func startSpan(ctx context.Context, t Tracer, name string, attrs ...SpanAttribute) (context.Context, func(err error)) {
+	if t == nil {
+		t = NopTracer
+	}
+
+	return t.StartSpan(ctx, name, attrs...)
+}
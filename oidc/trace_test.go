@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type spanContextKey struct{}
+
+// spanForTest はtracerForTestで終了したスパン
+type spanForTest struct {
+	name   string
+	parent string
+	attrs  []SpanAttribute
+	err    error
+}
+
+// tracerForTest は終了したスパンを順に記録するTracer。親のスパンの名前をcontextで受け渡す
+type tracerForTest struct {
+	mu    sync.Mutex
+	ended []spanForTest
+}
+
+func (t *tracerForTest) StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, func(error)) {
+	parent, _ := ctx.Value(spanContextKey{}).(string)
+
+	return context.WithValue(ctx, spanContextKey{}, name), func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.ended = append(t.ended, spanForTest{name: name, parent: parent, attrs: attrs, err: err})
+	}
+}
+
+func TestVerifier_Verify_Span(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpmock.RegisterResponder(http.MethodGet, testJwksUrl, httpmock.NewBytesResponder(http.StatusOK, rsaJwksForTest(t, &rsaKey.PublicKey, "key-1")))
+
+	tracer := &tracerForTest{}
+	cfg := httpConfig{tracer: tracer}
+	v := newVerifier(
+		Google,
+		newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+		"",
+		[]string{"RS256"},
+		false,
+		newRemoteKeySet(testJwksUrl, NewJwksCache(time.Hour), nil, cfg),
+	)
+	v.tracer = tracer
+
+	// 呼び出し元のスパンの子としてスパンを作成する
+	ctx, endCallback := tracer.StartSpan(context.Background(), "callback")
+	payload := validGooglePayloadForTest()
+	token, err := NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey)), Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, v.Verify(ctx, token))
+
+	payload["aud"] = "another-client"
+	token, err = NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey)), Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, v.Verify(ctx, token))
+	endCallback(nil)
+
+	spans := tracer.ended
+	names := []string{}
+	for _, span := range spans {
+		names = append(names, span.name)
+	}
+	assert.Equal(t, []string{"oidc.jwks", "oidc.verify", "oidc.verify", "callback"}, names)
+	assert.Equal(t, "oidc.verify", spans[0].parent)
+	assert.Equal(t, []SpanAttribute{{"url.full", testJwksUrl}}, spans[0].attrs)
+	assert.Equal(t, "callback", spans[1].parent)
+	assert.Equal(t, []SpanAttribute{{"jwt.alg", "RS256"}, {"jwt.kid", "key-1"}}, spans[1].attrs)
+	assert.Nil(t, spans[1].err)
+	assert.Error(t, spans[2].err)
+}
+
+func TestStartSpan_NilTracer(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, endSpan := startSpan(ctx, nil, "oidc.token")
+	endSpan(nil)
+	assert.Equal(t, ctx, spanCtx)
+}
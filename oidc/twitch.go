@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"os"
+)
+
+// NewTwitchOidcClient はTwitchのクライアントを返す
+//
+// Twitchはuser:read:emailスコープに加えてclaimsパラメータで要求しないとemailとemail_verifiedを返さないため、
+// id_tokenとUserInfoの両方に要求するClaimsRequestを設定する
+//
+// refs: https://dev.twitch.tv/docs/authentication/getting-tokens-oidc/
+func NewTwitchOidcClient(opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		Twitch,
+		"https://id.twitch.tv/oauth2",
+		os.Getenv("TWITCH_CLIENT_ID"),
+		clientSecret(os.Getenv("TWITCH_CLIENT_SECRET")),
+		"https://id.twitch.tv/oauth2/authorize",
+		"https://id.twitch.tv/oauth2/token",
+		"https://id.twitch.tv/oauth2/keys",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = "https://id.twitch.tv/oauth2/userinfo"
+	client.RevocationEndpoint = "https://id.twitch.tv/oauth2/revoke"
+	client.Scopes = []string{"openid", "user:read:email"}
+	claims := map[string]*ClaimRequest{
+		"email":              nil,
+		"email_verified":     nil,
+		"picture":            nil,
+		"preferred_username": nil,
+	}
+	client.ClaimsRequest = &ClaimsRequest{IdToken: claims, UserInfo: claims}
+	client.Apply(opts...)
+
+	return client
+}
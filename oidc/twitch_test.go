@@ -0,0 +1,26 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestNewTwitchOidcClient_LoginUrl(t *testing.T) {
+	client := NewTwitchOidcClient()
+
+	rawUrl, _, err := client.LoginUrl("DummyState", "DummyNonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := loginUrl.Query()
+	assert.Equal(t, "openid user:read:email", query.Get("scope"))
+	assert.JSONEq(t, `{
+  "id_token": {"email": null, "email_verified": null, "picture": null, "preferred_username": null},
+  "userinfo": {"email": null, "email_verified": null, "picture": null, "preferred_username": null}
+}`, query.Get("claims"))
+}
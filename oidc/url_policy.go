@@ -0,0 +1,113 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// UrlPolicy はJWKsやDiscoveryドキュメントを取得するURLの制限
+//
+// issuerを設定ファイルやリクエストのヘッダから受け取る場合に、内部のサービスへのリクエスト(SSRF)に使われないようにする。
+// デフォルトのクライアントでは、IdPへのリクエストのリダイレクト先にも適用する
+type UrlPolicy struct {
+	// AllowHttp はhttpのURLを許可するかどうか。falseの場合でもlocalhostと127.0.0.1などのループバックアドレスはhttpを許可する
+	AllowHttp bool
+	// BlockPrivateAddresses はループバック、リンクローカル、プライベート(RFC 1918など)のアドレスへのリクエストを拒否するかどうか
+	//
+	// 接続前に名前解決したアドレスを確認し、接続時のアドレスも確認してDNSリバインディングも防ぐ。
+	// 接続時の確認はデフォルトのTransportで行うので、HttpClientを指定した場合やhttp.DefaultTransportを差し替えた場合はリクエストがエラーになる。
+	// プロキシ(環境変数の設定を含む)を経由する場合、接続時に確認されるのはプロキシのアドレスになる。
+	// プライベートなアドレスのプロキシには接続できず、接続先のアドレスは名前解決したときにしか確認されない
+	BlockPrivateAddresses bool
+}
+
+// check はrawUrlがhttpsで、BlockPrivateAddressesの場合はプライベートなアドレスに解決されないことを確認する
+func (p UrlPolicy) check(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !p.AllowHttp && !isLoopbackHost(u.Hostname()) {
+			return fmt.Errorf("%w: %s", errInsecureUrl, u.Redacted())
+		}
+	default:
+		return fmt.Errorf("%w: %s", errInsecureUrl, u.Redacted())
+	}
+
+	if !p.BlockPrivateAddresses {
+		return nil
+	}
+	ips, err := lookupIps(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if isPrivateIp(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", errPrivateAddress, u.Hostname(), ip)
+		}
+	}
+
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func lookupIps(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+
+	return ips, nil
+}
+
+// sharedAddressSpace はキャリアグレードNATで使われるアドレス(RFC 6598)
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIp はipがインターネットから到達できないアドレスかどうかを返す
+func isPrivateIp(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// blockPrivateAddress はnet.DialerのControlとして、接続先がプライベートなアドレスの場合に接続を拒否する
+//
+// 名前解決の後に確認するので、確認した後にDNSの応答を変えられても接続されない
+func blockPrivateAddress(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("failed to parse address: %w", err)
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivateIp(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+
+	return nil
+}
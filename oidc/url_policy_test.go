@@ -0,0 +1,141 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUrlPolicy_Check(t *testing.T) {
+	patterns := []struct {
+		desc     string
+		policy   UrlPolicy
+		url      string
+		expected error
+	}{
+		{"https", UrlPolicy{}, "https://203.0.113.1/jwks", nil},
+		{"http", UrlPolicy{}, "http://203.0.113.1/jwks", errInsecureUrl},
+		{"http allowed", UrlPolicy{AllowHttp: true}, "http://203.0.113.1/jwks", nil},
+		{"http on localhost", UrlPolicy{}, "http://localhost:8080/jwks", nil},
+		{"http on loopback", UrlPolicy{}, "http://127.0.0.1:8080/jwks", nil},
+		{"unsupported scheme", UrlPolicy{AllowHttp: true}, "file:///etc/passwd", errInsecureUrl},
+		{"loopback", UrlPolicy{BlockPrivateAddresses: true}, "https://127.0.0.1/jwks", errPrivateAddress},
+		{"http on blocked loopback", UrlPolicy{BlockPrivateAddresses: true}, "http://[::1]/jwks", errPrivateAddress},
+		{"RFC1918", UrlPolicy{BlockPrivateAddresses: true}, "https://10.0.0.1/jwks", errPrivateAddress},
+		{"link-local", UrlPolicy{BlockPrivateAddresses: true}, "https://169.254.169.254/latest/meta-data", errPrivateAddress},
+		{"shared address space", UrlPolicy{BlockPrivateAddresses: true}, "https://100.64.0.1/jwks", errPrivateAddress},
+		{"unique local", UrlPolicy{BlockPrivateAddresses: true}, "https://[fd00::1]/jwks", errPrivateAddress},
+		{"IPv4-mapped private", UrlPolicy{BlockPrivateAddresses: true}, "https://[::ffff:192.168.0.1]/jwks", errPrivateAddress},
+		{"public", UrlPolicy{BlockPrivateAddresses: true}, "https://203.0.113.1/jwks", nil},
+		{"localhost name", UrlPolicy{BlockPrivateAddresses: true}, "https://localhost/jwks", errPrivateAddress},
+	}
+
+	for _, pattern := range patterns {
+		err := pattern.policy.check(context.Background(), pattern.url)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+}
+
+func TestFetchJwks_UrlPolicy(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, "http://jwks.example.com/certs", httpmock.NewStringResponder(http.StatusOK, testJwksBody))
+
+	_, _, err := fetchJwks(context.Background(), httpConfig{}, "http://jwks.example.com/certs")
+	assert.ErrorIs(t, err, errInsecureUrl)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	_, err = discoverProvider(context.Background(), httpConfig{}, "http://op.example.com")
+	assert.ErrorIs(t, err, errInsecureUrl)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestBlockPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testJwksBody))
+	}))
+	defer server.Close()
+
+	// 名前解決の結果を確認した後に接続先が変わった場合も、接続時に拒否する
+	transport, err := defaultTransport(transportConfig{blockPrivateAddresses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.ErrorIs(t, err, errPrivateAddress)
+
+	assert.Nil(t, blockPrivateAddress("tcp", net.JoinHostPort("203.0.113.1", "443"), nil))
+}
+
+func TestHttpConfig_CheckRedirect(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, "https://op.example.com/jwks", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusFound, "")
+		resp.Header.Set("Location", req.URL.Query().Get("to"))
+
+		return resp, nil
+	})
+	httpmock.RegisterResponder(http.MethodGet, "https://jwks.example.com/certs", httpmock.NewStringResponder(http.StatusOK, testJwksBody))
+	httpmock.RegisterResponder(http.MethodGet, "http://jwks.example.com/certs", httpmock.NewStringResponder(http.StatusOK, testJwksBody))
+
+	patterns := []struct {
+		desc     string
+		policy   UrlPolicy
+		to       string
+		expected error
+	}{
+		{"redirect to https", UrlPolicy{}, "https://jwks.example.com/certs", nil},
+		{"redirect to http", UrlPolicy{}, "http://jwks.example.com/certs", errInsecureUrl},
+		{"allowed redirect to http", UrlPolicy{AllowHttp: true}, "http://jwks.example.com/certs", nil},
+	}
+
+	for _, pattern := range patterns {
+		_, _, err := fetchJwks(context.Background(), httpConfig{urlPolicy: pattern.policy}, "https://op.example.com/jwks?to="+url.QueryEscape(pattern.to))
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+
+	// 名前解決やリクエストをせずに、リダイレクト先のプライベートなアドレスを拒否する
+	cfg := httpConfig{urlPolicy: UrlPolicy{BlockPrivateAddresses: true}}
+	req := httptest.NewRequest(http.MethodGet, "https://169.254.169.254/latest/meta-data", nil)
+	assert.ErrorIs(t, cfg.checkRedirect(req, nil), errPrivateAddress)
+	assert.Error(t, cfg.checkRedirect(httptest.NewRequest(http.MethodGet, "https://203.0.113.1/", nil), make([]*http.Request, maxRedirects)))
+}
+
+func TestHttpConfig_UnenforceableUrlPolicy(t *testing.T) {
+	policy := UrlPolicy{BlockPrivateAddresses: true}
+
+	// 指定されたクライアントでは接続時のアドレスを確認できない
+	_, err := httpConfig{client: &http.Client{}, urlPolicy: policy}.httpClient()
+	assert.ErrorIs(t, err, errUnenforceableUrlPolicy)
+
+	_, err = New(
+		"https://op.example.com",
+		WithClientId("DummyClientId"),
+		WithEndpoints("https://op.example.com/authorize", "https://op.example.com/token", "https://op.example.com/jwks"),
+		WithHttpClient(&http.Client{}),
+		WithUrlPolicy(policy),
+	)
+	assert.ErrorIs(t, err, errUnenforceableUrlPolicy)
+
+	// http.DefaultTransportが差し替えられている場合も確認できない
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	_, err = httpConfig{urlPolicy: policy}.httpClient()
+	assert.ErrorIs(t, err, errUnenforceableUrlPolicy)
+}
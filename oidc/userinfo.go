@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// userInfoJwtContentType はUserInfoエンドポイントが署名されたJWTを返す場合のContent-Type
+const userInfoJwtContentType = "application/jwt"
+
+// userInfo はUserInfoエンドポイントから取得したクレーム
+type userInfo struct {
+	rawClaims []byte
+	claims    IdTokenClaims
+}
+
+// UserInfo はアクセストークンを使ってUserInfoエンドポイントからユーザーのクレームを取得する
+//
+// レスポンスはJSONと署名されたJWTの両方に対応し、JWTの場合はid_tokenと同じ鍵で署名を検証する。
+// 別のユーザーのクレームを受け入れないように、subがidTokenのsubと一致するかを確認する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#UserInfoResponse
+func (c oidcClient) UserInfo(ctx context.Context, accessToken string, idToken *idToken) (_ *userInfo, err error) {
+	ctx, endSpan := startSpan(ctx, c.Tracer, "oidc.userinfo")
+	defer func() { endSpan(err) }()
+
+	return c.userInfo(ctx, accessToken, idToken)
+}
+
+func (c oidcClient) userInfo(ctx context.Context, accessToken string, idToken *idToken) (*userInfo, error) {
+	if c.UserInfoEndpoint == "" {
+		return nil, errUserInfoEndpointMissing
+	}
+	if idToken == nil {
+		return nil, errIdTokenMissing
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.Timeouts.userInfo())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodGet, c.UserInfoEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request of GET userinfo endpoint: %w", err)
+	}
+	resp, body, err := c.sendWithAccessToken(req, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET userinfo endpoint: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: GET userinfo endpoint returned %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	rawClaims := body
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == userInfoJwtContentType {
+		rawClaims, err = c.verifyUserInfoJwt(ctx, string(body))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info := &userInfo{rawClaims: rawClaims}
+	if err := info.Claims(&info.claims); err != nil {
+		return nil, err
+	}
+
+	expectedSub := idToken.StandardClaims().Sub
+	if subtle.ConstantTimeCompare([]byte(info.claims.Sub), []byte(expectedSub)) != 1 {
+		return nil, fmt.Errorf("%w: %s", errUserInfoSubMismatch, info.claims.Sub)
+	}
+
+	return info, nil
+}
+
+// verifyUserInfoJwt は署名されたUserInfoレスポンスの署名を検証し、payloadを返す
+//
+// issとaudは含まれる場合のみ検証する
+func (c oidcClient) verifyUserInfoJwt(ctx context.Context, rawJwt string) ([]byte, error) {
+	token, err := NewIdToken(rawJwt, c.IdProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse userinfo JWT: %w", err)
+	}
+
+	v := c.Verifier()
+	if err := v.checkAlg(token.header.Alg); err != nil {
+		return nil, err
+	}
+	if err := v.verifySignature(ctx, token); err != nil {
+		return nil, err
+	}
+
+	claims := token.StandardClaims()
+	if claims.Iss != "" {
+		if err := v.tenantClaims(token).validateIss(claims.Iss); err != nil {
+			return nil, err
+		}
+	}
+	if len(claims.Aud) > 0 && !claims.Aud.contains(c.ClientId) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAudience, []string(claims.Aud))
+	}
+
+	rawClaims := json.RawMessage{}
+	if err := token.Claims(&rawClaims); err != nil {
+		return nil, err
+	}
+
+	return rawClaims, nil
+}
+
+// Claims はUserInfoレスポンスのクレームをvにunmarshalする
+//
+// IdP固有のクレームなど、IdTokenClaimsに含まれないクレームを取り出したい場合に使う
+func (info *userInfo) Claims(v interface{}) error {
+	if err := json.Unmarshal(info.rawClaims, v); err != nil {
+		return fmt.Errorf("failed to unmarshal userinfo response: %w", err)
+	}
+
+	return nil
+}
+
+// StandardClaims はUserInfoレスポンスのクレームのうちOIDC Coreで定義されているものを返す
+func (info *userInfo) StandardClaims() IdTokenClaims {
+	return info.claims
+}
@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestOidcClient_UserInfo(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anotherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewGoogleOidcClient()
+	client.KeyProvider = StaticKeys{"key-1": &rsaKey.PublicKey}
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	idToken, err := NewIdToken(encodeTokenForTest(
+		t,
+		map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		validGooglePayloadForTest(),
+		rsaSignerForTest(rsaKey),
+	), Google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedUserInfo := func(key *rsa.PrivateKey, payload map[string]interface{}) string {
+		return encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(key))
+	}
+
+	patterns := []struct {
+		desc        string
+		status      int
+		contentType string
+		body        string
+		expected    error
+	}{
+		{
+			"json",
+			http.StatusOK,
+			"application/json; charset=utf-8",
+			`{"sub": "1234567890", "email": "user@example.com", "hd": "example.com"}`,
+			nil,
+		},
+		{
+			"signed jwt",
+			http.StatusOK,
+			"application/jwt",
+			signedUserInfo(rsaKey, map[string]interface{}{
+				"iss":   "https://accounts.google.com",
+				"aud":   os.Getenv("GOOGLE_CLIENT_ID"),
+				"sub":   "1234567890",
+				"email": "user@example.com",
+				"hd":    "example.com",
+			}),
+			nil,
+		},
+		{
+			"signed jwt without iss and aud",
+			http.StatusOK,
+			"application/jwt",
+			signedUserInfo(rsaKey, map[string]interface{}{"sub": "1234567890", "email": "user@example.com", "hd": "example.com"}),
+			nil,
+		},
+		{
+			"signed by another key",
+			http.StatusOK,
+			"application/jwt",
+			signedUserInfo(anotherKey, map[string]interface{}{"sub": "1234567890"}),
+			ErrSignatureInvalid,
+		},
+		{
+			"aud mismatch",
+			http.StatusOK,
+			"application/jwt",
+			signedUserInfo(rsaKey, map[string]interface{}{"aud": "another-client", "sub": "1234567890"}),
+			ErrInvalidAudience,
+		},
+		{
+			"sub mismatch",
+			http.StatusOK,
+			"application/json",
+			`{"sub": "0987654321", "email": "another@example.com"}`,
+			errUserInfoSubMismatch,
+		},
+		{
+			"invalid access token",
+			http.StatusUnauthorized,
+			"application/json",
+			`{"error": "invalid_token"}`,
+			errUnexpectedStatus,
+		},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pattern := range patterns {
+		httpmock.Reset()
+		pattern := pattern
+		httpmock.RegisterResponder(http.MethodGet, client.UserInfoEndpoint, func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"), pattern.desc)
+			resp := httpmock.NewStringResponse(pattern.status, pattern.body)
+			resp.Header.Set("Content-Type", pattern.contentType)
+
+			return resp, nil
+		})
+
+		info, err := client.UserInfo(context.Background(), "DummyAccessToken", idToken)
+
+		if pattern.expected == nil {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, "user@example.com", info.StandardClaims().Email, pattern.desc)
+
+			custom := struct {
+				HostedDomain string `json:"hd"`
+			}{}
+			assert.Nil(t, info.Claims(&custom), pattern.desc)
+			assert.Equal(t, "example.com", custom.HostedDomain, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expected, pattern.desc)
+		}
+	}
+
+	client.UserInfoEndpoint = ""
+	_, err = client.UserInfo(context.Background(), "DummyAccessToken", idToken)
+	assert.ErrorIs(t, err, errUserInfoEndpointMissing)
+}
@@ -0,0 +1,227 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// verifier はid_tokenの署名とpayloadを検証する
+type verifier struct {
+	idProvider   IdProvider
+	claims       claimsValidator
+	clientSecret clientSecret
+	allowedAlgs  []string
+	allowHS256   bool
+	keyProvider  KeyProvider
+	// logger は検証に失敗した理由を出力するロガー。nilの場合は出力しない
+	logger *slog.Logger
+	// metrics は検証にかかった時間と結果を受け取る。nilの場合は記録しない
+	metrics MetricsRecorder
+	// tracer は検証のスパンを作成する。nilの場合は作成しない
+	tracer Tracer
+	// replayCache は検証済みのjtiを記録する。nilの場合は再利用を確認しない
+	replayCache ReplayCache
+}
+
+func newVerifier(
+	idProvider IdProvider,
+	claims claimsValidator,
+	clientSecret clientSecret,
+	allowedAlgs []string,
+	allowHS256 bool,
+	keyProvider KeyProvider,
+) *verifier {
+	return &verifier{
+		idProvider:   idProvider,
+		claims:       claims,
+		clientSecret: clientSecret,
+		allowedAlgs:  allowedAlgs,
+		allowHS256:   allowHS256,
+		keyProvider:  keyProvider,
+	}
+}
+
+// Verifier はクライアントの設定でid_tokenを検証するverifierを返す
+//
+// KeyProviderが設定されていない場合はJWKsエンドポイントから公開鍵を取得する
+func (c oidcClient) Verifier() *verifier {
+	keyProvider := c.KeyProvider
+	if keyProvider == nil {
+		keyProvider = newRemoteKeySet(c.JwksEndpoint, c.JwksCache, c.X5cRoots, c.httpConfig())
+	}
+
+	claims := newClaimsValidator(c.issuers(), c.ClientId, leewayOrDefault(c.Leeway))
+	claims.requireAzp = c.RequireAzp
+	claims.requireAuthTime = c.RequireAuthTime
+	claims.maxAge = c.MaxAge
+	claims.policy = c.AuthnPolicy
+
+	v := newVerifier(c.IdProvider, claims, c.clientSecret, c.AllowedAlgs, c.AllowHS256, keyProvider)
+	v.logger = c.Logger
+	v.metrics = c.Metrics
+	v.tracer = c.Tracer
+	v.replayCache = c.ReplayCache
+
+	return v
+}
+
+// issuers はid_tokenのissとして受け入れる値を返す
+func (c oidcClient) issuers() []string {
+	if c.IdProvider == Google {
+		return googleIssuers[:]
+	}
+
+	return []string{c.Issuer}
+}
+
+// Verify はJWTの署名とpayloadの中身を検証する
+func (v verifier) Verify(ctx context.Context, token *idToken) error {
+	ctx, endSpan := startSpan(
+		ctx,
+		v.tracer,
+		"oidc.verify",
+		SpanAttribute{"jwt.alg", token.header.Alg},
+		SpanAttribute{"jwt.kid", token.header.Kid},
+	)
+	start := time.Now()
+	err := v.verify(ctx, token)
+	endSpan(err)
+	metricsOrNop(v.metrics).TokenValidated(v.idProvider.String(), time.Since(start), err)
+	if err != nil {
+		loggerOrDiscard(v.logger).WarnContext(
+			ctx,
+			"id_token validation failed",
+			slog.String("alg", token.header.Alg),
+			slog.String("kid", token.header.Kid),
+			slog.Any("error", err),
+		)
+	}
+
+	return err
+}
+
+// tenantClaims はtokenのテナントIDのクレームでissuersのプレースホルダを置き換えたclaimsValidatorを返す
+//
+// id_token以外のJWTではテナントIDのクレームがないことがあるので、その場合は置き換えず、
+// validateIssでプレースホルダが任意のテナントIDと一致するようにする
+func (v verifier) tenantClaims(token *idToken) claimsValidator {
+	if payload, ok := token.Payload.(tenantIdTokenPayload); ok && payload.tenantId() != "" {
+		return v.claims.forTenant(payload.tenantId())
+	}
+
+	return v.claims
+}
+
+func (v verifier) verify(ctx context.Context, token *idToken) error {
+	// 公開鍵の取得より前に確認し、想定外のalgのトークンでJWKsエンドポイントにアクセスしないようにする
+	if err := v.checkAlg(token.header.Alg); err != nil {
+		return err
+	}
+
+	if err := v.verifySignature(ctx, token); err != nil {
+		return err
+	}
+
+	// id_tokenではテナントIDのクレームを必須とし、issのテナントIDと一致することを確認する
+	claims := v.claims
+	if payload, ok := token.Payload.(tenantIdTokenPayload); ok {
+		claims = claims.forTenant(payload.tenantId())
+	}
+	if err := claims.validate(token.Payload.standardClaims()); err != nil {
+		return fmt.Errorf("failed to validate id_token payload: %w", err)
+	}
+	if payload, ok := token.Payload.(validatingIdTokenPayload); ok {
+		if err := payload.validate(); err != nil {
+			return fmt.Errorf("failed to validate id_token payload: %w", err)
+		}
+	}
+
+	// 他の検証に失敗したトークンのjtiを記録しないように、最後に確認する
+	return v.checkReplay(ctx, token.Payload.standardClaims())
+}
+
+// VerifyAndDecode は生のid_tokenを検証し、payloadをTにunmarshalして返す
+//
+// Goではメソッドに型パラメータを持たせられないので関数として定義している
+func VerifyAndDecode[T any](ctx context.Context, v *verifier, rawToken string) (T, error) {
+	var claims T
+
+	token, err := NewIdToken(rawToken, v.idProvider)
+	if err != nil {
+		return claims, fmt.Errorf("failed to parse id_token: %w", err)
+	}
+	if err := v.Verify(ctx, token); err != nil {
+		return claims, err
+	}
+	if err := token.Claims(&claims); err != nil {
+		return claims, err
+	}
+
+	return claims, nil
+}
+
+// VerifyRawToken は生のid_tokenを検証し、OIDC Coreで定義されたクレームを返す
+//
+// 他のパッケージがverifierをinterfaceで受け取れるように、引数と戻り値はexportされた型にしている
+func (v *verifier) VerifyRawToken(ctx context.Context, rawToken string) (IdTokenClaims, error) {
+	return VerifyAndDecode[IdTokenClaims](ctx, v, rawToken)
+}
+
+// checkAlg はヘッダのalgが許可されたアルゴリズムかを確認する
+//
+// alg=noneは署名のないトークンなので、許可リストの内容に関わらず常に拒否する
+func (v verifier) checkAlg(alg string) error {
+	if alg == "" || strings.EqualFold(alg, "none") {
+		return errAlgNone
+	}
+
+	if alg == "HS256" {
+		if !v.allowHS256 {
+			return errHmacNotAllowed
+		}
+
+		return nil
+	}
+	for _, allowed := range v.allowedAlgs {
+		if alg == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrAlgNotAllowed, alg)
+}
+
+// verifySignature はヘッダのalgに応じて公開鍵もしくはclient_secretで署名を検証する
+//
+// HS256の鍵には必ずclient_secretを使い、JWKsの公開鍵をHMACの鍵として使うことはない。
+// これにより公開鍵をHMACの鍵として署名されたトークンを受け入れてしまう、RS256からHS256へのダウングレード攻撃を防ぐ
+func (v verifier) verifySignature(ctx context.Context, token *idToken) error {
+	if token.header.Alg != "HS256" {
+		pubKey, err := v.keyProvider.Key(ctx, token.header.Kid, token.header.Alg)
+		if err != nil {
+			return fmt.Errorf("failed to get public key: %w", err)
+		}
+
+		return token.validateSignature(pubKey)
+	}
+
+	if !v.allowHS256 {
+		return errHmacNotAllowed
+	}
+
+	decSignature, err := base64.RawURLEncoding.DecodeString(token.rawSignature)
+	if err != nil {
+		return fmt.Errorf("failed to base64 decode id_token signature: %w", err)
+	}
+
+	if err := verifyHmac(v.clientSecret, crypto.SHA256, token.signingInput(), decSignature); err != nil {
+		return fmt.Errorf("failed to verify id_token signature: %w", err)
+	}
+
+	return nil
+}
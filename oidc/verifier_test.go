@@ -0,0 +1,267 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeTokenForTest はheaderとpayloadをbase64urlエンコードし、signで署名したJWTを返す
+func encodeTokenForTest(t *testing.T, header map[string]interface{}, payload map[string]interface{}, sign func(signingInput string) []byte) string {
+	byteHeader, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytePayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingInput := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString(byteHeader),
+		base64.RawURLEncoding.EncodeToString(bytePayload),
+	}, ".")
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(signingInput))
+}
+
+func hmacSignerForTest(secret string) func(signingInput string) []byte {
+	return func(signingInput string) []byte {
+		mac := hmac.New(crypto.SHA256.New, []byte(secret))
+		mac.Write([]byte(signingInput))
+
+		return mac.Sum(nil)
+	}
+}
+
+func rsaSignerForTest(key *rsa.PrivateKey) func(signingInput string) []byte {
+	return func(signingInput string) []byte {
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest(crypto.SHA256, signingInput))
+		if err != nil {
+			panic(err)
+		}
+
+		return sig
+	}
+}
+
+// rsaJwksForTest はRSA公開鍵をkidと共にJWKsのJSONにして返す
+func rsaJwksForTest(t *testing.T, pubKey *rsa.PublicKey, kid string) []byte {
+	raw, err := json.Marshal(jwks{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(pubKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pubKey.E)).Bytes()),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func validGooglePayloadForTest() map[string]interface{} {
+	return map[string]interface{}{
+		"iss": "https://accounts.google.com",
+		"aud": os.Getenv("GOOGLE_CLIENT_ID"),
+		"sub": "1234567890",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+}
+
+func TestVerifier_Verify_HS256(t *testing.T) {
+	const secret = "super-secret-value"
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		allowHS256    bool
+		clientSecret  string
+		signSecret    string
+	}{
+		{"valid", true, true, secret, secret},
+		{"HS256 not allowed", false, false, secret, secret},
+		{"wrong secret", false, true, secret, "another-secret"},
+		{"empty client secret", false, true, "", ""},
+	}
+
+	for _, pattern := range patterns {
+		rawToken := encodeTokenForTest(
+			t,
+			map[string]interface{}{"alg": "HS256", "typ": "JWT"},
+			validGooglePayloadForTest(),
+			hmacSignerForTest(pattern.signSecret),
+		)
+		token, err := NewIdToken(rawToken, Google)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v := newVerifier(
+			Google,
+			newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+			clientSecret(pattern.clientSecret),
+			[]string{"RS256"},
+			pattern.allowHS256,
+			StaticKeys{},
+		)
+		err = v.Verify(context.Background(), token)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestVerifier_Verify_KeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := NewKeySet(rsaJwksForTest(t, &rsaKey.PublicKey, "key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		kid           string
+		payload       map[string]interface{}
+	}{
+		{"valid", true, "key-1", validGooglePayloadForTest()},
+		{"unknown kid", false, "key-2", validGooglePayloadForTest()},
+		{"invalid payload", false, "key-1", map[string]interface{}{"iss": "https://example.com"}},
+	}
+
+	for _, pattern := range patterns {
+		rawToken := encodeTokenForTest(
+			t,
+			map[string]interface{}{"alg": "RS256", "kid": pattern.kid, "typ": "JWT"},
+			pattern.payload,
+			rsaSignerForTest(rsaKey),
+		)
+		token, err := NewIdToken(rawToken, Google)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v := newVerifier(
+			Google,
+			newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+			"",
+			[]string{"RS256"},
+			false,
+			set,
+		)
+		err = v.Verify(context.Background(), token)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestVerifier_CheckAlg(t *testing.T) {
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		allowedAlgs   []string
+		allowHS256    bool
+		alg           string
+	}{
+		{"allowed", true, []string{"RS256", "ES256"}, false, "ES256"},
+		{"not allowed", false, []string{"RS256"}, false, "ES256"},
+		{"none", false, []string{"RS256", "none"}, false, "none"},
+		{"None", false, []string{"RS256"}, false, "None"},
+		{"empty alg", false, []string{"RS256"}, false, ""},
+		{"HS256 enabled", true, []string{"RS256"}, true, "HS256"},
+		{"HS256 in allowlist but not enabled", false, []string{"RS256", "HS256"}, false, "HS256"},
+	}
+
+	for _, pattern := range patterns {
+		v := newVerifier(Google, claimsValidator{}, "", pattern.allowedAlgs, pattern.allowHS256, StaticKeys{})
+		err := v.checkAlg(pattern.alg)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
+
+func TestVerifyAndDecode(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newVerifier(
+		Google,
+		newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+		"",
+		[]string{"RS256"},
+		false,
+		StaticKeys{"key-1": &rsaKey.PublicKey},
+	)
+
+	type customClaims struct {
+		Sub          string `json:"sub"`
+		HostedDomain string `json:"hd"`
+	}
+
+	payload := validGooglePayloadForTest()
+	payload["hd"] = "example.com"
+	rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+
+	claims, err := VerifyAndDecode[customClaims](context.Background(), v, rawToken)
+	assert.Nil(t, err)
+	assert.Equal(t, customClaims{Sub: "1234567890", HostedDomain: "example.com"}, claims)
+
+	// 検証に失敗した場合はゼロ値を返す
+	payload["exp"] = time.Now().Add(-time.Hour).Unix()
+	rawToken = encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, payload, rsaSignerForTest(rsaKey))
+	claims, err = VerifyAndDecode[customClaims](context.Background(), v, rawToken)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.Equal(t, customClaims{}, claims)
+}
+
+func TestVerifier_VerifyRawToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newVerifier(
+		Google,
+		newClaimsValidator(googleIssuers[:], os.Getenv("GOOGLE_CLIENT_ID"), defaultLeeway),
+		"",
+		[]string{"RS256"},
+		false,
+		StaticKeys{"key-1": &rsaKey.PublicKey},
+	)
+
+	rawToken := encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, validGooglePayloadForTest(), rsaSignerForTest(rsaKey))
+	claims, err := v.VerifyRawToken(context.Background(), rawToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234567890", claims.Sub)
+
+	_, err = v.VerifyRawToken(context.Background(), "invalid-token")
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// xUsersMeUrl はXの認証済みユーザーの情報を返すAPI
+//
+// refs: https://developer.x.com/en/docs/x-api/users/lookup/api-reference/get-users-me
+const xUsersMeUrl = "https://api.x.com/2/users/me"
+
+// xProvider はXのOAuth 2.0のプロバイダ
+//
+// XはOIDCに対応していないため、アクセストークンでusers/meからユーザーの情報を取得する
+type xProvider struct {
+	*oidcClient
+	// UsersMeUrl はusers/meのURL
+	UsersMeUrl string
+}
+
+// xUsersMe はusers/meのレスポンス
+type xUsersMe struct {
+	Data struct {
+		Id              string `json:"id"`
+		Name            string `json:"name"`
+		Username        string `json:"username"`
+		ProfileImageUrl string `json:"profile_image_url"`
+	} `json:"data"`
+}
+
+// NewXProvider はXのプロバイダを返す
+//
+// XではPKCEが必須なので、DisablePkceは指定せず、LoginにはLoginUrlが返したcode_verifierをWithCodeVerifierで必ず渡す。
+// コンフィデンシャルクライアントはBasic認証でクライアント認証する
+//
+// refs: https://developer.x.com/en/docs/authentication/oauth-2-0/authorization-code
+func NewXProvider(opts ...ClientOption) *xProvider {
+	client := newOidcClient(
+		X,
+		"",
+		os.Getenv("X_CLIENT_ID"),
+		clientSecret(os.Getenv("X_CLIENT_SECRET")),
+		"https://x.com/i/oauth2/authorize",
+		"https://api.x.com/2/oauth2/token",
+		"",
+		nil,
+	)
+	client.Scopes = []string{"users.read", "tweet.read"}
+	client.ClientAuthMethod = ClientSecretBasic
+	client.RevocationEndpoint = "https://api.x.com/2/oauth2/revoke"
+	client.Apply(opts...)
+
+	return &xProvider{oidcClient: client, UsersMeUrl: xUsersMeUrl}
+}
+
+// Login は認可コードをアクセストークンに交換し、users/meからユーザーの情報を取得する
+//
+// nonceは使われない。optsにWithCodeVerifierが含まれない場合はエラーを返す
+func (p xProvider) Login(ctx context.Context, code string, _ string, opts ...AuthCodeOption) (*User, error) {
+	if !hasParam(opts, "code_verifier") {
+		return nil, errCodeVerifierMissing
+	}
+
+	tokenResp, err := p.PostTokenEndpoint(ctx, code, p.RedirectUrl, "authorization_code", opts...)
+	if err != nil {
+		return nil, err
+	}
+	token := newToken(tokenResp)
+
+	values := url.Values{}
+	values.Set("user.fields", "profile_image_url")
+	me := xUsersMe{}
+	if err := p.getJson(ctx, p.UsersMeUrl+"?"+values.Encode(), token.AccessToken, &me); err != nil {
+		return nil, fmt.Errorf("failed to GET X users/me: %w", err)
+	}
+
+	// XのAPIではメールアドレスを取得できない
+	return &User{
+		IdProvider: X,
+		Sub:        me.Data.Id,
+		Name:       me.Data.Name,
+		Username:   me.Data.Username,
+		Picture:    me.Data.ProfileImageUrl,
+		Token:      token,
+	}, nil
+}
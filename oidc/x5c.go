@@ -0,0 +1,60 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// x5cPublicKey はJWKのx5cの先頭の証明書から公開鍵を取り出す
+//
+// rootsが指定されている場合は、x5cの2番目以降を中間証明書として証明書チェーンを検証する
+//
+// refs: https://datatracker.ietf.org/doc/html/rfc7517#section-4.7
+func (key jwk) x5cPublicKey(roots *x509.CertPool) (crypto.PublicKey, error) {
+	if len(key.X5c) == 0 {
+		return nil, errX5cRequired
+	}
+
+	certs := make([]*x509.Certificate, 0, len(key.X5c))
+	for _, encCert := range key.X5c {
+		// x5cはbase64urlではなく通常のbase64でエンコードされたDER
+		der, err := base64.StdEncoding.DecodeString(encCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 x5c certificate: %w", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse x5c certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return nil, fmt.Errorf("failed to verify x5c certificate chain: %w", err)
+		}
+	}
+
+	return certs[0].PublicKey, nil
+}
+
+// equalPublicKey は2つの公開鍵が同じかを返す
+func equalPublicKey(a crypto.PublicKey, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+
+	return key.Equal(b)
+}
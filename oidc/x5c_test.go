@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newCertForTest はparentで署名した証明書を作る。parentがnilの場合は自己署名のCA証明書を作る
+func newCertForTest(t *testing.T, pubKey interface{}, parent *x509.Certificate, signer *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "sns-login test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pubKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestJwk_PublicKeyFor_X5c(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert := newCertForTest(t, &caKey.PublicKey, nil, caKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	otherCaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(newCertForTest(t, &otherCaKey.PublicKey, nil, otherCaKey))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafCert := newCertForTest(t, &rsaKey.PublicKey, caCert, caKey)
+	x5c := []string{base64.StdEncoding.EncodeToString(leafCert.Raw)}
+
+	anotherRsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes())
+	anotherN := base64.RawURLEncoding.EncodeToString(anotherRsaKey.N.Bytes())
+
+	patterns := []struct {
+		desc          string
+		isExpectValid bool
+		key           jwk
+		roots         *x509.CertPool
+	}{
+		{"x5c only", true, jwk{Kty: "RSA", X5c: x5c}, nil},
+		{"x5c verified by roots", true, jwk{Kty: "RSA", X5c: x5c}, roots},
+		{"x5c and matching n/e", true, jwk{Kty: "RSA", N: n, E: "AQAB", X5c: x5c}, roots},
+		{"x5c not verified by roots", false, jwk{Kty: "RSA", X5c: x5c}, otherRoots},
+		{"x5c and mismatched n/e", false, jwk{Kty: "RSA", N: anotherN, E: "AQAB", X5c: x5c}, roots},
+		{"x5c required by roots", false, jwk{Kty: "RSA", N: n, E: "AQAB"}, roots},
+		{"invalid x5c", false, jwk{Kty: "RSA", X5c: []string{"invalid"}}, nil},
+	}
+
+	for _, pattern := range patterns {
+		pubKey, err := pattern.key.publicKeyFor("RS256", pattern.roots)
+
+		if pattern.isExpectValid {
+			assert.Nil(t, err, pattern.desc)
+			assert.Equal(t, &rsaKey.PublicKey, pubKey, pattern.desc)
+		} else {
+			assert.Error(t, err, pattern.desc)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package oidc
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var _ Provider = xProvider{}
+
+func TestXProvider_Login(t *testing.T) {
+	provider := NewXProvider()
+	provider.ClientId = "DummyClientId"
+	provider.clientSecret = "DummyClientSecret"
+	provider.Retry = RetryPolicy{MaxAttempts: 1}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodPost, provider.tokenEndpoint, func(req *http.Request) (*http.Response, error) {
+		clientId, secret, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "DummyClientId", clientId)
+		assert.Equal(t, "DummyClientSecret", secret)
+		assert.Nil(t, req.ParseForm())
+		assert.Equal(t, "DummyVerifier", req.PostForm.Get("code_verifier"))
+		assert.Empty(t, req.PostForm.Get("client_secret"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "token_type": "bearer",
+  "expires_in": 7200,
+  "access_token": "DummyAccessToken",
+  "scope": "users.read tweet.read"
+}`), nil
+	})
+	httpmock.RegisterResponder(http.MethodGet, xUsersMeUrl, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer DummyAccessToken", req.Header.Get("Authorization"))
+		assert.Equal(t, "profile_image_url", req.URL.Query().Get("user.fields"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{
+  "data": {
+    "id": "2244994945",
+    "name": "Taro X",
+    "username": "taro_x",
+    "profile_image_url": "https://pbs.twimg.com/profile_images/normal.jpg"
+  }
+}`), nil
+	})
+
+	user, err := provider.Login(context.Background(), "DummyCode", "", WithCodeVerifier("DummyVerifier"))
+	assert.Nil(t, err)
+	assert.Equal(t, &User{
+		IdProvider: X,
+		Sub:        "2244994945",
+		Name:       "Taro X",
+		Username:   "taro_x",
+		Picture:    "https://pbs.twimg.com/profile_images/normal.jpg",
+		Token:      user.Token,
+	}, user)
+	assert.Equal(t, "DummyAccessToken", user.Token.AccessToken)
+}
+
+func TestXProvider_Login_WithoutCodeVerifier(t *testing.T) {
+	provider := NewXProvider()
+
+	_, err := provider.Login(context.Background(), "DummyCode", "")
+	assert.ErrorIs(t, err, errCodeVerifierMissing)
+}
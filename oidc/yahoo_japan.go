@@ -0,0 +1,80 @@
+package oidc
+
+import (
+	"context"
+	"os"
+)
+
+// yahooJapanIssuer はYahoo! JAPAN(Yahoo! ID連携 v2)のissuer
+//
+// refs: https://developer.yahoo.co.jp/yconnect/v2/
+const yahooJapanIssuer = "https://auth.login.yahoo.co.jp/yconnect/v2"
+
+// NewYahooJapanOidcClient はYahoo! JAPANのクライアントを返す
+//
+// Yahoo! JAPANでは認可リクエストのnonceが必須なので、AuthUrlには必ずRandomNonceで生成したnonceを渡し、
+// コールバックでVerifyNonceにより確認する
+//
+// refs: https://developer.yahoo.co.jp/yconnect/v2/authorization_code/authorization.html
+func NewYahooJapanOidcClient(opts ...ClientOption) *oidcClient {
+	client := newOidcClient(
+		YahooJapan,
+		yahooJapanIssuer,
+		os.Getenv("YAHOO_JAPAN_CLIENT_ID"),
+		clientSecret(os.Getenv("YAHOO_JAPAN_CLIENT_SECRET")),
+		"https://auth.login.yahoo.co.jp/yconnect/v2/authorization",
+		"https://auth.login.yahoo.co.jp/yconnect/v2/token",
+		"https://auth.login.yahoo.co.jp/yconnect/v2/jwks",
+		[]string{"RS256"},
+	)
+	client.UserInfoEndpoint = "https://userinfo.yahooapis.jp/yconnect/v2/attribute"
+	client.Apply(opts...)
+
+	return client
+}
+
+// DiscoverYahooJapanOidcClient はYahoo! JAPANのDiscoveryドキュメントの内容で設定したクライアントを返す
+//
+// エンドポイントが変更された場合にも追従できるが、Discoveryドキュメントの取得に失敗した場合はエラーになる
+func DiscoverYahooJapanOidcClient(ctx context.Context) (*oidcClient, error) {
+	metadata, err := DiscoverProvider(ctx, yahooJapanIssuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.NewOidcClient(YahooJapan, os.Getenv("YAHOO_JAPAN_CLIENT_ID"), os.Getenv("YAHOO_JAPAN_CLIENT_SECRET")), nil
+}
+
+// YahooJapanUserInfo はYahoo! JAPANのUserInfo(属性取得)APIのレスポンス
+//
+// userInfo.Claimsでunmarshalする。氏名のカナ表記はgiven_name#ja-Kana-JPのように言語タグ付きのクレームで返される
+//
+// refs: https://developer.yahoo.co.jp/yconnect/v2/userinfo.html
+type YahooJapanUserInfo struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	GivenNameKana string `json:"given_name#ja-Kana-JP"`
+	// FamilyNameKana は姓のカナ表記
+	FamilyNameKana string `json:"family_name#ja-Kana-JP"`
+	Nickname       string `json:"nickname"`
+	Picture        string `json:"picture"`
+	Email          string `json:"email"`
+	EmailVerified  bool   `json:"email_verified"`
+	Gender         string `json:"gender"`
+	// Birthdate は生年。Yahoo! JAPANではYYYY形式で返される
+	Birthdate string            `json:"birthdate"`
+	Address   YahooJapanAddress `json:"address"`
+}
+
+// YahooJapanAddress はYahoo! JAPANのUserInfoのaddressクレーム
+type YahooJapanAddress struct {
+	Country    string `json:"country"`
+	PostalCode string `json:"postal_code"`
+	// Region は都道府県
+	Region string `json:"region"`
+	// Locality は市区町村
+	Locality  string `json:"locality"`
+	Formatted string `json:"formatted"`
+}
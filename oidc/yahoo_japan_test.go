@@ -0,0 +1,90 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDiscoverYahooJapanOidcClient(t *testing.T) {
+	defaultDiscoveryCache.Purge()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		http.MethodGet,
+		yahooJapanIssuer+"/.well-known/openid-configuration",
+		httpmock.NewStringResponder(http.StatusOK, `{
+  "issuer": "https://auth.login.yahoo.co.jp/yconnect/v2",
+  "authorization_endpoint": "https://auth.login.yahoo.co.jp/yconnect/v2/authorization",
+  "token_endpoint": "https://auth.login.yahoo.co.jp/yconnect/v2/token",
+  "userinfo_endpoint": "https://userinfo.yahooapis.jp/yconnect/v2/attribute",
+  "jwks_uri": "https://auth.login.yahoo.co.jp/yconnect/v2/jwks",
+  "id_token_signing_alg_values_supported": ["RS256"]
+}`),
+	)
+
+	client, err := DiscoverYahooJapanOidcClient(context.Background())
+	assert.Nil(t, err)
+
+	expected := NewYahooJapanOidcClient()
+	assert.Equal(t, expected.Issuer, client.Issuer)
+	assert.Equal(t, expected.authEndpoint, client.authEndpoint)
+	assert.Equal(t, expected.tokenEndpoint, client.tokenEndpoint)
+	assert.Equal(t, expected.JwksEndpoint, client.JwksEndpoint)
+	assert.Equal(t, expected.UserInfoEndpoint, client.UserInfoEndpoint)
+	assert.Equal(t, expected.AllowedAlgs, client.AllowedAlgs)
+}
+
+func TestNewYahooJapanOidcClient_UserInfo(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewYahooJapanOidcClient()
+	client.ClientId = "DummyClientId"
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+
+	idToken, err := NewIdToken(encodeTokenForTest(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, map[string]interface{}{
+		"iss":   yahooJapanIssuer,
+		"sub":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		"aud":   "DummyClientId",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "DummyNonce",
+	}, rsaSignerForTest(rsaKey)), YahooJapan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, client.UserInfoEndpoint, httpmock.NewStringResponder(http.StatusOK, `{
+  "sub": "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+  "name": "矢風 太郎",
+  "given_name": "太郎",
+  "given_name#ja-Kana-JP": "タロウ",
+  "family_name": "矢風",
+  "family_name#ja-Kana-JP": "ヤフウ",
+  "email": "yconnect@example.com",
+  "email_verified": true,
+  "birthdate": "1990",
+  "address": {"country": "JP", "postal_code": "1028282", "region": "東京都", "locality": "千代田区"}
+}`))
+
+	info, err := client.UserInfo(context.Background(), "DummyAccessToken", idToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := YahooJapanUserInfo{}
+	assert.Nil(t, info.Claims(&actual))
+	assert.Equal(t, "タロウ", actual.GivenNameKana)
+	assert.Equal(t, "ヤフウ", actual.FamilyNameKana)
+	assert.Equal(t, "1990", actual.Birthdate)
+	assert.Equal(t, YahooJapanAddress{Country: "JP", PostalCode: "1028282", Region: "東京都", Locality: "千代田区"}, actual.Address)
+	assert.Equal(t, "yconnect@example.com", info.StandardClaims().Email)
+}
@@ -0,0 +1,188 @@
+package oidctest
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	conformanceSub   = "1234567890"
+	conformanceNonce = "conformance-nonce"
+)
+
+// ConformanceTarget はRunConformanceで確認するid_tokenの検証処理
+type ConformanceTarget struct {
+	Issuer   string
+	ClientId string
+	// Key はVerifyが信頼する署名鍵。Verifyは鍵のkidで公開鍵を引けるようにしておく
+	Key *Key
+	// Verify はrawTokenの署名とクレームを検証し、id_tokenのnonceがnonceと一致するかを確認する
+	Verify func(ctx context.Context, rawToken string, nonce string) error
+}
+
+// conformanceCase はOIDC Coreのid_tokenの検証の要件を確認するケース
+type conformanceCase struct {
+	desc          string
+	isExpectValid bool
+	// modify は署名する前にクレームを書き換える
+	modify func(claims map[string]interface{})
+	// sign はクレームに署名する。nilの場合はtarget.Keyで署名する
+	sign func(target ConformanceTarget, claims map[string]interface{}) (string, error)
+	// nonce はVerifyに渡す期待するnonce。nilの場合はconformanceNonceを渡す
+	nonce *string
+}
+
+// RunConformance はOIDC Coreのid_tokenの検証の要件に沿ったケースをtargetで確認する
+//
+// 誤ったiss、複数のaud、期限切れ、改ざんされた署名、nonceの欠落などを受け入れた場合、もしくは正しいid_tokenを拒否した場合にテストを失敗させる
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func RunConformance(t *testing.T, target ConformanceTarget) {
+	t.Helper()
+
+	emptyNonce := ""
+	cases := []conformanceCase{
+		{desc: "valid", isExpectValid: true},
+		{desc: "wrong issuer", modify: set("iss", "https://evil.example.com")},
+		{desc: "issuer with trailing slash", modify: set("iss", target.Issuer+"/")},
+		{desc: "missing issuer", modify: remove("iss")},
+		{desc: "aud array with azp", isExpectValid: true, modify: setAll(map[string]interface{}{
+			"aud": []string{target.ClientId, "another-client"},
+			"azp": target.ClientId,
+		})},
+		{desc: "aud array with single value", isExpectValid: true, modify: set("aud", []string{target.ClientId})},
+		{desc: "aud array without client", modify: setAll(map[string]interface{}{
+			"aud": []string{"another-client", "third-client"},
+			"azp": "another-client",
+		})},
+		{desc: "aud array without azp", modify: set("aud", []string{target.ClientId, "another-client"})},
+		{desc: "azp of another client", modify: set("azp", "another-client")},
+		{desc: "wrong audience", modify: set("aud", "another-client")},
+		{desc: "missing audience", modify: remove("aud")},
+		{desc: "expired", modify: set("exp", time.Now().Add(-time.Hour).Unix())},
+		{desc: "missing exp", modify: remove("exp")},
+		{desc: "iat in the future", modify: set("iat", time.Now().Add(time.Hour).Unix())},
+		{desc: "missing iat", modify: remove("iat")},
+		{desc: "nbf in the future", modify: set("nbf", time.Now().Add(time.Hour).Unix())},
+		{desc: "missing sub", modify: remove("sub")},
+		{desc: "missing nonce claim", modify: remove("nonce")},
+		{desc: "nonce mismatch", modify: set("nonce", "another-nonce")},
+		{desc: "empty expected nonce", nonce: &emptyNonce},
+		{desc: "tampered payload", sign: signTamperedPayload},
+		{desc: "tampered signature", sign: signTamperedSignature},
+		{desc: "signed by another key with same kid", sign: signWithAnotherKey},
+		{desc: "unknown kid", sign: func(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+			return target.Key.SignWith(mustAlg(target.Key), "unknown-kid", claims)
+		}},
+		{desc: "alg none", sign: func(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+			return target.Key.SignWith("none", target.Key.Kid(), claims)
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			claims := NewClaims(target.Issuer, target.ClientId, conformanceSub)
+			claims["nonce"] = conformanceNonce
+			if c.modify != nil {
+				c.modify(claims)
+			}
+			sign := c.sign
+			if sign == nil {
+				sign = func(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+					return target.Key.Sign(claims)
+				}
+			}
+			rawToken, err := sign(target, claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce := conformanceNonce
+			if c.nonce != nil {
+				nonce = *c.nonce
+			}
+
+			err = target.Verify(context.Background(), rawToken, nonce)
+			if c.isExpectValid && err != nil {
+				t.Errorf("valid id_token was rejected: %v", err)
+			}
+			if !c.isExpectValid && err == nil {
+				t.Error("invalid id_token was accepted")
+			}
+		})
+	}
+}
+
+func set(name string, value interface{}) func(claims map[string]interface{}) {
+	return func(claims map[string]interface{}) {
+		claims[name] = value
+	}
+}
+
+func setAll(values map[string]interface{}) func(claims map[string]interface{}) {
+	return func(claims map[string]interface{}) {
+		for name, value := range values {
+			claims[name] = value
+		}
+	}
+}
+
+func remove(name string) func(claims map[string]interface{}) {
+	return func(claims map[string]interface{}) {
+		delete(claims, name)
+	}
+}
+
+func mustAlg(key *Key) string {
+	alg, _ := key.alg()
+
+	return alg
+}
+
+// signTamperedPayload は署名した後にsubを書き換えたpayloadに差し替える
+func signTamperedPayload(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+	rawToken, err := target.Key.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+	claims["sub"] = "another-user"
+	tampered, err := target.Key.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(rawToken, ".")
+	segments[1] = strings.Split(tampered, ".")[1]
+
+	return strings.Join(segments, "."), nil
+}
+
+// signTamperedSignature は署名の先頭のバイトを反転させる
+func signTamperedSignature(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+	rawToken, err := target.Key.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(rawToken, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return "", err
+	}
+	signature[0] ^= 0xff
+	segments[2] = base64.RawURLEncoding.EncodeToString(signature)
+
+	return strings.Join(segments, "."), nil
+}
+
+// signWithAnotherKey はtarget.Keyと同じ種類の別の鍵で、target.Keyのkidを付けて署名する
+func signWithAnotherKey(target ConformanceTarget, claims map[string]interface{}) (string, error) {
+	another, err := newKeyLike(target.Key)
+	if err != nil {
+		return "", err
+	}
+
+	return another.SignWith(mustAlg(target.Key), target.Key.Kid(), claims)
+}
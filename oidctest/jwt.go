@@ -0,0 +1,134 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var errAlgKeyMismatch = errors.New("signing algorithm does not match key")
+
+// algHashes は署名アルゴリズムごとのハッシュ関数
+var algHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// NewClaims はissuerがaudienceに発行したsubのid_tokenのクレームを返す。iatは現在時刻、expは1時間後になる
+//
+// 検証の失敗を確認する場合は、返したmapのクレームを書き換えてからSignに渡す
+func NewClaims(issuer string, audience string, sub string) map[string]interface{} {
+	now := time.Now()
+
+	return map[string]interface{}{
+		"iss": issuer,
+		"aud": audience,
+		"sub": sub,
+		"iat": now.Unix(),
+		"exp": now.Add(defaultTokenTtl).Unix(),
+	}
+}
+
+// Sign は既定の署名アルゴリズムと鍵のkidでclaimsをpayloadとするJWTに署名する
+func (k *Key) Sign(claims interface{}) (string, error) {
+	alg, err := k.alg()
+	if err != nil {
+		return "", err
+	}
+
+	return k.SignWith(alg, k.kid, claims)
+}
+
+// SignWith はalgとkidをheaderに指定してclaimsをpayloadとするJWTに署名する
+//
+// kidを鍵と異なる値にしたり、RSA鍵でPS256を使ったりする場合に使う。algがnoneの場合は署名のないJWTを返す。
+// JwksのJWKのalgは既定の署名アルゴリズムになるので、別のalgで検証する場合はoidc.StaticKeysに公開鍵を渡す
+func (k *Key) SignWith(alg string, kid string, claims interface{}) (string, error) {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal header: %w", err)
+	}
+	rawPayload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+
+	signingInput := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString(rawHeader),
+		base64.RawURLEncoding.EncodeToString(rawPayload),
+	}, ".")
+	signature, err := k.signature(alg, signingInput)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (k *Key) signature(alg string, signingInput string) ([]byte, error) {
+	if alg == "none" {
+		return nil, nil
+	}
+	if alg == "EdDSA" {
+		key, ok := k.key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errAlgKeyMismatch, alg)
+		}
+
+		return ed25519.Sign(key, []byte(signingInput)), nil
+	}
+
+	hash, ok := algHashes[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errAlgKeyMismatch, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch key := k.key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.SignPSS(rand.Reader, key, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign: %w", err)
+		}
+		// JWSではASN.1ではなくrとsを固定長で連結する
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+
+		return signature, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errAlgKeyMismatch, alg)
+}
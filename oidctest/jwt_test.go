@@ -0,0 +1,111 @@
+package oidctest
+
+import (
+	"context"
+	"crypto/elliptic"
+	"github.com/stretchr/testify/assert"
+	"sns-login/oidc"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://op.example.com"
+	testClientId = "DummyClientId"
+)
+
+// verifyFuncForTest はkeyProviderの公開鍵でid_tokenを検証する関数を返す
+func verifyFuncForTest(t *testing.T, keyProvider oidc.KeyProvider) func(rawToken string) error {
+	client, err := oidc.New(
+		testIssuer,
+		oidc.WithClientId(testClientId),
+		oidc.WithEndpoints(testIssuer+"/authorize", testIssuer+"/token", testIssuer+"/jwks"),
+		oidc.WithKeyProvider(keyProvider),
+		oidc.WithAllowedAlgs("RS256", "PS256", "ES256", "ES384"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func(rawToken string) error {
+		_, err := client.Verifier().VerifyRawToken(context.Background(), rawToken)
+
+		return err
+	}
+}
+
+func TestSigningKey_Sign(t *testing.T) {
+	rsaKey, err := NewRsaKey("rsa-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := NewEcKey("ec-key", elliptic.P384())
+	if err != nil {
+		t.Fatal(err)
+	}
+	anotherKey, err := NewRsaKey("another-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawJwks, err := Jwks(rsaKey, ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := oidc.NewKeySet(rawJwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyWithJwks := verifyFuncForTest(t, keySet)
+	verifyWithStaticKeys := verifyFuncForTest(t, oidc.StaticKeys{"rsa-key": rsaKey.Public(), "ec-key": ecKey.Public()})
+
+	expired := NewClaims(testIssuer, testClientId, "1234567890")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	patterns := []struct {
+		desc        string
+		verify      func(rawToken string) error
+		key         *Key
+		alg         string
+		kid         string
+		claims      map[string]interface{}
+		expectedErr error
+	}{
+		{"RS256 from jwks", verifyWithJwks, rsaKey, "RS256", "rsa-key", NewClaims(testIssuer, testClientId, "1234567890"), nil},
+		{"ES384 from jwks", verifyWithJwks, ecKey, "ES384", "ec-key", NewClaims(testIssuer, testClientId, "1234567890"), nil},
+		{"PS256 with static key", verifyWithStaticKeys, rsaKey, "PS256", "rsa-key", NewClaims(testIssuer, testClientId, "1234567890"), nil},
+		{"unknown kid", verifyWithJwks, rsaKey, "RS256", "unknown", NewClaims(testIssuer, testClientId, "1234567890"), oidc.ErrJwkNotFound},
+		{"signed by another key", verifyWithJwks, anotherKey, "RS256", "rsa-key", NewClaims(testIssuer, testClientId, "1234567890"), oidc.ErrSignatureInvalid},
+		{"another issuer", verifyWithJwks, rsaKey, "RS256", "rsa-key", NewClaims("https://evil.example.com", testClientId, "1234567890"), oidc.ErrInvalidIssuer},
+		{"expired", verifyWithJwks, rsaKey, "RS256", "rsa-key", expired, oidc.ErrTokenExpired},
+	}
+
+	for _, pattern := range patterns {
+		rawToken, err := pattern.key.SignWith(pattern.alg, pattern.kid, pattern.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = pattern.verify(rawToken)
+
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		}
+	}
+}
+
+func TestSigningKey_SignWith_Error(t *testing.T) {
+	rsaKey, err := NewRsaKey("rsa-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = rsaKey.SignWith("ES256", "rsa-key", map[string]interface{}{})
+	assert.ErrorIs(t, err, errAlgKeyMismatch)
+	_, err = rsaKey.SignWith("EdDSA", "rsa-key", map[string]interface{}{})
+	assert.ErrorIs(t, err, errAlgKeyMismatch)
+
+	rawToken, err := rsaKey.SignWith("none", "", map[string]interface{}{"sub": "1234567890"})
+	assert.Nil(t, err)
+	assert.Regexp(t, `\.$`, rawToken)
+}
@@ -0,0 +1,176 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const rsaKeyBits = 2048
+
+var errUnsupportedKey = errors.New("unsupported signing key")
+
+// jwk はJWKsで公開する公開鍵
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Key はkidと共に保持するテスト用の署名鍵
+type Key struct {
+	kid string
+	key crypto.Signer
+}
+
+// NewRsaKey はkidの2048ビットのRSA鍵を生成する。既定の署名アルゴリズムはRS256
+func NewRsaKey(kid string) (*Key, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	return &Key{kid: kid, key: key}, nil
+}
+
+// NewEcKey はkidのECDSA鍵をcurveで生成する。既定の署名アルゴリズムはP-256がES256、P-384がES384、P-521がES512
+func NewEcKey(kid string, curve elliptic.Curve) (*Key, error) {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate EC key: %w", err)
+	}
+	signing := &Key{kid: kid, key: key}
+	if _, err := signing.alg(); err != nil {
+		return nil, err
+	}
+
+	return signing, nil
+}
+
+// NewKey は既存の鍵をkidの署名鍵にする。RSA、ECDSA(P-256、P-384、P-521)、Ed25519の鍵に対応する
+func NewKey(kid string, key crypto.Signer) (*Key, error) {
+	signing := &Key{kid: kid, key: key}
+	if _, err := signing.alg(); err != nil {
+		return nil, err
+	}
+
+	return signing, nil
+}
+
+// Kid は鍵のkidを返す
+func (k *Key) Kid() string {
+	return k.kid
+}
+
+// Public は署名の検証に使う公開鍵を返す
+func (k *Key) Public() crypto.PublicKey {
+	return k.key.Public()
+}
+
+// Signer は秘密鍵を返す
+func (k *Key) Signer() crypto.Signer {
+	return k.key
+}
+
+// Jwks はkeysの公開鍵をJWKsのJSONにする
+//
+// oidc.NewKeySetに渡したり、httptestのサーバーからJWKsエンドポイントとして返したりできる
+func Jwks(keys ...*Key) ([]byte, error) {
+	publicKeys := make([]jwk, 0, len(keys))
+	for _, key := range keys {
+		publicKey, err := key.jwk()
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	raw, err := json.Marshal(map[string]interface{}{"keys": publicKeys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal jwks: %w", err)
+	}
+
+	return raw, nil
+}
+
+// alg は鍵の種類から既定の署名アルゴリズムを返す
+func (k *Key) alg() (string, error) {
+	switch key := k.key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+	case ed25519.PrivateKey:
+		return "EdDSA", nil
+	}
+
+	return "", errUnsupportedKey
+}
+
+// jwk は公開鍵を既定の署名アルゴリズムのJWKにする
+func (k *Key) jwk() (jwk, error) {
+	alg, err := k.alg()
+	if err != nil {
+		return jwk{}, err
+	}
+
+	key := jwk{Kid: k.kid, Use: "sig", Alg: alg}
+	switch pub := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = pub.Curve.Params().Name
+		key.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		key.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return key, nil
+}
+
+// newKeyLike はkeyと同じ種類の新しい鍵を生成する
+func newKeyLike(key *Key) (*Key, error) {
+	switch k := key.key.(type) {
+	case *rsa.PrivateKey:
+		return NewRsaKey(key.kid)
+	case *ecdsa.PrivateKey:
+		return NewEcKey(key.kid, k.Curve)
+	case ed25519.PrivateKey:
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+
+		return NewKey(key.kid, edKey)
+	}
+
+	return nil, errUnsupportedKey
+}
@@ -0,0 +1,387 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClientId と DefaultClientSecret はNewServerで作ったサーバーに登録されているクライアントの認証情報
+	DefaultClientId     = "oidctest-client"
+	DefaultClientSecret = "oidctest-secret"
+	// DefaultKeyId はNewServerで生成したRSA鍵のkid
+	DefaultKeyId    = "oidctest-key"
+	defaultTokenTtl = time.Hour
+)
+
+var (
+	errKeyNotFound    = errors.New("signing key not found")
+	errNoRedirect     = errors.New("authorization endpoint did not redirect")
+	errKeyIdDuplicate = errors.New("key id is already registered")
+)
+
+// server はDiscovery、JWKs、認可、トークン、UserInfoのエンドポイントを持つテスト用のOpenID Provider
+//
+// 認可エンドポイントはユーザーの操作なしに常に認可コードを発行してリダイレクトする。
+// 実際のIdPの認証情報を使わずにログインのフローの結合テストを書く場合に使う
+type server struct {
+	*httptest.Server
+	// Issuer はid_tokenのissとDiscoveryドキュメントのissuer。サーバーのURLになる
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// Claims はid_tokenとUserInfoのレスポンスに含めるユーザーのクレーム。iss、aud、exp、iatなどは上書きされる
+	Claims map[string]interface{}
+	// TokenTtl は発行するid_tokenの有効期間
+	TokenTtl time.Duration
+
+	mu sync.Mutex
+	// keys はJWKsエンドポイントで公開する鍵。先頭の鍵でid_tokenに署名する
+	keys         []*Key
+	codes        map[string]authorization
+	accessTokens map[string]map[string]interface{}
+}
+
+// authorization は認可コードを発行した認可リクエストの内容
+type authorization struct {
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// NewServer はRSA鍵を生成してテスト用のOpenID Providerを起動する。使い終わったらCloseを呼ぶ
+func NewServer() (*server, error) {
+	key, err := NewRsaKey(DefaultKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &server{
+		ClientId:     DefaultClientId,
+		ClientSecret: DefaultClientSecret,
+		Claims:       map[string]interface{}{"sub": "1234567890", "email": "user@example.com", "email_verified": true},
+		TokenTtl:     defaultTokenTtl,
+		keys:         []*Key{key},
+		codes:        map[string]authorization{},
+		accessTokens: map[string]map[string]interface{}{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/jwks", s.handleJwks)
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/userinfo", s.handleUserInfo)
+	s.Server = httptest.NewServer(mux)
+	s.Issuer = s.Server.URL
+
+	return s, nil
+}
+
+// AddKey はkeyをkidでJWKsエンドポイントに公開する。MintIdTokenWithKeyでこの鍵を使って署名できる
+//
+// RSA、ECDSA(P-256、P-384、P-521)、Ed25519の鍵に対応する
+func (s *server) AddKey(kid string, key crypto.Signer) error {
+	newKey, err := NewKey(kid, key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.kid == kid {
+			return fmt.Errorf("%w: %s", errKeyIdDuplicate, kid)
+		}
+	}
+	s.keys = append(s.keys, newKey)
+
+	return nil
+}
+
+// MintIdToken はDefaultKeyIdの鍵でclaimsを含むid_tokenに署名する
+//
+// iss、aud、sub、iat、expは既定値が入り、claimsで指定したものが優先される
+func (s *server) MintIdToken(claims map[string]interface{}) (string, error) {
+	return s.MintIdTokenWithKey(DefaultKeyId, claims)
+}
+
+// MintIdTokenWithKey はAddKeyで追加したkidの鍵でclaimsを含むid_tokenに署名する
+func (s *server) MintIdTokenWithKey(kid string, claims map[string]interface{}) (string, error) {
+	key, err := s.key(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Sign(s.idTokenClaims(claims))
+}
+
+// Authorize はブラウザの代わりにauthUrlの認可エンドポイントにアクセスし、リダイレクト先のコールバックのURLを返す
+//
+// 返したURLのクエリにはcodeとstate、もしくはerrorが含まれる
+func (s *server) Authorize(authUrl string) (*url.URL, error) {
+	client := s.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(authUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET authorization endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("%w: status %d", errNoRedirect, resp.StatusCode)
+	}
+
+	return resp.Location()
+}
+
+func (s *server) key(kid string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.kid == kid {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errKeyNotFound, kid)
+}
+
+// idTokenClaims は既定のクレームとClaimsにextraを重ねたid_tokenのクレームを返す
+func (s *server) idTokenClaims(extra map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": s.Issuer,
+		"aud": s.ClientId,
+		"iat": now.Unix(),
+		"exp": now.Add(s.TokenTtl).Unix(),
+	}
+	for name, value := range s.Claims {
+		claims[name] = value
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func (s *server) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.Issuer,
+		"authorization_endpoint":                s.Issuer + "/authorize",
+		"token_endpoint":                        s.Issuer + "/token",
+		"userinfo_endpoint":                     s.Issuer + "/userinfo",
+		"jwks_uri":                              s.Issuer + "/jwks",
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256", "ES256", "ES384", "ES512", "EdDSA"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_post", "client_secret_basic"},
+	})
+}
+
+func (s *server) handleJwks(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	raw, err := Jwks(s.keys...)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(raw)
+}
+
+// handleAuthorize は認可リクエストを確認し、ユーザーの同意なしに認可コードを発行してredirect_uriにリダイレクトする
+func (s *server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectUri, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectUri.IsAbs() || query.Get("client_id") != s.ClientId {
+		// redirect_uriやclient_idが不正な場合はリダイレクトせずにエラーを表示する
+		http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+
+		return
+	}
+
+	callbackQuery := redirectUri.Query()
+	callbackQuery.Set("state", query.Get("state"))
+	switch {
+	case query.Get("response_type") != "code":
+		callbackQuery.Set("error", "unsupported_response_type")
+	case query.Get("code_challenge") != "" && query.Get("code_challenge_method") != "S256":
+		callbackQuery.Set("error", "invalid_request")
+		callbackQuery.Set("error_description", "code_challenge_method must be S256")
+	default:
+		code, err := randomString()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+		s.mu.Lock()
+		s.codes[code] = authorization{
+			redirectUri:   redirectUri.String(),
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			claims:        s.idTokenClaims(nil),
+		}
+		s.mu.Unlock()
+		callbackQuery.Set("code", code)
+	}
+	redirectUri.RawQuery = callbackQuery.Encode()
+
+	http.Redirect(w, r, redirectUri.String(), http.StatusFound)
+}
+
+// handleToken は認可コードを一度だけid_tokenとアクセストークンに交換する
+//
+// refs: https://openid.net/specs/openid-connect-core-1_0.html#TokenEndpoint
+func (s *server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+
+		return
+	}
+	if !s.authenticateClient(r) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != "authorization_code" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", grantType)
+
+		return
+	}
+
+	s.mu.Lock()
+	authz, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+	if !ok || authz.redirectUri != r.PostForm.Get("redirect_uri") {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid code or redirect_uri")
+
+		return
+	}
+	if authz.codeChallenge != "" && !verifyCodeChallenge(authz.codeChallenge, r.PostForm.Get("code_verifier")) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+
+		return
+	}
+
+	claims := authz.claims
+	if authz.nonce != "" {
+		claims["nonce"] = authz.nonce
+	}
+	idToken, err := s.MintIdToken(claims)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+
+		return
+	}
+	accessToken, err := randomString()
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+
+		return
+	}
+	s.mu.Lock()
+	s.accessTokens[accessToken] = claims
+	s.mu.Unlock()
+
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(s.TokenTtl / time.Second),
+		"scope":        "openid email profile",
+		"id_token":     idToken,
+	})
+}
+
+// authenticateClient はclient_secret_basicとclient_secret_postのどちらかでクライアントを認証する
+func (s *server) authenticateClient(r *http.Request) bool {
+	clientId, secret, ok := r.BasicAuth()
+	if ok {
+		// client_secret_basicではclient_idとclient_secretをURLエンコードしてから送る
+		clientId, _ = url.QueryUnescape(clientId)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientId, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	return clientId == s.ClientId && subtle.ConstantTimeCompare([]byte(secret), []byte(s.ClientSecret)) == 1
+}
+
+// handleUserInfo はアクセストークンを発行したときのクレームを返す
+func (s *server) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	claims, ok := s.accessTokens[accessToken]
+	s.mu.Unlock()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	userInfo := map[string]interface{}{}
+	for name, value := range claims {
+		switch name {
+		case "iss", "aud", "iat", "exp", "nonce":
+			continue
+		}
+		userInfo[name] = value
+	}
+	writeJson(w, http.StatusOK, userInfo)
+}
+
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	digest := sha256.Sum256([]byte(verifier))
+
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(digest[:])), []byte(challenge)) == 1
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJson(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
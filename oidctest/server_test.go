@@ -0,0 +1,166 @@
+package oidctest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"sns-login/oidc"
+	"testing"
+	"time"
+)
+
+const testRedirectUrl = "https://rp.example.com/callback"
+
+func newServerForTest(t *testing.T) *server {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestServer_Login(t *testing.T) {
+	s := newServerForTest(t)
+	s.Claims["name"] = "Test User"
+	client, err := oidc.NewFromIssuer(
+		context.Background(),
+		s.Issuer,
+		oidc.WithClientId(s.ClientId),
+		oidc.WithClientSecret(s.ClientSecret),
+		oidc.WithRedirectUrl(testRedirectUrl),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loginUrl, pkce, err := client.LoginUrl("DummyState", "DummyNonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := s.Authorize(loginUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "DummyState", callback.Query().Get("state"))
+	code := callback.Query().Get("code")
+
+	user, err := client.Login(context.Background(), code, "DummyNonce", oidc.WithCodeVerifier(pkce.Verifier))
+	assert.Nil(t, err)
+	assert.Equal(t, "1234567890", user.Sub)
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.Equal(t, "Test User", user.Name)
+
+	info, err := client.UserInfo(context.Background(), user.Token.AccessToken, user.Token.IdToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234567890", info.StandardClaims().Sub)
+
+	// 認可コードは一度しか使えない
+	_, err = client.Login(context.Background(), code, "DummyNonce", oidc.WithCodeVerifier(pkce.Verifier))
+	var oauthErr *oidc.OAuthError
+	assert.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestServer_Login_Error(t *testing.T) {
+	s := newServerForTest(t)
+	client, err := oidc.NewFromIssuer(
+		context.Background(),
+		s.Issuer,
+		oidc.WithClientId(s.ClientId),
+		oidc.WithClientSecret(s.ClientSecret),
+		oidc.WithRedirectUrl(testRedirectUrl),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkce, err := oidc.NewPkce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(nonce string, codeVerifier string) error {
+		loginUrl, _, err := client.LoginUrl("DummyState", "DummyNonce", oidc.WithCodeChallenge(pkce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		callback, err := s.Authorize(loginUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Login(context.Background(), callback.Query().Get("code"), nonce, oidc.WithCodeVerifier(codeVerifier))
+
+		return err
+	}
+
+	assert.ErrorIs(t, login("AnotherNonce", pkce.Verifier), oidc.ErrNonceMismatch)
+
+	var oauthErr *oidc.OAuthError
+	assert.ErrorAs(t, login("DummyNonce", "AnotherVerifier"), &oauthErr)
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestServer_MintIdTokenWithKey(t *testing.T) {
+	s := newServerForTest(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, s.AddKey("ec-key", ecKey))
+	assert.Nil(t, s.AddKey("ed-key", edKey))
+	assert.Error(t, s.AddKey("ec-key", ecKey))
+
+	client, err := oidc.NewFromIssuer(
+		context.Background(),
+		s.Issuer,
+		oidc.WithClientId(s.ClientId),
+		oidc.WithClientSecret(s.ClientSecret),
+		oidc.WithRedirectUrl(testRedirectUrl),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AllowedAlgs = []string{"RS256", "ES256", "EdDSA"}
+
+	patterns := []struct {
+		desc        string
+		kid         string
+		claims      map[string]interface{}
+		expectedErr error
+	}{
+		{"RSA key", DefaultKeyId, nil, nil},
+		{"EC key", "ec-key", map[string]interface{}{"sub": "another-user"}, nil},
+		{"Ed25519 key", "ed-key", nil, nil},
+		{"expired", "ec-key", map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}, oidc.ErrTokenExpired},
+		{"another audience", DefaultKeyId, map[string]interface{}{"aud": "another-client"}, oidc.ErrInvalidAudience},
+	}
+
+	for _, pattern := range patterns {
+		rawToken, err := s.MintIdTokenWithKey(pattern.kid, pattern.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := oidc.NewIdToken(rawToken, oidc.GenericOidc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Verifier().Verify(context.Background(), token)
+
+		if pattern.expectedErr == nil {
+			assert.Nil(t, err, pattern.desc)
+		} else {
+			assert.ErrorIs(t, err, pattern.expectedErr, pattern.desc)
+		}
+	}
+
+	_, err = s.MintIdTokenWithKey("unknown", nil)
+	assert.ErrorIs(t, err, errKeyNotFound)
+}
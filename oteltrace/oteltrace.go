@@ -0,0 +1,52 @@
+// Package oteltrace はoidcのスパンをOpenTelemetryで作成するTracerを管理します
+package oteltrace
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"sns-login/oidc"
+)
+
+// tracerName はスパンを作成するTracerの名前
+const tracerName = "sns-login/oidc"
+
+var _ oidc.Tracer = (*tracer)(nil)
+
+// tracer はOpenTelemetryのTracerProviderでスパンを作成するoidc.Tracer
+type tracer struct {
+	tp trace.TracerProvider
+}
+
+// NewTracer はtpでスパンを作成するoidc.Tracerを返す。oidcClientのTracerに設定する
+//
+// tpがnilの場合はotel.SetTracerProviderで設定したグローバルのTracerProviderを使う
+func NewTracer(tp trace.TracerProvider) *tracer {
+	return &tracer{tp: tp}
+}
+
+// StartSpan はctxのスパンの子としてSpanKindClientのスパンを開始する
+//
+// 終了する関数にエラーを渡すと、スパンにエラーを記録してステータスをErrorにする
+func (t *tracer) StartSpan(ctx context.Context, name string, attrs ...oidc.SpanAttribute) (context.Context, func(err error)) {
+	tp := t.tp
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, attribute.String(attr.Key, attr.Value))
+	}
+
+	ctx, span := tp.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(kvs...))
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
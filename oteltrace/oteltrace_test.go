@@ -0,0 +1,51 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"sns-login/oidc"
+	"testing"
+)
+
+func TestTracer_StartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(tp)
+
+	// 呼び出し元のスパンの子としてスパンを作成する
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "callback")
+	_, endVerify := tracer.StartSpan(ctx, "oidc.verify", oidc.SpanAttribute{Key: "jwt.alg", Value: "RS256"})
+	endVerify(nil)
+	_, endToken := tracer.StartSpan(ctx, "oidc.token")
+	endToken(errors.New("invalid_grant"))
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	patterns := []struct {
+		desc           string
+		span           sdktrace.ReadOnlySpan
+		expectedName   string
+		expectedStatus codes.Code
+	}{
+		{"success", spans[0], "oidc.verify", codes.Unset},
+		{"failure", spans[1], "oidc.token", codes.Error},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.desc, func(t *testing.T) {
+			assert.Equal(t, pattern.expectedName, pattern.span.Name())
+			assert.Equal(t, trace.SpanKindClient, pattern.span.SpanKind())
+			assert.Equal(t, parent.SpanContext().SpanID(), pattern.span.Parent().SpanID())
+			assert.Equal(t, pattern.expectedStatus, pattern.span.Status().Code)
+		})
+	}
+	assert.Equal(t, []attribute.KeyValue{attribute.String("jwt.alg", "RS256")}, spans[0].Attributes())
+}
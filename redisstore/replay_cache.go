@@ -0,0 +1,44 @@
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sns-login/oidc"
+	"time"
+)
+
+const defaultReplayKeyPrefix = "sns-login:jti:"
+
+var _ oidc.ReplayCache = (*replayCache)(nil)
+
+// replayCache は検証済みのid_tokenのjtiをRedisに記録するReplayCache
+type replayCache struct {
+	// KeyPrefix は保存するキーの接頭辞
+	KeyPrefix string
+	client    redis.UniversalClient
+	now       func() time.Time
+}
+
+// NewReplayCache はclientのRedisにjtiを記録するReplayCacheを返す
+//
+// 複数のインスタンスで同じRedisを使うことで、どのインスタンスでもトークンの再利用を検出できる
+func NewReplayCache(client redis.UniversalClient) *replayCache {
+	return &replayCache{KeyPrefix: defaultReplayKeyPrefix, client: client, now: time.Now}
+}
+
+// Add はSET NXで記録する。同時に同じkeyで呼ばれても、記録できるのは1回だけになる
+func (c *replayCache) Add(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	ttl := expiresAt.Sub(c.now())
+	if ttl <= 0 {
+		// 期限切れのトークンはclaimsの検証で拒否されるので、記録する必要はない
+		return true, nil
+	}
+
+	ok, err := c.client.SetNX(ctx, c.KeyPrefix+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record jti to redis: %w", err)
+	}
+
+	return ok, nil
+}
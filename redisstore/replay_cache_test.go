@@ -0,0 +1,28 @@
+package redisstore
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReplayCache_Add(t *testing.T) {
+	server := miniredis.RunT(t)
+	cache := NewReplayCache(redisClientForTest(server))
+
+	ok, err := cache.Add(context.Background(), "jti-1", time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = cache.Add(context.Background(), "jti-1", time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// expiresAtを過ぎるとRedisから削除され、同じkeyを記録できる
+	server.FastForward(time.Minute)
+	ok, err = cache.Add(context.Background(), "jti-1", time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,104 @@
+// Package redisstore はセッション、ログイン中の状態、id_tokenのjtiをRedisに保存するストレージを管理します
+package redisstore
+
+import (
+	"context"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sns-login/auth"
+	"time"
+)
+
+const defaultSessionKeyPrefix = "sns-login:session:"
+
+var _ auth.SessionStore = (*sessionStore)(nil)
+
+// sessionStore はセッションをRedisに保存するSessionStore
+//
+// 複数のインスタンスで同じRedisを使うことでセッションを共有する。期限切れのセッションはRedisのTTLで削除される
+type sessionStore struct {
+	// KeyPrefix はセッションを保存するキーの接頭辞
+	KeyPrefix string
+	// Sliding はGetのたびにセッションの有効期限をSetで指定したttlだけ延長するかどうか
+	Sliding bool
+	// Cipher を設定するとトークンを含むセッションの内容を暗号化して保存する
+	Cipher cipher.AEAD
+	client redis.UniversalClient
+	now    func() time.Time
+}
+
+// NewSessionStore はclientのRedisにセッションを保存するSessionStoreを返す
+func NewSessionStore(client redis.UniversalClient) *sessionStore {
+	return &sessionStore{KeyPrefix: defaultSessionKeyPrefix, client: client, now: time.Now}
+}
+
+func (s *sessionStore) Get(ctx context.Context, id string) (*auth.Session, error) {
+	data, err := s.client.Get(ctx, s.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session from redis: %w", err)
+	}
+
+	session, ttl, err := auth.DecodeSession(id, data, s.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	// RedisのTTLより先にExpiresAtが過ぎた場合も期限切れとして扱う
+	if !s.now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+
+	if s.Sliding && ttl > 0 {
+		session.ExpiresAt = s.now().Add(ttl)
+		if err := s.save(ctx, session, ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+func (s *sessionStore) Set(ctx context.Context, session *auth.Session, ttl time.Duration) error {
+	stored := *session
+	stored.ExpiresAt = s.now().Add(ttl)
+
+	return s.save(ctx, &stored, ttl)
+}
+
+func (s *sessionStore) Delete(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, s.key(id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete session from redis: %w", err)
+	}
+
+	return nil
+}
+
+// GC はRedisがTTLで期限切れのセッションを削除するので何もしない
+func (s *sessionStore) GC(_ context.Context) error {
+	return nil
+}
+
+// save はsessionをttlの間保存する。Redisはttlが0以下の場合に期限なしで保存するので、その場合は削除する
+func (s *sessionStore) save(ctx context.Context, session *auth.Session, ttl time.Duration) error {
+	if ttl <= 0 {
+		return s.Delete(ctx, session.Id)
+	}
+
+	data, err := auth.EncodeSession(session, ttl, s.Cipher)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, s.key(session.Id), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save session to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sessionStore) key(id string) string {
+	return s.KeyPrefix + id
+}
@@ -0,0 +1,141 @@
+package redisstore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"sns-login/auth"
+	"sns-login/oidc"
+	"testing"
+	"time"
+)
+
+// rawIdTokenForTest は署名を検証しないテスト用のid_tokenを返す
+func rawIdTokenForTest() string {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(`{"sub":"1234567890"}`)) + "." + encode([]byte("signature"))
+}
+
+func sessionForTest(t *testing.T) *auth.Session {
+	idToken, err := oidc.NewIdToken(rawIdTokenForTest(), oidc.GenericOidc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &auth.Session{Id: "session-1", User: &oidc.User{
+		IdProvider: oidc.GenericOidc,
+		Sub:        "1234567890",
+		Email:      "user@example.com",
+		Token:      &oidc.Token{AccessToken: "DummyAccessToken", RefreshToken: "DummyRefreshToken", IdToken: idToken},
+	}}
+}
+
+func redisClientForTest(server *miniredis.Miniredis) *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: server.Addr()})
+}
+
+func newSessionStoreForTest(t *testing.T) (*sessionStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	store := NewSessionStore(redisClientForTest(server))
+
+	return store, server
+}
+
+func TestSessionStore(t *testing.T) {
+	ctx := context.Background()
+	store, server := newSessionStoreForTest(t)
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Hour))
+	assert.Equal(t, time.Hour, server.TTL(defaultSessionKeyPrefix+"session-1"))
+
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "user@example.com", session.User.Email)
+	assert.Equal(t, "DummyRefreshToken", session.User.Token.RefreshToken)
+	assert.Equal(t, rawIdTokenForTest(), session.User.Token.IdToken.Raw())
+	assert.Equal(t, "1234567890", session.User.Token.IdToken.StandardClaims().Sub)
+
+	session, err = store.Get(ctx, "unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	// RedisのTTLで期限切れのセッションが削除される
+	server.FastForward(time.Hour)
+	session, err = store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Hour))
+	assert.Nil(t, store.Delete(ctx, "session-1"))
+	assert.False(t, server.Exists(defaultSessionKeyPrefix+"session-1"))
+}
+
+func TestSessionStore_Sliding(t *testing.T) {
+	ctx := context.Background()
+	store, server := newSessionStoreForTest(t)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.Sliding = true
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Hour))
+
+	now = now.Add(30 * time.Minute)
+	server.FastForward(30 * time.Minute)
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(time.Hour), session.ExpiresAt)
+	assert.Equal(t, time.Hour, server.TTL(defaultSessionKeyPrefix+"session-1"))
+}
+
+func TestSessionStore_Cipher(t *testing.T) {
+	ctx := context.Background()
+	store, server := newSessionStoreForTest(t)
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Cipher, err = cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, store.Set(ctx, sessionForTest(t), time.Hour))
+	stored, err := server.Get(defaultSessionKeyPrefix + "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, stored, "DummyRefreshToken")
+
+	session, err := store.Get(ctx, "session-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyRefreshToken", session.User.Token.RefreshToken)
+
+	// 別のセッションIDのキーにコピーされたデータは復号できない
+	if err := server.Set(defaultSessionKeyPrefix+"session-2", stored); err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, "session-2")
+	assert.Error(t, err)
+}
+
+func TestStateStore(t *testing.T) {
+	ctx := context.Background()
+	_, server := newSessionStoreForTest(t)
+	store := NewStateStore(redisClientForTest(server))
+
+	assert.Nil(t, store.Save(ctx, "login-1", &auth.LoginState{State: "DummyState", CodeVerifier: "DummyVerifier"}, time.Minute))
+	assert.Equal(t, time.Minute, server.TTL(defaultStateKeyPrefix+"login-1"))
+
+	state, err := store.Consume(ctx, "login-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "DummyVerifier", state.CodeVerifier)
+
+	state, err = store.Consume(ctx, "login-1")
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+}
@@ -0,0 +1,57 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sns-login/auth"
+	"time"
+)
+
+const defaultStateKeyPrefix = "sns-login:state:"
+
+var _ auth.StateStore = (*stateStore)(nil)
+
+// stateStore はログイン中の状態をRedisに保存するStateStore
+type stateStore struct {
+	// KeyPrefix は保存するキーの接頭辞
+	KeyPrefix string
+	client    redis.UniversalClient
+}
+
+// NewStateStore はclientのRedisにログイン中の状態を保存するStateStoreを返す
+func NewStateStore(client redis.UniversalClient) *stateStore {
+	return &stateStore{KeyPrefix: defaultStateKeyPrefix, client: client}
+}
+
+func (s *stateStore) Save(ctx context.Context, id string, state *auth.LoginState, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal login state: %w", err)
+	}
+	if err := s.client.Set(ctx, s.KeyPrefix+id, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save login state to redis: %w", err)
+	}
+
+	return nil
+}
+
+// Consume はGETDELで取り出す。同時に同じIDでコールバックされても、取り出せるのは1回だけになる
+func (s *stateStore) Consume(ctx context.Context, id string) (*auth.LoginState, error) {
+	data, err := s.client.GetDel(ctx, s.KeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login state from redis: %w", err)
+	}
+
+	state := &auth.LoginState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal login state: %w", err)
+	}
+
+	return state, nil
+}